@@ -0,0 +1,60 @@
+package domaindataset
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (DatasetConnector) TableName() string {
+	return "dataset_connectors"
+}
+
+// DatasetConnector fetches a dataset file from an HTTP endpoint on a cron schedule.
+type DatasetConnector struct {
+	Id              string     `json:"id" gorm:"column:id;primaryKey"`
+	Name            string     `json:"name" gorm:"column:name"`
+	URL             string     `json:"url" gorm:"column:url"`                 // may contain {period_date}, {period_month} and {period_year}
+	AuthHeader      string     `json:"auth_header" gorm:"column:auth_header"` // e.g. Authorization
+	AuthValue       string     `json:"-" gorm:"column:auth_value"`
+	Format          string     `json:"format" gorm:"column:format"` // xlsx, csv or json
+	DatasetType     string     `json:"dataset_type" gorm:"column:dataset_type"`
+	PeriodFrequency string     `json:"period_frequency" gorm:"column:period_frequency"`
+	PeriodLagDays   int        `json:"period_lag_days" gorm:"column:period_lag_days"` // the period is the run date minus this many days
+	Schedule        string     `json:"schedule" gorm:"column:schedule"`               // cron expression
+	Active          bool       `json:"active" gorm:"column:active"`
+	NextRunAt       *time.Time `json:"next_run_at,omitempty" gorm:"column:next_run_at"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty" gorm:"column:last_run_at"`
+	LastRunStatus   *string    `json:"last_run_status,omitempty" gorm:"column:last_run_status"`
+
+	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string         `json:"updated_by" gorm:"column:updated_by"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletedBy string         `json:"-"`
+}
+
+// HasAuth reports whether the connector sends an authentication header.
+func (c DatasetConnector) HasAuth() bool {
+	return c.AuthHeader != "" && c.AuthValue != ""
+}
+
+func (DatasetConnectorRun) TableName() string {
+	return "dataset_connector_runs"
+}
+
+// DatasetConnectorRun records one execution of a connector.
+type DatasetConnectorRun struct {
+	Id          string     `json:"id" gorm:"column:id;primaryKey"`
+	ConnectorId string     `json:"connector_id" gorm:"column:connector_id;index"`
+	Trigger     string     `json:"trigger" gorm:"column:trigger_type"` // SCHEDULE or MANUAL
+	Status      string     `json:"status" gorm:"column:status"`
+	HttpStatus  *int       `json:"http_status,omitempty" gorm:"column:http_status"`
+	DatasetId   *string    `json:"dataset_id,omitempty" gorm:"column:dataset_id"`
+	Message     *string    `json:"message,omitempty" gorm:"column:message"`
+	BytesRead   int64      `json:"bytes_read" gorm:"column:bytes_read"`
+	StartedAt   time.Time  `json:"started_at" gorm:"column:started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty" gorm:"column:finished_at"`
+	TriggeredBy string     `json:"triggered_by" gorm:"column:triggered_by"`
+}
@@ -18,10 +18,23 @@ type DashboardDataset struct {
 	PeriodYear      int       `json:"period_year" gorm:"column:period_year"`
 	PeriodFrequency string    `json:"period_frequency" gorm:"column:period_frequency"`
 	FileName        string    `json:"file_name" gorm:"column:file_name"`
+	FileFormat      string    `json:"file_format" gorm:"column:file_format"`   // xlsx, csv or json
+	ContentHash     string    `json:"content_hash" gorm:"column:content_hash"` // sha256 of the uploaded file
+	SupersededBy    *string   `json:"superseded_by,omitempty" gorm:"column:superseded_by"`
 	UploadedBy      string    `json:"uploaded_by" gorm:"column:uploaded_by"`
 	UploadedAt      time.Time `json:"uploaded_at" gorm:"column:uploaded_at"`
 	Status          string    `json:"status" gorm:"column:status"`
 
+	// Progress of the background import job
+	TotalRows       *int       `json:"total_rows" gorm:"column:total_rows"` // null until the whole file was read
+	ProcessedRows   int        `json:"processed_rows" gorm:"column:processed_rows"`
+	QuarantinedRows int        `json:"quarantined_rows" gorm:"column:quarantined_rows"` // rows kept aside because their Honda ID is unknown
+	ErrorMessage    *string    `json:"error_message,omitempty" gorm:"column:error_message"`
+	StartedAt       *time.Time `json:"started_at,omitempty" gorm:"column:started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty" gorm:"column:finished_at"`
+
+	History []DatasetStatusHistory `json:"history,omitempty" gorm:"foreignKey:DatasetId"`
+
 	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
 	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
 	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
@@ -29,3 +42,43 @@ type DashboardDataset struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 	DeletedBy string         `json:"-"`
 }
+
+func (DatasetStatusHistory) TableName() string {
+	return "dataset_status_history"
+}
+
+// DatasetStatusHistory records one status transition of a dataset
+type DatasetStatusHistory struct {
+	Id         string    `json:"id" gorm:"column:id;primaryKey"`
+	DatasetId  string    `json:"dataset_id" gorm:"column:dataset_id;index"`
+	FromStatus *string   `json:"from_status" gorm:"column:from_status"` // nil for the upload itself
+	ToStatus   string    `json:"to_status" gorm:"column:to_status"`
+	Reason     *string   `json:"reason,omitempty" gorm:"column:reason"`
+	ChangedBy  string    `json:"changed_by" gorm:"column:changed_by"`
+	ChangedAt  time.Time `json:"changed_at" gorm:"column:changed_at"`
+}
+
+func (DatasetRowError) TableName() string {
+	return "dashboard_dataset_errors"
+}
+
+// DatasetRowError is a validation error found on a single row of an uploaded dataset file
+type DatasetRowError struct {
+	Id        string    `json:"id" gorm:"column:id;primaryKey"`
+	DatasetId string    `json:"dataset_id" gorm:"column:dataset_id;index"`
+	RowNumber int       `json:"row_number" gorm:"column:row_number"` // 1-based row number in the sheet
+	Column    string    `json:"column" gorm:"column:column_name"`
+	Value     string    `json:"value" gorm:"column:value"`
+	Reason    string    `json:"reason" gorm:"column:reason"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// DatasetCoverage summarises the imported (DONE) datasets of one type in one period.
+type DatasetCoverage struct {
+	Type           string    `json:"type" gorm:"column:type"`
+	PeriodMonth    int       `json:"period_month" gorm:"column:period_month"`
+	PeriodYear     int       `json:"period_year" gorm:"column:period_year"`
+	DatasetIds     []string  `json:"dataset_ids" gorm:"-"`
+	PersonsCovered int       `json:"persons_covered" gorm:"column:persons_covered"` // distinct persons with at least one metric row
+	LastUploadedAt time.Time `json:"last_uploaded_at" gorm:"column:last_uploaded_at"`
+}
@@ -0,0 +1,69 @@
+package domaindataset
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuarantineValues maps a column (a sheet header or a template field) to the cell value of a row.
+type QuarantineValues map[string]string
+
+func (v QuarantineValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (v *QuarantineValues) Scan(value interface{}) error {
+	var raw []byte
+	switch val := value.(type) {
+	case nil:
+		*v = QuarantineValues{}
+		return nil
+	case []byte:
+		raw = val
+	case string:
+		raw = []byte(val)
+	default:
+		return errors.New("unsupported type for QuarantineValues")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func (DatasetQuarantineRow) TableName() string {
+	return "dataset_quarantine_rows"
+}
+
+// DatasetQuarantineRow is a dataset row whose Honda ID did not match any person at import time.
+// It is kept until an admin maps it to a person, after which it is imported into its metric table.
+type DatasetQuarantineRow struct {
+	Id               string           `json:"id" gorm:"column:id;primaryKey"`
+	DatasetId        string           `json:"dataset_id" gorm:"column:dataset_id;index"`
+	DatasetType      string           `json:"dataset_type" gorm:"column:dataset_type"`
+	PeriodDate       time.Time        `json:"period_date" gorm:"column:period_date"`
+	RowNumber        int              `json:"row_number" gorm:"column:row_number"` // 1-based row number in the sheet
+	HondaId          string           `json:"honda_id" gorm:"column:honda_id"`
+	DealerCode       *string          `json:"dealer_code,omitempty" gorm:"column:dealer_code"`
+	RawPayload       QuarantineValues `json:"raw_payload" gorm:"column:raw_payload;type:jsonb"`   // sheet header -> cell
+	FieldValues      QuarantineValues `json:"field_values" gorm:"column:field_values;type:jsonb"` // template field -> cell
+	Status           string           `json:"status" gorm:"column:status"`                        // PENDING, RESOLVED or DISCARDED
+	ResolvedPersonId *string          `json:"resolved_person_id,omitempty" gorm:"column:resolved_person_id"`
+	ResolvedAt       *time.Time       `json:"resolved_at,omitempty" gorm:"column:resolved_at"`
+	ResolvedBy       *string          `json:"resolved_by,omitempty" gorm:"column:resolved_by"`
+
+	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string         `json:"updated_by" gorm:"column:updated_by"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletedBy string         `json:"-"`
+}
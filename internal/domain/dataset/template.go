@@ -0,0 +1,61 @@
+package domaindataset
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TemplateColumns maps a logical field (e.g. "honda_id") to the header names accepted for it.
+type TemplateColumns map[string][]string
+
+func (c TemplateColumns) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (c *TemplateColumns) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*c = TemplateColumns{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("unsupported type for TemplateColumns")
+	}
+	return json.Unmarshal(raw, c)
+}
+
+func (DatasetTemplate) TableName() string {
+	return "dataset_templates"
+}
+
+// DatasetTemplate describes where the processor finds each field of a dataset type in the uploaded sheet.
+type DatasetTemplate struct {
+	Id          string          `json:"id" gorm:"column:id;primaryKey"`
+	DatasetType string          `json:"dataset_type" gorm:"column:dataset_type"`
+	SheetName   string          `json:"sheet_name" gorm:"column:sheet_name"` // empty means the first sheet
+	HeaderRow   int             `json:"header_row" gorm:"column:header_row"` // 1-based
+	Columns     TemplateColumns `json:"columns" gorm:"column:columns;type:jsonb"`
+	Active      bool            `json:"active" gorm:"column:active"`
+
+	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string         `json:"updated_by" gorm:"column:updated_by"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletedBy string         `json:"-"`
+}
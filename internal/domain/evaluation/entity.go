@@ -1,6 +1,10 @@
 package domainevaluation
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 // Evaluation represents the overall evaluation result for a person in a specific period
 type Evaluation struct {
@@ -25,6 +29,8 @@ type EvaluationDetail struct {
 	EvaluationId     string   `json:"evaluation_id" gorm:"column:evaluation_id"`
 	KpiItemId        string   `json:"kpi_item_id" gorm:"column:kpi_item_id"`
 	ActualValue      *float64 `json:"actual_value" gorm:"column:actual_value"`
+	TargetValue      *float64 `json:"target_value" gorm:"column:target_value"`   // target the KPI was scored against
+	TargetSource     string   `json:"target_source" gorm:"column:target_source"` // PERSON, DEALER or KPI, empty before targets were resolved
 	AchievementRatio *float64 `json:"achievement_ratio" gorm:"column:achievement_ratio"`
 	Score            float64  `json:"score" gorm:"column:score"`
 }
@@ -39,6 +45,11 @@ type EvaluationPeriod struct {
 	PeriodMonth int       `json:"period_month" gorm:"column:period_month"`
 	PeriodYear  int       `json:"period_year" gorm:"column:period_year"`
 	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+
+	// RequiredDatasets overrides the default required dataset types for the period; nil keeps the default
+	RequiredDatasets pq.StringArray `json:"required_datasets,omitempty" gorm:"column:required_datasets;type:text[]"`
+	UpdatedAt        *time.Time     `json:"updated_at,omitempty" gorm:"column:updated_at"`
+	UpdatedBy        *string        `json:"updated_by,omitempty" gorm:"column:updated_by"`
 }
 
 func (EvaluationPeriod) TableName() string {
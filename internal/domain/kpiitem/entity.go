@@ -1,27 +1,69 @@
 package domainkpiitem
 
 import (
+	"database/sql/driver"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// ScoringParams is the JSON object of parameters of the scoring strategy of a KPI item,
+// e.g. {"tiers": [{"min_achievement": 60, "score": 50}]} for STEPPED.
+type ScoringParams []byte
+
+func (p ScoringParams) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("{}"), nil
+	}
+	return p, nil
+}
+
+func (p *ScoringParams) UnmarshalJSON(b []byte) error {
+	*p = append((*p)[:0], b...)
+	return nil
+}
+
+func (p ScoringParams) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return "{}", nil
+	}
+	return string(p), nil
+}
+
+func (p *ScoringParams) Scan(value interface{}) error {
+	switch val := value.(type) {
+	case nil:
+		*p = nil
+	case []byte:
+		*p = append(ScoringParams(nil), val...)
+	case string:
+		*p = ScoringParams(val)
+	default:
+		return errors.New("unsupported type for ScoringParams")
+	}
+	return nil
+}
+
 func (KPIItem) TableName() string {
 	return "kpi_items"
 }
 
 type KPIItem struct {
-	Id                string   `json:"id" gorm:"column:id;primaryKey"`
-	PillarId          string   `json:"pillar_id" gorm:"column:pillar_id"`
-	Name              string   `json:"name" gorm:"column:name"`
-	Weight            float64  `json:"weight" gorm:"column:weight"` // percent 0-100
-	TargetValue       *float64 `json:"target_value,omitempty" gorm:"column:target_value"`
-	Unit              *string  `json:"unit,omitempty" gorm:"column:unit"`           // x/hari | point | ratio | percentage | etc...
-	Frequency         *string  `json:"frequency,omitempty" gorm:"column:frequency"` // DAILY | WEEKLY | MONTHLY | YEARLY
-	InputSource       string   `json:"input_source" gorm:"column:input_source"`     // ADMIN | TL | SYSTEM
-	AppliesToTL       bool     `json:"applies_to_tl" gorm:"column:applies_to_tl"`
-	AppliesToSalesman bool     `json:"applies_to_salesman" gorm:"column:applies_to_salesman"`
-	Notes             *string  `json:"notes,omitempty" gorm:"column:notes"`
+	Id                string        `json:"id" gorm:"column:id;primaryKey"`
+	PillarId          string        `json:"pillar_id" gorm:"column:pillar_id"`
+	Name              string        `json:"name" gorm:"column:name"`
+	MetricKey         *string       `json:"metric_key,omitempty" gorm:"column:metric_key"` // metric of the evaluation the KPI is scored on
+	Weight            float64       `json:"weight" gorm:"column:weight"`                   // percent 0-100
+	TargetValue       *float64      `json:"target_value,omitempty" gorm:"column:target_value"`
+	ScoringType       string        `json:"scoring_type" gorm:"column:scoring_type"`
+	ScoringParams     ScoringParams `json:"scoring_params" gorm:"column:scoring_params;type:jsonb"`
+	Unit              *string       `json:"unit,omitempty" gorm:"column:unit"`           // x/hari | point | ratio | percentage | etc...
+	Frequency         *string       `json:"frequency,omitempty" gorm:"column:frequency"` // DAILY | WEEKLY | MONTHLY | YEARLY
+	InputSource       string        `json:"input_source" gorm:"column:input_source"`     // ADMIN | TL | SYSTEM
+	AppliesToTL       bool          `json:"applies_to_tl" gorm:"column:applies_to_tl"`
+	AppliesToSalesman bool          `json:"applies_to_salesman" gorm:"column:applies_to_salesman"`
+	Notes             *string       `json:"notes,omitempty" gorm:"column:notes"`
 
 	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
 	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
@@ -31,6 +73,17 @@ type KPIItem struct {
 	DeletedBy string         `json:"-"`
 }
 
+// PersonKPITargetRow is a person KPI target together with the person and KPI item it belongs to.
+type PersonKPITargetRow struct {
+	HondaId     string  `json:"honda_id" gorm:"column:honda_id"`
+	PersonName  string  `json:"person_name" gorm:"column:person_name"`
+	KPIItemId   string  `json:"kpi_item_id" gorm:"column:kpi_item_id"`
+	KPIItemName string  `json:"kpi_item_name" gorm:"column:kpi_item_name"`
+	PeriodMonth int     `json:"period_month" gorm:"column:period_month"`
+	PeriodYear  int     `json:"period_year" gorm:"column:period_year"`
+	TargetValue float64 `json:"target_value" gorm:"column:target_value"`
+}
+
 func (PersonKPITarget) TableName() string {
 	return "person_kpi_targets"
 }
@@ -50,3 +103,23 @@ type PersonKPITarget struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 	DeletedBy string         `json:"-"`
 }
+
+func (DealerKPITarget) TableName() string {
+	return "dealer_kpi_targets"
+}
+
+// DealerKPITarget is the default target of a KPI item for the persons of a dealer, used when a person
+// has no target of their own for the period.
+type DealerKPITarget struct {
+	Id          string  `json:"id" gorm:"column:id;primaryKey"`
+	DealerCode  string  `json:"dealer_code" gorm:"column:dealer_code"`
+	KPIItemId   string  `json:"kpi_item_id" gorm:"column:kpi_item_id"`
+	TargetValue float64 `json:"target_value" gorm:"column:target_value"`
+
+	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string         `json:"updated_by" gorm:"column:updated_by"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	DeletedBy string         `json:"-"`
+}
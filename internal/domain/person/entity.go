@@ -17,7 +17,9 @@ type Person struct {
 	JobTitle   *string `json:"job_title,omitempty" gorm:"column:job_title"`
 	Role       string  `json:"role" gorm:"column:role"`
 	DealerCode *string `json:"dealer_code,omitempty" gorm:"column:dealer_code"`
-	Active     bool    `json:"active" gorm:"column:active"`
+	// SupervisorId is the TL a salesman currently reports to; SupervisorAssignment keeps the history.
+	SupervisorId *string `json:"supervisor_id,omitempty" gorm:"column:supervisor_id"`
+	Active       bool    `json:"active" gorm:"column:active"`
 
 	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
 	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
@@ -26,3 +28,23 @@ type Person struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 	DeletedBy string         `json:"-"`
 }
+
+func (SupervisorAssignment) TableName() string {
+	return "person_supervisor_assignments"
+}
+
+// SupervisorAssignment is a period during which a person reported to a supervisor. EffectiveTo is the
+// first day the assignment no longer applies, nil for the current assignment.
+type SupervisorAssignment struct {
+	Id            string     `json:"id" gorm:"column:id;primaryKey"`
+	PersonId      string     `json:"person_id" gorm:"column:person_id"`
+	SupervisorId  string     `json:"supervisor_id" gorm:"column:supervisor_id"`
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"column:effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" gorm:"column:effective_to"`
+	Reason        *string    `json:"reason,omitempty" gorm:"column:reason"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string    `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string    `json:"updated_by" gorm:"column:updated_by"`
+}
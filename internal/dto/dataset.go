@@ -1,13 +1,195 @@
 package dto
 
+import "fmt"
+
 type DatasetUploadRequest struct {
 	PeriodDate      string `form:"period_date" binding:"required"`                // YYYY-MM-DD anchor date
 	PeriodMonth     int    `form:"period_month" binding:"omitempty,min=1,max=12"` // optional derived
 	PeriodYear      int    `form:"period_year" binding:"omitempty,min=2000"`      // optional derived
 	PeriodFrequency string `form:"period_frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
 	Type            string `form:"type" binding:"required"`
+	DryRun          bool   `form:"dry_run"` // validate only, nothing is stored
 }
 
 type DatasetStatusUpdate struct {
-	Status string `json:"status" binding:"required"`
+	Status string `json:"status" binding:"required"` // FAILED or ARCHIVED
+	Reason string `json:"reason"`
+}
+
+type DatasetDeleteResponse struct {
+	DatasetId             string   `json:"dataset_id"`
+	MetricRowsDeleted     int64    `json:"metric_rows_deleted"`
+	RestoredDatasetIds    []string `json:"restored_dataset_ids,omitempty"` // superseded datasets queued for import again
+	PeriodMonth           int      `json:"period_month"`
+	PeriodYear            int      `json:"period_year"`
+	RecalculationStarted  bool     `json:"recalculation_started"`
+	RecalculationWarnings []string `json:"recalculation_warnings,omitempty"` // required datasets missing, recalculated anyway
+	RecalculationError    string   `json:"recalculation_error,omitempty"`    // why the recalculation was refused
+}
+
+// DatasetRecalculationConflictError is returned when a recalculation is requested with the deletion of a dataset
+// that re-imports the datasets it superseded: the evaluations can only be recalculated once those are imported.
+type DatasetRecalculationConflictError struct {
+	Restored int
+}
+
+func (e *DatasetRecalculationConflictError) Error() string {
+	return fmt.Sprintf("deleting this dataset imports again the %d dataset(s) it superseded, so the evaluations cannot be recalculated yet; delete without recalculate and recalculate once they are DONE", e.Restored)
+}
+
+type DatasetRowErrorItem struct {
+	RowNumber int    `json:"row_number"`
+	Column    string `json:"column"`
+	Value     string `json:"value"`
+	Reason    string `json:"reason"`
+}
+
+// DatasetPreviewResponse is returned by a dry-run upload; nothing is stored.
+type DatasetPreviewResponse struct {
+	Type              string                `json:"type"`
+	PeriodDate        string                `json:"period_date"`
+	PeriodFrequency   string                `json:"period_frequency"`
+	FileName          string                `json:"file_name"`
+	TotalRows         int                   `json:"total_rows"`
+	ValidRows         int                   `json:"valid_rows"`
+	QuarantinedRows   int                   `json:"quarantined_rows"` // valid rows with an unknown Honda ID
+	InvalidRows       int                   `json:"invalid_rows"`
+	MatchedHondaIds   []string              `json:"matched_honda_ids"`
+	UnmatchedHondaIds []string              `json:"unmatched_honda_ids"`
+	Sample            []interface{}         `json:"sample"`
+	Errors            []DatasetRowErrorItem `json:"errors"`
+	Supersedes        []string              `json:"supersedes"` // DONE datasets that importing this file would replace
+}
+
+type DatasetTemplateCreate struct {
+	DatasetType string              `json:"dataset_type" binding:"required"`
+	SheetName   string              `json:"sheet_name" binding:"omitempty,max=100"`
+	HeaderRow   int                 `json:"header_row" binding:"omitempty,min=1"`
+	Columns     map[string][]string `json:"columns" binding:"required"`
+	Active      *bool               `json:"active"`
+}
+
+type DatasetTemplateDownloadRequest struct {
+	Prefill    bool   `form:"prefill"` // fill the rows with active persons
+	Role       string `form:"role"`
+	DealerCode string `form:"dealer_code"`
+}
+
+type DatasetTemplateUpdate struct {
+	SheetName *string             `json:"sheet_name" binding:"omitempty,max=100"`
+	HeaderRow *int                `json:"header_row" binding:"omitempty,min=1"`
+	Columns   map[string][]string `json:"columns"`
+	Active    *bool               `json:"active"`
+}
+
+// DatasetQuarantineResolve maps a quarantined row to an existing person (person_id) or to a new one (person).
+type DatasetQuarantineResolve struct {
+	PersonId   string                   `json:"person_id" binding:"omitempty,uuid4"`
+	Person     *DatasetQuarantinePerson `json:"person"`
+	ApplyToAll bool                     `json:"apply_to_all"` // also resolve every pending row with the same Honda ID
+}
+
+// DatasetQuarantinePerson creates the person of a quarantined row; honda_id defaults to the Honda ID of the row.
+type DatasetQuarantinePerson struct {
+	HondaId    string  `json:"honda_id" binding:"omitempty,max=20"`
+	Name       string  `json:"name" binding:"required,min=3,max=150"`
+	JobTitle   *string `json:"job_title" binding:"omitempty,min=2,max=150"`
+	Role       string  `json:"role" binding:"required,oneof=teamleader sales_portal admin staff viewer salesman"`
+	DealerCode *string `json:"dealer_code" binding:"omitempty"`
+}
+
+type DatasetQuarantineResolveResponse struct {
+	PersonId      string   `json:"person_id"`
+	HondaId       string   `json:"honda_id"`
+	PersonCreated bool     `json:"person_created"`
+	ResolvedRows  []string `json:"resolved_rows"`
+}
+
+type DatasetConnectorCreate struct {
+	Name            string `json:"name" binding:"required,min=3,max=150"`
+	URL             string `json:"url" binding:"required,max=2000"` // may contain {period_date}, {period_month} and {period_year}
+	AuthHeader      string `json:"auth_header" binding:"omitempty,max=100"`
+	AuthValue       string `json:"auth_value"`
+	Format          string `json:"format" binding:"required,oneof=xlsx csv json"`
+	DatasetType     string `json:"dataset_type" binding:"required"`
+	PeriodFrequency string `json:"period_frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
+	PeriodLagDays   int    `json:"period_lag_days" binding:"omitempty,min=0,max=366"`
+	Schedule        string `json:"schedule" binding:"required"` // cron expression, e.g. "0 7 * * *"
+	Active          *bool  `json:"active"`
+}
+
+type DatasetConnectorUpdate struct {
+	Name            *string `json:"name" binding:"omitempty,min=3,max=150"`
+	URL             *string `json:"url" binding:"omitempty,max=2000"`
+	AuthHeader      *string `json:"auth_header" binding:"omitempty,max=100"`
+	AuthValue       *string `json:"auth_value"` // an empty string removes the authentication
+	Format          *string `json:"format" binding:"omitempty,oneof=xlsx csv json"`
+	DatasetType     *string `json:"dataset_type"`
+	PeriodFrequency *string `json:"period_frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
+	PeriodLagDays   *int    `json:"period_lag_days" binding:"omitempty,min=0,max=366"`
+	Schedule        *string `json:"schedule"`
+	Active          *bool   `json:"active"`
+}
+
+// DatasetRowValue is the compared value of a dataset row, e.g. the FLP amount of a SALES_FLP row.
+// PersonId is empty when the Honda ID matches no person.
+type DatasetRowValue struct {
+	HondaId  string  `json:"honda_id"`
+	PersonId string  `json:"person_id,omitempty"`
+	Value    float64 `json:"value"`
+}
+
+// DatasetValues holds the row values of a dataset file by Honda ID; rows failing validation are left out.
+type DatasetValues struct {
+	Values      map[string]DatasetRowValue
+	TotalRows   int
+	InvalidRows int
+}
+
+// DatasetDiffEntry is a Honda ID whose value differs between the two datasets. OldValue is nil for added
+// persons and NewValue for removed ones.
+type DatasetDiffEntry struct {
+	HondaId    string   `json:"honda_id"`
+	PersonId   string   `json:"person_id,omitempty"`
+	PersonName string   `json:"person_name,omitempty"`
+	OldValue   *float64 `json:"old_value"`
+	NewValue   *float64 `json:"new_value"`
+	Delta      float64  `json:"delta"`
+}
+
+type DatasetDiffSummary struct {
+	Added          int `json:"added"`
+	Removed        int `json:"removed"`
+	Changed        int `json:"changed"`
+	Unchanged      int `json:"unchanged"`
+	OldInvalidRows int `json:"old_invalid_rows"`
+	NewInvalidRows int `json:"new_invalid_rows"`
+}
+
+// DatasetScoreEstimate is the expected change of the evaluation of a TL when the new dataset replaces the old one.
+// Scores are nil when the TL has no evaluation for the period yet.
+type DatasetScoreEstimate struct {
+	PersonId       string   `json:"person_id"`
+	PersonName     string   `json:"person_name"`
+	KpiItemId      string   `json:"kpi_item_id"`
+	KpiName        string   `json:"kpi_name"`
+	CurrentValue   float64  `json:"current_value"`
+	EstimatedValue float64  `json:"estimated_value"`
+	KpiScoreDelta  float64  `json:"kpi_score_delta"`
+	CurrentScore   *float64 `json:"current_score"`
+	EstimatedScore *float64 `json:"estimated_score"`
+}
+
+// DatasetDiffResponse compares the rows of two datasets of the same type by Honda ID.
+type DatasetDiffResponse struct {
+	Type              string                 `json:"type"`
+	Field             string                 `json:"field"`
+	OldDatasetId      string                 `json:"old_dataset_id"`
+	NewDatasetId      string                 `json:"new_dataset_id"`
+	Summary           DatasetDiffSummary     `json:"summary"`
+	Added             []DatasetDiffEntry     `json:"added"`
+	Removed           []DatasetDiffEntry     `json:"removed"`
+	Changed           []DatasetDiffEntry     `json:"changed"`
+	ScoreEstimates    []DatasetScoreEstimate `json:"score_estimates"`
+	ScoreEstimateNote string                 `json:"score_estimate_note,omitempty"`
 }
@@ -1,12 +1,17 @@
 package dto
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // EvaluationCalculateRequest triggers evaluation calculation for a period
 type EvaluationCalculateRequest struct {
 	PeriodMonth int    `json:"period_month" binding:"required,min=1,max=12"`
 	PeriodYear  int    `json:"period_year" binding:"required,min=2020"`
 	PersonId    string `json:"person_id" binding:"omitempty,uuid4"` // Optional: calculate for specific TL, if empty calculate for all
+	Force       bool   `json:"force"`                               // Optional: calculate even when required datasets are missing
 }
 
 // EvaluationResponse returns the evaluation result with breakdown
@@ -39,6 +44,7 @@ type KpiScoreBreakdown struct {
 	Weight           float64  `json:"weight"`            // e.g., 25.00 for Quantity Activity
 	ActualValue      *float64 `json:"actual_value"`      // Actual metric value
 	TargetValue      *float64 `json:"target_value"`      // Target metric value (if applicable)
+	TargetSource     string   `json:"target_source"`     // PERSON, DEALER or KPI: where the target came from
 	AchievementRatio *float64 `json:"achievement_ratio"` // Actual/Target ratio (if applicable)
 	Score            float64  `json:"score"`             // Weighted score from this KPI
 	MaxScore         float64  `json:"max_score"`         // Max possible score (same as weight)
@@ -71,3 +77,59 @@ type LeaderboardResponse struct {
 	Entries []LeaderboardEntry `json:"entries"`
 	Total   int                `json:"total"`
 }
+
+// EvaluationCoverageRequest selects the periods of the dataset coverage matrix; without a month the whole year is returned
+type EvaluationCoverageRequest struct {
+	PeriodMonth int `form:"period_month" binding:"omitempty,min=1,max=12"`
+	PeriodYear  int `form:"period_year" binding:"required,min=2020"`
+}
+
+// EvaluationRequiredDatasets sets the dataset types required before a period is evaluated; an empty list requires none
+type EvaluationRequiredDatasets struct {
+	PeriodMonth  int      `json:"period_month" binding:"required,min=1,max=12"`
+	PeriodYear   int      `json:"period_year" binding:"required,min=2020"`
+	DatasetTypes []string `json:"dataset_types" binding:"required"`
+}
+
+// EvaluationPeriodRequest selects one evaluation period
+type EvaluationPeriodRequest struct {
+	PeriodMonth int `form:"period_month" binding:"required,min=1,max=12"`
+	PeriodYear  int `form:"period_year" binding:"required,min=2020"`
+}
+
+// DatasetCoverageEntry tells whether a dataset type was imported for a period
+type DatasetCoverageEntry struct {
+	Type           string     `json:"type"`
+	Required       bool       `json:"required"`
+	Available      bool       `json:"available"` // at least one DONE dataset exists
+	DatasetIds     []string   `json:"dataset_ids"`
+	PersonsCovered int        `json:"persons_covered"`
+	LastUploadedAt *time.Time `json:"last_uploaded_at,omitempty"`
+}
+
+// DatasetCoveragePeriod is one row of the coverage matrix
+type DatasetCoveragePeriod struct {
+	PeriodMonth       int                    `json:"period_month"`
+	PeriodYear        int                    `json:"period_year"`
+	RequiredDatasets  []string               `json:"required_datasets"`
+	RequirementSource string                 `json:"requirement_source"` // PERIOD or DEFAULT
+	Complete          bool                   `json:"complete"`           // every required dataset is available
+	Missing           []string               `json:"missing"`
+	Datasets          []DatasetCoverageEntry `json:"datasets"`
+}
+
+// DatasetCoverageResponse is the dataset coverage matrix together with the evaluation gate configuration
+type DatasetCoverageResponse struct {
+	Mode             string                  `json:"mode"`              // strict or warn
+	RequiredDatasets []string                `json:"required_datasets"` // default of the periods without their own requirement
+	Periods          []DatasetCoveragePeriod `json:"periods"`
+}
+
+// EvaluationCoverageError is returned when an evaluation is refused because required datasets are missing
+type EvaluationCoverageError struct {
+	Period DatasetCoveragePeriod
+}
+
+func (e *EvaluationCoverageError) Error() string {
+	return fmt.Sprintf("missing required dataset(s) for period %d-%02d: %s", e.Period.PeriodYear, e.Period.PeriodMonth, strings.Join(e.Period.Missing, ", "))
+}
@@ -1,29 +1,37 @@
 package dto
 
+import "encoding/json"
+
 type KPIItemCreate struct {
-	PillarId          string   `json:"pillar_id" binding:"required"`
-	Name              string   `json:"name" binding:"required,min=2,max=150"`
-	Weight            float64  `json:"weight" binding:"required,gte=0,lte=100"`
-	TargetValue       *float64 `json:"target_value" binding:"omitempty"`
-	Unit              *string  `json:"unit" binding:"omitempty,max=50"`
-	Frequency         *string  `json:"frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
-	InputSource       string   `json:"input_source" binding:"required,oneof=ADMIN_UPLOAD TL_INPUT SYSTEM"`
-	AppliesToTL       bool     `json:"applies_to_tl"`
-	AppliesToSalesman bool     `json:"applies_to_salesman"`
-	Notes             *string  `json:"notes" binding:"omitempty,max=500"`
+	PillarId          string          `json:"pillar_id" binding:"required"`
+	Name              string          `json:"name" binding:"required,min=2,max=150"`
+	MetricKey         *string         `json:"metric_key" binding:"omitempty,max=100"` // required when applies_to_tl
+	Weight            float64         `json:"weight" binding:"required,gte=0,lte=100"`
+	TargetValue       *float64        `json:"target_value" binding:"omitempty"`
+	ScoringType       string          `json:"scoring_type" binding:"omitempty,max=50"` // defaults to LINEAR_CAPPED
+	ScoringParams     json.RawMessage `json:"scoring_params"`
+	Unit              *string         `json:"unit" binding:"omitempty,max=50"`
+	Frequency         *string         `json:"frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
+	InputSource       string          `json:"input_source" binding:"required,oneof=ADMIN_UPLOAD TL_INPUT SYSTEM"`
+	AppliesToTL       bool            `json:"applies_to_tl"`
+	AppliesToSalesman bool            `json:"applies_to_salesman"`
+	Notes             *string         `json:"notes" binding:"omitempty,max=500"`
 }
 
 type KPIItemUpdate struct {
-	PillarId          *string  `json:"pillar_id" binding:"omitempty"`
-	Name              *string  `json:"name" binding:"omitempty,min=2,max=150"`
-	Weight            *float64 `json:"weight" binding:"omitempty,gte=0,lte=100"`
-	TargetValue       *float64 `json:"target_value" binding:"omitempty"`
-	Unit              *string  `json:"unit" binding:"omitempty,max=50"`
-	Frequency         *string  `json:"frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
-	InputSource       *string  `json:"input_source" binding:"omitempty,oneof=ADMIN_UPLOAD TL_INPUT SYSTEM"`
-	AppliesToTL       *bool    `json:"applies_to_tl"`
-	AppliesToSalesman *bool    `json:"applies_to_salesman"`
-	Notes             *string  `json:"notes" binding:"omitempty,max=500"`
+	PillarId          *string         `json:"pillar_id" binding:"omitempty"`
+	Name              *string         `json:"name" binding:"omitempty,min=2,max=150"`
+	MetricKey         *string         `json:"metric_key" binding:"omitempty,max=100"` // empty string unbinds the metric
+	Weight            *float64        `json:"weight" binding:"omitempty,gte=0,lte=100"`
+	TargetValue       *float64        `json:"target_value" binding:"omitempty"`
+	ScoringType       *string         `json:"scoring_type" binding:"omitempty,max=50"`
+	ScoringParams     json.RawMessage `json:"scoring_params"` // replaces the parameters when set
+	Unit              *string         `json:"unit" binding:"omitempty,max=50"`
+	Frequency         *string         `json:"frequency" binding:"omitempty,oneof=DAILY WEEKLY MONTHLY QUARTERLY YEARLY"`
+	InputSource       *string         `json:"input_source" binding:"omitempty,oneof=ADMIN_UPLOAD TL_INPUT SYSTEM"`
+	AppliesToTL       *bool           `json:"applies_to_tl"`
+	AppliesToSalesman *bool           `json:"applies_to_salesman"`
+	Notes             *string         `json:"notes" binding:"omitempty,max=500"`
 }
 
 type PersonKPITargetUpsert struct {
@@ -33,3 +41,56 @@ type PersonKPITargetUpsert struct {
 	PeriodYear  int     `json:"period_year" binding:"required,min=2000"`
 	TargetValue float64 `json:"target_value" binding:"required"`
 }
+
+// DealerKPITargetUpsert sets the default target of a KPI item for the persons of a dealer.
+type DealerKPITargetUpsert struct {
+	DealerCode  string  `json:"dealer_code" binding:"required,max=50"`
+	KPIItemId   string  `json:"kpi_item_id" binding:"required"`
+	TargetValue float64 `json:"target_value" binding:"required"`
+}
+
+type PersonKPITargetExportRequest struct {
+	PeriodMonth int    `form:"period_month" binding:"required,min=1,max=12"`
+	PeriodYear  int    `form:"period_year" binding:"required,min=2000"`
+	KPIItemId   string `form:"kpi_item_id"`
+}
+
+// PersonKPITargetImportRow is the outcome of one data row of a bulk target import.
+type PersonKPITargetImportRow struct {
+	RowNumber   int      `json:"row_number"` // 1-based row number in the sheet
+	HondaId     string   `json:"honda_id"`
+	KPIItem     string   `json:"kpi_item"`
+	PeriodMonth int      `json:"period_month,omitempty"`
+	PeriodYear  int      `json:"period_year,omitempty"`
+	TargetValue *float64 `json:"target_value,omitempty"`
+	Action      string   `json:"action"` // CREATE, UPDATE or ERROR
+	Errors      []string `json:"errors,omitempty"`
+}
+
+type PersonKPITargetImportResponse struct {
+	TotalRows int                        `json:"total_rows"`
+	Created   int                        `json:"created"`
+	Updated   int                        `json:"updated"`
+	Failed    int                        `json:"failed"`
+	Saved     bool                       `json:"saved"` // false on dry runs and when any row failed
+	Rows      []PersonKPITargetImportRow `json:"rows"`
+}
+
+// MetricDefinition describes a metric computed by the evaluation; KPI items are bound to it by Key.
+type MetricDefinition struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Unit        string `json:"unit"`
+	Source      string `json:"source"`                 // TL, ADMIN or SYSTEM
+	DatasetType string `json:"dataset_type,omitempty"` // dataset the value is read from, for ADMIN metrics
+	Numerator   string `json:"numerator,omitempty"`    // for ratio metrics: key of the metric divided by Denominator
+	Denominator string `json:"denominator,omitempty"`  // for ratio metrics: key of the metric Numerator is divided by
+	Description string `json:"description"`
+}
+
+// ScoringStrategyDefinition describes a scoring strategy a KPI item can use, with an example of its parameters.
+type ScoringStrategyDefinition struct {
+	Type          string          `json:"type"`
+	Description   string          `json:"description"`
+	ExampleParams json.RawMessage `json:"example_params"`
+}
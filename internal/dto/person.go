@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type PersonCreate struct {
 	HondaId    string  `json:"honda_id" binding:"required,max=20"`
 	Name       string  `json:"name" binding:"required,min=3,max=150"`
@@ -17,3 +19,52 @@ type PersonUpdate struct {
 	DealerCode *string `json:"dealer_code" binding:"omitempty"`
 	Active     *bool   `json:"active" binding:"omitempty"`
 }
+
+// SupervisorAssign moves a salesman to the team of a TL, or out of any team when SupervisorId is empty.
+type SupervisorAssign struct {
+	SupervisorId  *string    `json:"supervisor_id" binding:"omitempty"`
+	EffectiveFrom *time.Time `json:"effective_from" binding:"omitempty"` // defaults to today, never in the future
+	Reason        *string    `json:"reason" binding:"omitempty,max=500"`
+}
+
+// TeamTransfer moves salesmen from the team of a TL to another one; all of its members when PersonIds is empty.
+type TeamTransfer struct {
+	FromSupervisorId string     `json:"from_supervisor_id" binding:"required"`
+	ToSupervisorId   string     `json:"to_supervisor_id" binding:"required"`
+	PersonIds        []string   `json:"person_ids" binding:"omitempty"`
+	EffectiveFrom    *time.Time `json:"effective_from" binding:"omitempty"`
+	Reason           *string    `json:"reason" binding:"omitempty,max=500"`
+}
+
+type TeamTransferResponse struct {
+	Transferred   int      `json:"transferred"`
+	PersonIds     []string `json:"person_ids"`
+	EffectiveFrom string   `json:"effective_from"` // YYYY-MM-DD
+}
+
+// PersonFieldChange is the old and new value of a person field changed by a roster import.
+type PersonFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// PersonImportRow is the outcome of one data row of a roster import.
+type PersonImportRow struct {
+	RowNumber int                          `json:"row_number"` // 1-based row number in the sheet
+	HondaId   string                       `json:"honda_id"`
+	Name      string                       `json:"name"`
+	PersonId  string                       `json:"person_id,omitempty"`
+	Action    string                       `json:"action"` // CREATE, UPDATE, UNCHANGED or ERROR
+	Changes   map[string]PersonFieldChange `json:"changes,omitempty"`
+	Errors    []string                     `json:"errors,omitempty"`
+}
+
+type PersonImportResponse struct {
+	TotalRows int               `json:"total_rows"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Saved     bool              `json:"saved"` // false on dry runs and when any row failed
+	Rows      []PersonImportRow `json:"rows"`
+}
@@ -34,7 +34,7 @@ type TLActivityUpdate struct {
 // TL Attendance DTOs
 type AttendanceRecord struct {
 	SalesmanPersonId string `json:"salesman_person_id" binding:"required"`
-	SalesmanName     string `json:"salesman_name" binding:"required,min=2,max=150"`
+	SalesmanName     string `json:"salesman_name" binding:"omitempty,max=150"` // ignored, the name of the person is stored
 	Status           string `json:"status" binding:"required,oneof=hadir tidak_hadir"`
 }
 
@@ -68,7 +68,7 @@ type TLSessionUpdate struct {
 // TL Training Participation DTOs
 type TrainingParticipant struct {
 	SalesmanPersonId string `json:"salesman_person_id" binding:"required"`
-	SalesmanName     string `json:"salesman_name" binding:"required,min=2,max=150"`
+	SalesmanName     string `json:"salesman_name" binding:"omitempty,max=150"` // ignored, the name of the person is stored
 	Status           string `json:"status" binding:"required,oneof=hadir tidak_hadir"`
 }
 
@@ -0,0 +1,260 @@
+package handlerdataset
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/pkg/messages"
+	"teamleader-management/pkg/response"
+	"teamleader-management/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DatasetConnectorHandler struct {
+	Service interfacedataset.ServiceDatasetConnectorInterface
+}
+
+func NewDatasetConnectorHandler(s interfacedataset.ServiceDatasetConnectorInterface) *DatasetConnectorHandler {
+	return &DatasetConnectorHandler{Service: s}
+}
+
+func (h *DatasetConnectorHandler) Create(ctx *gin.Context) {
+	var req dto.DatasetConnectorCreate
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][Create]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Create(req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Create; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Dataset connector created successfully", logId, data)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (h *DatasetConnectorHandler) GetByID(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][GetByID]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	data, err := h.Service.GetByID(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetByID; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Dataset connector not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get dataset connector successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetConnectorHandler) GetAll(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][GetAll]", logId)
+
+	params, err := filter.GetBaseParams(ctx, "name", "asc", 10)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; GetBaseParams; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	data, total, err := h.Service.GetAll(params)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetAll; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(total), params.Page, params.Limit, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetConnectorHandler) Update(ctx *gin.Context) {
+	var req dto.DatasetConnectorUpdate
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][Update]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Update(id, req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Update; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dataset connector updated successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetConnectorHandler) Delete(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][Delete]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	if err := h.Service.Delete(id, actor); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Delete; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, err.Error(), logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dataset connector deleted successfully", logId, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetRuns lists the runs of a connector, most recent first.
+func (h *DatasetConnectorHandler) GetRuns(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][GetRuns]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	params, err := filter.GetBaseParams(ctx, "started_at", "desc", 10)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; GetBaseParams; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	data, total, err := h.Service.GetRuns(id, params)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetRuns; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Dataset connector not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(total), params.Page, params.Limit, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Run starts a run of the connector outside its schedule. The run executes in the background,
+// its outcome can be followed through GetRuns.
+func (h *DatasetConnectorHandler) Run(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][Run]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.RunNow(id, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.RunNow; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusAccepted, "Dataset connector run started", logId, data)
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// Test fetches the payload of the connector and returns a preview of it without importing anything.
+func (h *DatasetConnectorHandler) Test(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetConnectorHandler][Test]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Test(id, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Test; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dataset connector test completed", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -1,7 +1,9 @@
 package handlerdataset
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -15,15 +17,18 @@ import (
 	"teamleader-management/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type DatasetHandler struct {
-	Service   interfacedataset.ServiceDatasetInterface
-	Processor interfacedataset.DatasetProcessorInterface
+	Service     interfacedataset.ServiceDatasetInterface
+	Worker      interfacedataset.DatasetWorkerInterface
+	Processor   interfacedataset.DatasetProcessorInterface
+	DiffService interfacedataset.ServiceDatasetDiffInterface
 }
 
-func NewDatasetHandler(s interfacedataset.ServiceDatasetInterface, p interfacedataset.DatasetProcessorInterface) *DatasetHandler {
-	return &DatasetHandler{Service: s, Processor: p}
+func NewDatasetHandler(s interfacedataset.ServiceDatasetInterface, w interfacedataset.DatasetWorkerInterface, p interfacedataset.DatasetProcessorInterface, d interfacedataset.ServiceDatasetDiffInterface) *DatasetHandler {
+	return &DatasetHandler{Service: s, Worker: w, Processor: p, DiffService: d}
 }
 
 func (h *DatasetHandler) Upload(ctx *gin.Context) {
@@ -31,24 +36,45 @@ func (h *DatasetHandler) Upload(ctx *gin.Context) {
 	logId := utils.GenerateLogId(ctx)
 	logPrefix := fmt.Sprintf("[%s][DatasetHandler][Upload]", logId)
 
-	periodDate := ctx.PostForm("period_date")
-	periodMonthStr := ctx.PostForm("period_month")
-	periodYearStr := ctx.PostForm("period_year")
-
-	file, fileHeader, err := ctx.Request.FormFile("file")
-	if err != nil {
-		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
-		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
-		res.Error = "file is required"
-		ctx.JSON(http.StatusBadRequest, res)
-		return
+	// Period fields come from the multipart form, or from the query string when the rows are posted as a JSON body.
+	formValue := func(key string) string {
+		if v := ctx.PostForm(key); v != "" {
+			return v
+		}
+		return ctx.Query(key)
 	}
-	defer func() {
-		_ = file.Close()
-		if f, ok := file.(*os.File); ok {
-			_ = os.Remove(f.Name())
+	periodDate := formValue("period_date")
+	periodMonthStr := formValue("period_month")
+	periodYearStr := formValue("period_year")
+
+	var (
+		upload      io.Reader
+		fileName    string
+		contentType string
+	)
+	if ctx.ContentType() == gin.MIMEJSON {
+		upload = ctx.Request.Body
+		fileName = fmt.Sprintf("%s_%s.json", strings.ToUpper(datasetType), periodDate)
+		contentType = gin.MIMEJSON
+	} else {
+		file, fileHeader, err := ctx.Request.FormFile("file")
+		if err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+			res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+			res.Error = "file is required"
+			ctx.JSON(http.StatusBadRequest, res)
+			return
 		}
-	}()
+		defer func() {
+			_ = file.Close()
+			if f, ok := file.(*os.File); ok {
+				_ = os.Remove(f.Name())
+			}
+		}()
+		upload = file
+		fileName = fileHeader.Filename
+		contentType = fileHeader.Header.Get("Content-Type")
+	}
 
 	authData := utils.GetAuthData(ctx)
 	actor := ""
@@ -70,11 +96,14 @@ func (h *DatasetHandler) Upload(ctx *gin.Context) {
 			req.PeriodYear = num
 		}
 	}
-	if v := ctx.PostForm("period_frequency"); v != "" {
+	if v := formValue("period_frequency"); v != "" {
 		req.PeriodFrequency = v
 	}
+	if v := formValue("dry_run"); v != "" {
+		req.DryRun, _ = strconv.ParseBool(v)
+	}
 
-	ds, data, err := h.Service.Create(datasetType, req, file, fileHeader, actor)
+	ds, spooled, err := h.Service.Create(datasetType, req, upload, fileName, contentType, actor)
 	if err != nil {
 		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Upload; Error: %+v", logPrefix, err))
 		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
@@ -82,18 +111,92 @@ func (h *DatasetHandler) Upload(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, res)
 		return
 	}
+	defer spooled.Close()
+
+	if req.DryRun {
+		preview, err := h.Processor.Preview(ds, spooled, actor)
+		if err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Processor.Preview; Error: %+v", logPrefix, err))
+			res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+			res.Error = err.Error()
+			ctx.JSON(http.StatusBadRequest, res)
+			return
+		}
+
+		res := response.Response(http.StatusOK, "Dataset validated, nothing was saved (dry run)", logId, preview)
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+
+	if err := h.Worker.Enqueue(ds, spooled, spooled.Size, actor); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Worker.Enqueue; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusServiceUnavailable, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+
+	res := response.Response(http.StatusAccepted, "Dataset uploaded and queued for processing", logId, ds)
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+func (h *DatasetHandler) GetByID(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][GetByID]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	data, err := h.Service.GetByID(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetByID; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Dataset not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get dataset successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Diff compares the rows of the dataset with those of otherId by Honda ID, with the estimated change of
+// the evaluation scores if otherId replaced it.
+func (h *DatasetHandler) Diff(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][Diff]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+	otherId := ctx.Param("otherId")
+	if _, err := uuid.Parse(otherId); err != nil {
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = "otherId must be a valid UUID"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
 
-	processed, err := h.Processor.ProcessStream(&ds, data, actor)
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.DiffService.Diff(id, otherId, actor)
 	if err != nil {
-		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Processor.Process; Error: %+v", logPrefix, err))
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; DiffService.Diff; Error: %+v", logPrefix, err))
 		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
 		res.Error = err.Error()
 		ctx.JSON(http.StatusBadRequest, res)
 		return
 	}
 
-	res := response.Response(http.StatusCreated, "Dataset uploaded and processed successfully", logId, processed)
-	ctx.JSON(http.StatusCreated, res)
+	res := response.Response(http.StatusOK, "Get dataset diff successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
 }
 
 func (h *DatasetHandler) List(ctx *gin.Context) {
@@ -150,7 +253,7 @@ func (h *DatasetHandler) UpdateStatus(ctx *gin.Context) {
 		actor = utils.InterfaceString(authData["user_id"])
 	}
 
-	data, err := h.Service.UpdateStatus(id, req.Status, actor)
+	data, err := h.Service.UpdateStatus(id, req.Status, req.Reason, actor)
 	if err != nil {
 		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.UpdateStatus; Error: %+v", logPrefix, err))
 		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
@@ -162,3 +265,163 @@ func (h *DatasetHandler) UpdateStatus(ctx *gin.Context) {
 	res := response.Response(http.StatusOK, "Dataset status updated", logId, data)
 	ctx.JSON(http.StatusOK, res)
 }
+
+func (h *DatasetHandler) GetErrors(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][GetErrors]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	data, err := h.Service.GetErrors(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetErrors; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Dataset not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, len(data), 1, len(data), logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetHandler) DownloadErrorReport(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][DownloadErrorReport]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	fileName, content, err := h.Service.BuildErrorReport(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.BuildErrorReport; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	ctx.Data(http.StatusOK, utils.ContentTypeXLSX, content)
+}
+
+// DownloadSource returns the original file uploaded for the dataset.
+func (h *DatasetHandler) DownloadSource(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][DownloadSource]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	fileName, contentType, original, err := h.Service.GetSourceFile(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetSourceFile; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Dataset file not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	defer original.Close()
+
+	ctx.DataFromReader(http.StatusOK, -1, contentType, original, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", fileName),
+	})
+}
+
+// Reprocess imports the stored original file of a dataset again in the background.
+func (h *DatasetHandler) Reprocess(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][Reprocess]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	ds, err := h.Service.Reprocess(id, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Reprocess; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := h.Worker.Requeue(ds, actor); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Worker.Requeue; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusServiceUnavailable, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+
+	res := response.Response(http.StatusAccepted, "Dataset queued for reprocessing", logId, ds)
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+func (h *DatasetHandler) Delete(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetHandler][Delete]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+	recalculate, _ := strconv.ParseBool(ctx.Query("recalculate"))
+	force, _ := strconv.ParseBool(ctx.Query("force"))
+
+	data, err := h.Service.Delete(id, actor, recalculate, force)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Delete; Error: %+v", logPrefix, err))
+		var conflictErr *dto.DatasetRecalculationConflictError
+		if errors.As(err, &conflictErr) {
+			res := response.Response(http.StatusConflict, messages.MsgFail, logId, nil)
+			res.Error = err.Error()
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	// The deletion is done; a restored dataset that cannot be queued is marked FAILED and can be reprocessed.
+	for _, restoredId := range data.RestoredDatasetIds {
+		restored, err := h.Service.GetByID(restoredId)
+		if err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetByID %s; Error: %+v", logPrefix, restoredId, err))
+			continue
+		}
+		if err := h.Worker.Requeue(restored, actor); err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Worker.Requeue %s; Error: %+v", logPrefix, restoredId, err))
+		}
+	}
+
+	message := "Dataset deleted successfully"
+	if data.RecalculationError != "" {
+		message = "Dataset deleted, the evaluations were not recalculated: " + data.RecalculationError
+	}
+	res := response.Response(http.StatusOK, message, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
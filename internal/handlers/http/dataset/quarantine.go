@@ -0,0 +1,140 @@
+package handlerdataset
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/pkg/messages"
+	"teamleader-management/pkg/response"
+	"teamleader-management/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DatasetQuarantineHandler struct {
+	Service interfacedataset.ServiceDatasetQuarantineInterface
+}
+
+func NewDatasetQuarantineHandler(s interfacedataset.ServiceDatasetQuarantineInterface) *DatasetQuarantineHandler {
+	return &DatasetQuarantineHandler{Service: s}
+}
+
+func (h *DatasetQuarantineHandler) GetAll(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetQuarantineHandler][GetAll]", logId)
+
+	params, err := filter.GetBaseParams(ctx, "created_at", "desc", 10)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; GetBaseParams; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	data, total, err := h.Service.GetAll(params)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetAll; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(total), params.Page, params.Limit, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetQuarantineHandler) GetByID(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetQuarantineHandler][GetByID]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	data, err := h.Service.GetByID(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetByID; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Quarantined row not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get quarantined row successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Resolve maps a quarantined row to an existing or new person and imports it.
+func (h *DatasetQuarantineHandler) Resolve(ctx *gin.Context) {
+	var req dto.DatasetQuarantineResolve
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetQuarantineHandler][Resolve]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Resolve(id, req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Resolve; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Quarantined row resolved and imported", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetQuarantineHandler) Discard(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetQuarantineHandler][Discard]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Discard(id, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Discard; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Quarantined row discarded", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,210 @@
+package handlerdataset
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/pkg/messages"
+	"teamleader-management/pkg/response"
+	"teamleader-management/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DatasetTemplateHandler struct {
+	Service interfacedataset.ServiceDatasetTemplateInterface
+}
+
+func NewDatasetTemplateHandler(s interfacedataset.ServiceDatasetTemplateInterface) *DatasetTemplateHandler {
+	return &DatasetTemplateHandler{Service: s}
+}
+
+func (h *DatasetTemplateHandler) Create(ctx *gin.Context) {
+	var req dto.DatasetTemplateCreate
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][Create]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Create(req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Create; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Dataset template created successfully", logId, data)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (h *DatasetTemplateHandler) GetByID(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][GetByID]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	data, err := h.Service.GetByID(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetByID; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Dataset template not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get dataset template successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetEffective returns the template applied to uploads of a dataset type, including the built-in default.
+func (h *DatasetTemplateHandler) GetEffective(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][GetEffective]", logId)
+
+	data, err := h.Service.GetEffective(ctx.Param("type"))
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetEffective; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get dataset template successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetTemplateHandler) GetAll(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][GetAll]", logId)
+
+	params, err := filter.GetBaseParams(ctx, "dataset_type", "asc", 10)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; GetBaseParams; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	data, total, err := h.Service.GetAll(params)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetAll; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(total), params.Page, params.Limit, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetTemplateHandler) Update(ctx *gin.Context) {
+	var req dto.DatasetTemplateUpdate
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][Update]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.Update(id, req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Update; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dataset template updated successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DatasetTemplateHandler) Delete(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][Delete]", logId)
+
+	id, err := utils.ValidateUUID(ctx, logId)
+	if err != nil {
+		return
+	}
+
+	if err := h.Service.Delete(id); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Delete; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, err.Error(), logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dataset template deleted successfully", logId, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DownloadUploadTemplate returns an XLSX file with the columns expected by uploads of a dataset type.
+func (h *DatasetTemplateHandler) DownloadUploadTemplate(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][DatasetTemplateHandler][DownloadUploadTemplate]", logId)
+
+	var req dto.DatasetTemplateDownloadRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; ShouldBindQuery ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "form")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	fileName, content, err := h.Service.BuildUploadTemplate(ctx.Param("type"), req)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.BuildUploadTemplate; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	ctx.Data(http.StatusOK, utils.ContentTypeXLSX, content)
+}
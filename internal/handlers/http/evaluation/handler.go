@@ -1,10 +1,12 @@
 package handlerevaluation
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"teamleader-management/internal/dto"
 	interfaceevaluation "teamleader-management/internal/interfaces/evaluation"
@@ -15,6 +17,7 @@ import (
 	"teamleader-management/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type EvaluationHandler struct {
@@ -42,16 +45,14 @@ func (h *EvaluationHandler) CalculateEvaluation(ctx *gin.Context) {
 	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
 
 	// Calculate evaluation
-	results, err := h.Service.CalculateEvaluation(req.PeriodMonth, req.PeriodYear, req.PersonId)
+	results, warnings, err := h.Service.CalculateEvaluation(req.PeriodMonth, req.PeriodYear, req.PersonId, req.Force)
 	if err != nil {
 		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.CalculateEvaluation; Error: %+v", logPrefix, err))
-		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
-		res.Error = err.Error()
-		ctx.JSON(http.StatusInternalServerError, res)
+		writeCalculateError(ctx, logId, err)
 		return
 	}
 
-	message := fmt.Sprintf("Calculated evaluation for %d TL(s) in period %d-%02d", len(results), req.PeriodYear, req.PeriodMonth)
+	message := withWarnings(fmt.Sprintf("Calculated evaluation for %d TL(s) in period %d-%02d", len(results), req.PeriodYear, req.PeriodMonth), warnings)
 	res := response.Response(http.StatusOK, message, logId, results)
 	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Success; Count: %d", logPrefix, len(results)))
 	ctx.JSON(http.StatusOK, res)
@@ -72,16 +73,14 @@ func (h *EvaluationHandler) RecalculateEvaluation(ctx *gin.Context) {
 		return
 	}
 
-	results, err := h.Service.RecalculateEvaluation(req.PeriodMonth, req.PeriodYear, req.PersonId)
+	results, warnings, err := h.Service.RecalculateEvaluation(req.PeriodMonth, req.PeriodYear, req.PersonId, req.Force)
 	if err != nil {
 		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.RecalculateEvaluation; Error: %+v", logPrefix, err))
-		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
-		res.Error = err.Error()
-		ctx.JSON(http.StatusInternalServerError, res)
+		writeCalculateError(ctx, logId, err)
 		return
 	}
 
-	message := fmt.Sprintf("Recalculated evaluation for %d TL(s)", len(results))
+	message := withWarnings(fmt.Sprintf("Recalculated evaluation for %d TL(s)", len(results)), warnings)
 	res := response.Response(http.StatusOK, message, logId, results)
 	ctx.JSON(http.StatusOK, res)
 }
@@ -229,3 +228,122 @@ func (h *EvaluationHandler) GetLeaderboard(ctx *gin.Context) {
 	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Success; Period: %s", logPrefix, data.Period))
 	ctx.JSON(http.StatusOK, res)
 }
+
+// GetCoverage returns the dataset coverage matrix used to gate the evaluation
+// GET /api/evaluation/coverage
+// Query params: period_year, period_month (optional, the whole year when empty)
+func (h *EvaluationHandler) GetCoverage(ctx *gin.Context) {
+	var req dto.EvaluationCoverageRequest
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][EvaluationHandler][GetCoverage]", logId)
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; ShouldBindQuery ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "form")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	data, err := h.Service.GetCoverage(req.PeriodMonth, req.PeriodYear)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetCoverage; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get dataset coverage successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// SetRequiredDatasets sets the dataset types required before a period is evaluated
+// PUT /api/evaluation/required-datasets
+func (h *EvaluationHandler) SetRequiredDatasets(ctx *gin.Context) {
+	var req dto.EvaluationRequiredDatasets
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][EvaluationHandler][SetRequiredDatasets]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.SetRequiredDatasets(req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.SetRequiredDatasets; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Required datasets updated successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ResetRequiredDatasets makes a period require the default datasets again
+// DELETE /api/evaluation/required-datasets?period_month=&period_year=
+func (h *EvaluationHandler) ResetRequiredDatasets(ctx *gin.Context) {
+	var req dto.EvaluationPeriodRequest
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][EvaluationHandler][ResetRequiredDatasets]", logId)
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; ShouldBindQuery ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "form")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.ResetRequiredDatasets(req.PeriodMonth, req.PeriodYear, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.ResetRequiredDatasets; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Required datasets reset to the default", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// writeCalculateError answers a refused calculation with 422 and the coverage of the period,
+// and any other failure with 500.
+func writeCalculateError(ctx *gin.Context, logId uuid.UUID, err error) {
+	var coverageErr *dto.EvaluationCoverageError
+	if errors.As(err, &coverageErr) {
+		res := response.Response(http.StatusUnprocessableEntity, "Required datasets are missing for this period", logId, coverageErr.Period)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+	res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+	res.Error = err.Error()
+	ctx.JSON(http.StatusInternalServerError, res)
+}
+
+func withWarnings(message string, warnings []string) string {
+	if len(warnings) == 0 {
+		return message
+	}
+	return message + "; warning: " + strings.Join(warnings, "; ")
+}
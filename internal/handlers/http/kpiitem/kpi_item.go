@@ -105,6 +105,24 @@ func (h *KPIItemHandler) GetAll(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// GetMetrics lists the metrics a KPI item can be bound to through metric_key.
+func (h *KPIItemHandler) GetMetrics(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+
+	data := h.Service.GetMetrics()
+	res := response.Response(http.StatusOK, "Get metrics successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetScoringStrategies lists the scoring strategies a KPI item can use through scoring_type.
+func (h *KPIItemHandler) GetScoringStrategies(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+
+	data := h.Service.GetScoringStrategies()
+	res := response.Response(http.StatusOK, "Get scoring strategies successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
 func (h *KPIItemHandler) Update(ctx *gin.Context) {
 	id := ctx.Param("id")
 	var req dto.KPIItemUpdate
@@ -240,3 +258,171 @@ func (h *KPIItemHandler) DeletePersonTarget(ctx *gin.Context) {
 	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Response: target deleted", logPrefix))
 	ctx.JSON(http.StatusOK, res)
 }
+
+// GetDealerTargets lists the default targets of the dealer given by the dealer_code query parameter.
+func (h *KPIItemHandler) GetDealerTargets(ctx *gin.Context) {
+	dealerCode := ctx.Query("dealer_code")
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][KPIItemHandler][GetDealerTargets]", logId)
+
+	data, err := h.Service.GetDealerTargets(dealerCode)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetDealerTargets; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get dealer KPI targets successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *KPIItemHandler) UpsertDealerTarget(ctx *gin.Context) {
+	var req dto.DealerKPITargetUpsert
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][KPIItemHandler][UpsertDealerTarget]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.UpsertDealerTarget(req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.UpsertDealerTarget; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dealer KPI target saved successfully", logId, data)
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Response: %+v;", logPrefix, utils.JsonEncode(data)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *KPIItemHandler) DeleteDealerTarget(ctx *gin.Context) {
+	dealerCode := ctx.Query("dealer_code")
+	kpiItemId := ctx.Query("kpi_item_id")
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][KPIItemHandler][DeleteDealerTarget]", logId)
+
+	if dealerCode == "" || kpiItemId == "" {
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = "dealer_code and kpi_item_id are required"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	if err := h.Service.DeleteDealerTarget(dealerCode, kpiItemId, actor); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.DeleteDealerTarget; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Dealer KPI target deleted successfully", logId, nil)
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Response: target deleted", logPrefix))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ImportPersonTargets upserts person KPI targets from an uploaded XLSX or CSV file and returns a report per row.
+// Nothing is saved when a row fails or dry_run is set.
+func (h *KPIItemHandler) ImportPersonTargets(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][KPIItemHandler][ImportPersonTargets]", logId)
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = "file is required"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	upload, err := fileHeader.Open()
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; FileHeader.Open ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	defer upload.Close()
+
+	dryRun, _ := strconv.ParseBool(ctx.PostForm("dry_run"))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.ImportPersonTargets(upload, fileHeader.Filename, fileHeader.Header.Get("Content-Type"), dryRun, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.ImportPersonTargets; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if data.Failed > 0 {
+		res := response.Response(http.StatusUnprocessableEntity, messages.MsgFail, logId, data)
+		res.Error = fmt.Sprintf("%d row(s) have errors, no target was saved", data.Failed)
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	msg := "Person KPI targets imported successfully"
+	if !data.Saved {
+		msg = "Person KPI targets validated, nothing was saved (dry run)"
+	}
+	res := response.Response(http.StatusOK, msg, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ExportPersonTargets returns the targets of a period as an XLSX file in the import layout.
+func (h *KPIItemHandler) ExportPersonTargets(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][KPIItemHandler][ExportPersonTargets]", logId)
+
+	var req dto.PersonKPITargetExportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; ShouldBindQuery ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "form")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	fileName, content, err := h.Service.ExportPersonTargets(req)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.ExportPersonTargets; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	ctx.Data(http.StatusOK, utils.ContentTypeXLSX, content)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"strconv"
 	"teamleader-management/internal/dto"
 	interfaceperson "teamleader-management/internal/interfaces/person"
 	"teamleader-management/pkg/filter"
@@ -143,7 +144,13 @@ func (h *PersonHandler) Delete(ctx *gin.Context) {
 	logId := utils.GenerateLogId(ctx)
 	logPrefix := fmt.Sprintf("[%s][PersonHandler][Delete]", logId)
 
-	if err := h.Service.Delete(id); err != nil {
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	if err := h.Service.Delete(id, actor); err != nil {
 		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.Delete; Error: %+v", logPrefix, err))
 		res := response.Response(http.StatusInternalServerError, err.Error(), logId, nil)
 		res.Error = err.Error()
@@ -155,3 +162,86 @@ func (h *PersonHandler) Delete(ctx *gin.Context) {
 	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Response: Person deactivated", logPrefix))
 	ctx.JSON(http.StatusOK, res)
 }
+
+// ImportRoster creates or updates persons from an uploaded XLSX or CSV roster and returns the changes per row.
+// Nothing is saved when a row fails or dry_run is set.
+func (h *PersonHandler) ImportRoster(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][PersonHandler][ImportRoster]", logId)
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = "file is required"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	upload, err := fileHeader.Open()
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; FileHeader.Open ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	defer upload.Close()
+
+	dryRun, _ := strconv.ParseBool(ctx.PostForm("dry_run"))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.ImportRoster(upload, fileHeader.Filename, fileHeader.Header.Get("Content-Type"), dryRun, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.ImportRoster; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if data.Failed > 0 {
+		res := response.Response(http.StatusUnprocessableEntity, messages.MsgFail, logId, data)
+		res.Error = fmt.Sprintf("%d row(s) have errors, no person was saved", data.Failed)
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	msg := "Person roster imported successfully"
+	if !data.Saved {
+		msg = "Person roster validated, nothing was saved (dry run)"
+	}
+	res := response.Response(http.StatusOK, msg, logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ExportRoster returns the persons matching the list filters as an XLSX file in the import layout.
+func (h *PersonHandler) ExportRoster(ctx *gin.Context) {
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][PersonHandler][ExportRoster]", logId)
+
+	params, err := filter.GetBaseParams(ctx, "honda_id", "asc", 10)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; GetBaseParams; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	fileName, content, err := h.Service.ExportRoster(params)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.ExportRoster; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	ctx.Data(http.StatusOK, utils.ContentTypeXLSX, content)
+}
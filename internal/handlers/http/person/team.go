@@ -0,0 +1,136 @@
+package handlerperson
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/pkg/messages"
+	"teamleader-management/pkg/response"
+	"teamleader-management/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignSupervisor moves a salesman to the team of a TL, or out of any team when supervisor_id is empty.
+func (h *PersonHandler) AssignSupervisor(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var req dto.SupervisorAssign
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][PersonHandler][AssignSupervisor]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.AssignSupervisor(id, req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.AssignSupervisor; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Supervisor assigned successfully", logId, data)
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Response: %+v;", logPrefix, utils.JsonEncode(data)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// TransferTeam moves salesmen from the team of a TL to another one.
+func (h *PersonHandler) TransferTeam(ctx *gin.Context) {
+	var req dto.TeamTransfer
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][PersonHandler][TransferTeam]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Request: %+v;", logPrefix, utils.JsonEncode(req)))
+
+	authData := utils.GetAuthData(ctx)
+	actor := ""
+	if authData != nil {
+		actor = utils.InterfaceString(authData["user_id"])
+	}
+
+	data, err := h.Service.TransferTeam(req, actor)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.TransferTeam; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, messages.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Team transferred successfully", logId, data)
+	logger.WriteLog(logger.LogLevelDebug, fmt.Sprintf("%s; Response: %+v;", logPrefix, utils.JsonEncode(data)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (h *PersonHandler) GetSupervisorHistory(ctx *gin.Context) {
+	id := ctx.Param("id")
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][PersonHandler][GetSupervisorHistory]", logId)
+
+	data, err := h.Service.GetSupervisorHistory(id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetSupervisorHistory; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Person not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get supervisor history successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetTeam lists the persons reporting to a TL on the date query parameter (YYYY-MM-DD, today by default).
+func (h *PersonHandler) GetTeam(ctx *gin.Context) {
+	id := ctx.Param("id")
+	logId := utils.GenerateLogId(ctx)
+	logPrefix := fmt.Sprintf("[%s][PersonHandler][GetTeam]", logId)
+
+	date := time.Now().UTC()
+	if dateStr := ctx.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			res := response.Response(http.StatusBadRequest, messages.InvalidRequest, logId, nil)
+			res.Error = "invalid date, expected YYYY-MM-DD"
+			ctx.JSON(http.StatusBadRequest, res)
+			return
+		}
+		date = parsed
+	}
+
+	data, err := h.Service.GetTeam(id, date)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Service.GetTeam; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, "Person not found", logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Get team successfully", logId, data)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,41 @@
+package interfacedataset
+
+import (
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/filter"
+)
+
+type RepoDatasetConnectorInterface interface {
+	Store(m domaindataset.DatasetConnector) error
+	GetByID(id string) (domaindataset.DatasetConnector, error)
+	GetAll(params filter.BaseParams) ([]domaindataset.DatasetConnector, int64, error)
+	GetDue(now time.Time) ([]domaindataset.DatasetConnector, error)
+	ClaimRun(id string, dueAt time.Time, nextRunAt *time.Time) (bool, error)
+	UpdateLastRun(id string, runAt time.Time, status string) error
+	Update(m domaindataset.DatasetConnector) error
+	Delete(id string, actorId string) error
+
+	StoreRun(m domaindataset.DatasetConnectorRun) error
+	UpdateRun(m domaindataset.DatasetConnectorRun) error
+	FailRunning(message string) error
+	GetRuns(connectorId string, params filter.BaseParams) ([]domaindataset.DatasetConnectorRun, int64, error)
+}
+
+type ServiceDatasetConnectorInterface interface {
+	Create(req dto.DatasetConnectorCreate, actorId string) (domaindataset.DatasetConnector, error)
+	GetByID(id string) (domaindataset.DatasetConnector, error)
+	GetAll(params filter.BaseParams) ([]domaindataset.DatasetConnector, int64, error)
+	Update(id string, req dto.DatasetConnectorUpdate, actorId string) (domaindataset.DatasetConnector, error)
+	Delete(id string, actorId string) error
+	GetRuns(id string, params filter.BaseParams) ([]domaindataset.DatasetConnectorRun, int64, error)
+
+	// RunNow starts a run in the background and returns it while it is still RUNNING.
+	RunNow(id string, actorId string) (domaindataset.DatasetConnectorRun, error)
+	// Test fetches the endpoint and previews the payload without storing anything.
+	Test(id string, actorId string) (dto.DatasetPreviewResponse, error)
+	// Start runs the due connectors in the background.
+	Start()
+}
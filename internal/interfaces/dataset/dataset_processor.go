@@ -1,7 +1,18 @@
 package interfacedataset
 
-import domaindataset "teamleader-management/internal/domain/dataset"
+import (
+	"io"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+
+	"gorm.io/gorm"
+)
 
 type DatasetProcessorInterface interface {
-	ProcessStream(ds *domaindataset.DashboardDataset, data []byte, actorId string) (domaindataset.DashboardDataset, error)
+	ProcessStream(ds *domaindataset.DashboardDataset, r io.Reader, actorId string) (domaindataset.DashboardDataset, error)
+	Preview(ds domaindataset.DashboardDataset, r io.Reader, actorId string) (dto.DatasetPreviewResponse, error)
+	Values(ds domaindataset.DashboardDataset, r io.Reader, actorId string) (dto.DatasetValues, error)
+	Reingest(tx *gorm.DB, rows []domaindataset.DatasetQuarantineRow, person domainperson.Person, actorId string) error
 }
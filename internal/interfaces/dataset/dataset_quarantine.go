@@ -0,0 +1,26 @@
+package interfacedataset
+
+import (
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/filter"
+
+	"gorm.io/gorm"
+)
+
+type RepoDatasetQuarantineInterface interface {
+	WithTx(tx *gorm.DB) RepoDatasetQuarantineInterface
+	StoreMultiple(rows []domaindataset.DatasetQuarantineRow) error
+	GetByID(id string) (domaindataset.DatasetQuarantineRow, error)
+	GetAll(params filter.BaseParams) ([]domaindataset.DatasetQuarantineRow, int64, error)
+	GetPendingByHondaID(hondaId string) ([]domaindataset.DatasetQuarantineRow, error)
+	Update(m domaindataset.DatasetQuarantineRow) error
+	DeletePendingByDatasets(datasetIds []string, actorId string) error
+}
+
+type ServiceDatasetQuarantineInterface interface {
+	GetByID(id string) (domaindataset.DatasetQuarantineRow, error)
+	GetAll(params filter.BaseParams) ([]domaindataset.DatasetQuarantineRow, int64, error)
+	Resolve(id string, req dto.DatasetQuarantineResolve, actorId string) (dto.DatasetQuarantineResolveResponse, error)
+	Discard(id string, actorId string) (domaindataset.DatasetQuarantineRow, error)
+}
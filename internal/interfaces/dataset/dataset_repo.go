@@ -2,11 +2,30 @@ package interfacedataset
 
 import (
 	domaindataset "teamleader-management/internal/domain/dataset"
+
+	"gorm.io/gorm"
 )
 
 type RepoDatasetInterface interface {
+	WithTx(tx *gorm.DB) RepoDatasetInterface
 	Store(m domaindataset.DashboardDataset) error
 	GetByID(id string) (domaindataset.DashboardDataset, error)
+	GetForUpdate(id string) (domaindataset.DashboardDataset, error)
 	Update(m domaindataset.DashboardDataset) error
+	UpdateWithHistory(m domaindataset.DashboardDataset, history []domaindataset.DatasetStatusHistory) error
+	UpdateProgress(id string, processedRows int, totalRows *int) error
 	GetAll(params map[string]interface{}) ([]domaindataset.DashboardDataset, int64, error)
+	GetByStatuses(statuses []string) ([]domaindataset.DashboardDataset, error)
+	GetByContentHash(datasetType, contentHash string) (domaindataset.DashboardDataset, error)
+	GetSupersedable(ds domaindataset.DashboardDataset) ([]domaindataset.DashboardDataset, error)
+	MarkSuperseded(ids []string, supersededBy, actorId string) error
+	GetSupersededBy(id string) ([]domaindataset.DashboardDataset, error)
+	GetCoverage(periodMonth, periodYear int) ([]domaindataset.DatasetCoverage, error)
+	Delete(id string, actorId string) error
+
+	StoreErrors(errs []domaindataset.DatasetRowError) error
+	GetErrors(datasetId string) ([]domaindataset.DatasetRowError, error)
+	DeleteErrors(datasetId string) error
+
+	StoreStatusHistory(history []domaindataset.DatasetStatusHistory) error
 }
@@ -1,13 +1,37 @@
 package interfacedataset
 
 import (
-	"mime/multipart"
+	"io"
 	domaindataset "teamleader-management/internal/domain/dataset"
 	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/file"
 )
 
 type ServiceDatasetInterface interface {
-	Create(datasetType string, req dto.DatasetUploadRequest, file multipart.File, fileHeader *multipart.FileHeader, actorId string) (domaindataset.DashboardDataset, []byte, error)
+	Create(datasetType string, req dto.DatasetUploadRequest, upload io.Reader, fileName, contentType string, actorId string) (domaindataset.DashboardDataset, *file.TempFile, error)
+	GetByID(id string) (domaindataset.DashboardDataset, error)
 	List(filters map[string]interface{}) ([]domaindataset.DashboardDataset, int64, error)
-	UpdateStatus(id string, status string, actorId string) (domaindataset.DashboardDataset, error)
+	UpdateStatus(id string, status, reason string, actorId string) (domaindataset.DashboardDataset, error)
+	GetErrors(id string) ([]domaindataset.DatasetRowError, error)
+	BuildErrorReport(id string) (string, []byte, error)
+	GetSourceFile(id string) (string, string, io.ReadCloser, error)
+	Reprocess(id string, actorId string) (domaindataset.DashboardDataset, error)
+	Delete(id string, actorId string, recalculate bool, force bool) (dto.DatasetDeleteResponse, error)
+}
+
+// EvaluationRecalculatorInterface recalculates the evaluations of a period after its data changed.
+type EvaluationRecalculatorInterface interface {
+	CheckCoverage(periodMonth int, periodYear int, force bool) ([]string, error)
+	RecalculateEvaluation(periodMonth int, periodYear int, personId string, force bool) ([]dto.EvaluationResponse, []string, error)
+}
+
+// EvaluationEstimatorInterface estimates how the evaluation scores of a period move when newDs replaces
+// oldDs, given the rows whose value differs between them.
+type EvaluationEstimatorInterface interface {
+	EstimateDatasetChange(oldDs, newDs domaindataset.DashboardDataset, changes []dto.DatasetDiffEntry) ([]dto.DatasetScoreEstimate, error)
+}
+
+// ServiceDatasetDiffInterface compares the content of two datasets of the same type.
+type ServiceDatasetDiffInterface interface {
+	Diff(id string, otherId string, actorId string) (dto.DatasetDiffResponse, error)
 }
@@ -0,0 +1,15 @@
+package interfacedataset
+
+import (
+	"io"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+)
+
+// DatasetSourceStoreInterface keeps the original uploaded file of a dataset
+// so that it can be downloaded and processed again later.
+type DatasetSourceStoreInterface interface {
+	Save(ds domaindataset.DashboardDataset, r io.Reader, size int64) error
+	Open(datasetId string) (io.ReadCloser, error)
+	Remove(datasetId string)
+}
@@ -0,0 +1,26 @@
+package interfacedataset
+
+import (
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/filter"
+)
+
+type RepoDatasetTemplateInterface interface {
+	Store(m domaindataset.DatasetTemplate) error
+	GetByID(id string) (domaindataset.DatasetTemplate, error)
+	GetByType(datasetType string) (domaindataset.DatasetTemplate, error)
+	GetAll(params filter.BaseParams) ([]domaindataset.DatasetTemplate, int64, error)
+	Update(m domaindataset.DatasetTemplate) error
+	Delete(id string) error
+}
+
+type ServiceDatasetTemplateInterface interface {
+	Create(req dto.DatasetTemplateCreate, actorId string) (domaindataset.DatasetTemplate, error)
+	GetByID(id string) (domaindataset.DatasetTemplate, error)
+	GetEffective(datasetType string) (domaindataset.DatasetTemplate, error)
+	GetAll(params filter.BaseParams) ([]domaindataset.DatasetTemplate, int64, error)
+	Update(id string, req dto.DatasetTemplateUpdate, actorId string) (domaindataset.DatasetTemplate, error)
+	Delete(id string) error
+	BuildUploadTemplate(datasetType string, req dto.DatasetTemplateDownloadRequest) (string, []byte, error)
+}
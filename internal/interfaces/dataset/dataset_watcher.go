@@ -0,0 +1,7 @@
+package interfacedataset
+
+// DatasetWatcherInterface imports the dataset files dropped in a watched directory.
+type DatasetWatcherInterface interface {
+	Start()
+	Scan()
+}
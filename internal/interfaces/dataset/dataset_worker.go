@@ -0,0 +1,12 @@
+package interfacedataset
+
+import (
+	"io"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+)
+
+type DatasetWorkerInterface interface {
+	Enqueue(ds domaindataset.DashboardDataset, upload io.Reader, size int64, actorId string) error
+	Requeue(ds domaindataset.DashboardDataset, actorId string) error
+}
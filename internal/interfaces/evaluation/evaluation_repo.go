@@ -23,6 +23,8 @@ type RepoEvaluationInterface interface {
 	StorePeriod(period domainevaluation.EvaluationPeriod) error
 	GetPeriodByMonthYear(month int, year int) (domainevaluation.EvaluationPeriod, error)
 	GetOrCreatePeriod(month int, year int) (domainevaluation.EvaluationPeriod, error)
+	GetPeriodsByYear(year int) ([]domainevaluation.EvaluationPeriod, error)
+	UpdatePeriod(period domainevaluation.EvaluationPeriod) error
 
 	// Leaderboard
 	GetLeaderboard(periodId string, limit int) ([]domainevaluation.Evaluation, error)
@@ -6,8 +6,8 @@ import (
 )
 
 type ServiceEvaluationInterface interface {
-	// Calculate evaluation for a period (for one person or all persons)
-	CalculateEvaluation(periodMonth int, periodYear int, personId string) ([]dto.EvaluationResponse, error)
+	// Calculate evaluation for a period (for one person or all persons), returning coverage warnings
+	CalculateEvaluation(periodMonth int, periodYear int, personId string, force bool) ([]dto.EvaluationResponse, []string, error)
 
 	// Get evaluation by ID with full breakdown
 	GetByID(id string) (dto.EvaluationResponse, error)
@@ -22,5 +22,12 @@ type ServiceEvaluationInterface interface {
 	GetLeaderboard(periodMonth int, periodYear int, limit int) (dto.LeaderboardResponse, error)
 
 	// Recalculate (delete old and calculate new)
-	RecalculateEvaluation(periodMonth int, periodYear int, personId string) ([]dto.EvaluationResponse, error)
+	RecalculateEvaluation(periodMonth int, periodYear int, personId string, force bool) ([]dto.EvaluationResponse, []string, error)
+
+	// Get the dataset coverage matrix of a month, or of a whole year when periodMonth is zero
+	GetCoverage(periodMonth int, periodYear int) (dto.DatasetCoverageResponse, error)
+
+	// Set the dataset types required before a period is evaluated, or go back to the default
+	SetRequiredDatasets(req dto.EvaluationRequiredDatasets, actorId string) (dto.DatasetCoveragePeriod, error)
+	ResetRequiredDatasets(periodMonth int, periodYear int, actorId string) (dto.DatasetCoveragePeriod, error)
 }
@@ -3,19 +3,33 @@ package interfacekpiitem
 import (
 	domainkpiitem "teamleader-management/internal/domain/kpiitem"
 	"teamleader-management/pkg/filter"
+
+	"gorm.io/gorm"
 )
 
 type RepoKPIItemInterface interface {
 	Store(m domainkpiitem.KPIItem) error
 	GetByID(id string) (domainkpiitem.KPIItem, error)
 	GetByNameAndPillar(name, pillarId string) (domainkpiitem.KPIItem, error)
+	GetByNames(names []string) ([]domainkpiitem.KPIItem, error)
 	GetAll(params filter.BaseParams) ([]domainkpiitem.KPIItem, int64, error)
 	Update(m domainkpiitem.KPIItem) error
 	Delete(id string) error
 }
 
 type RepoPersonKPITargetInterface interface {
+	WithTx(tx *gorm.DB) RepoPersonKPITargetInterface
 	Upsert(target domainkpiitem.PersonKPITarget) error
+	UpsertMultiple(targets []domainkpiitem.PersonKPITarget) error
 	Get(personId, kpiItemId string, periodMonth, periodYear int) (domainkpiitem.PersonKPITarget, error)
+	GetByPersons(personIds []string) ([]domainkpiitem.PersonKPITarget, error)
+	GetByPeriod(periodMonth, periodYear int, kpiItemId string) ([]domainkpiitem.PersonKPITargetRow, error)
 	Delete(personId, kpiItemId string, periodMonth, periodYear int) error
 }
+
+type RepoDealerKPITargetInterface interface {
+	Upsert(target domainkpiitem.DealerKPITarget) error
+	Get(dealerCode, kpiItemId string) (domainkpiitem.DealerKPITarget, error)
+	GetByDealer(dealerCode string) ([]domainkpiitem.DealerKPITarget, error)
+	Delete(dealerCode, kpiItemId string, actorId string) error
+}
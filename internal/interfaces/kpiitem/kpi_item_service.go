@@ -1,6 +1,9 @@
 package interfacekpiitem
 
 import (
+	"encoding/json"
+	"io"
+
 	domainkpiitem "teamleader-management/internal/domain/kpiitem"
 	"teamleader-management/internal/dto"
 	"teamleader-management/pkg/filter"
@@ -12,7 +15,26 @@ type ServiceKPIItemInterface interface {
 	GetAll(params filter.BaseParams) ([]domainkpiitem.KPIItem, int64, error)
 	Update(id string, req dto.KPIItemUpdate, actorId string) (domainkpiitem.KPIItem, error)
 	Delete(id string) error
+	GetMetrics() []dto.MetricDefinition
+	GetScoringStrategies() []dto.ScoringStrategyDefinition
 
 	UpsertPersonTarget(req dto.PersonKPITargetUpsert, actorId string) (domainkpiitem.PersonKPITarget, error)
 	DeletePersonTarget(personId, kpiItemId string, periodMonth, periodYear int, actorId string) error
+	GetDealerTargets(dealerCode string) ([]domainkpiitem.DealerKPITarget, error)
+	UpsertDealerTarget(req dto.DealerKPITargetUpsert, actorId string) (domainkpiitem.DealerKPITarget, error)
+	DeleteDealerTarget(dealerCode, kpiItemId string, actorId string) error
+	ImportPersonTargets(upload io.Reader, fileName, contentType string, dryRun bool, actorId string) (dto.PersonKPITargetImportResponse, error)
+	ExportPersonTargets(req dto.PersonKPITargetExportRequest) (string, []byte, error)
+}
+
+// MetricRegistryInterface lists the evaluation metrics KPI items can be bound to.
+type MetricRegistryInterface interface {
+	Metrics() []dto.MetricDefinition
+	HasMetric(key string) bool
+}
+
+// ScoringRegistryInterface lists the scoring strategies KPI items can use and validates their parameters.
+type ScoringRegistryInterface interface {
+	ScoringStrategies() []dto.ScoringStrategyDefinition
+	ValidateScoring(scoringType string, params json.RawMessage) error
 }
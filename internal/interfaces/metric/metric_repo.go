@@ -1,8 +1,15 @@
 package interfacemetric
 
-import domainmetric "teamleader-management/internal/domain/metric"
+import (
+	domainmetric "teamleader-management/internal/domain/metric"
+
+	"gorm.io/gorm"
+)
 
 type RepoMetricInterface interface {
+	WithTx(tx *gorm.DB) RepoMetricInterface
+	SoftDeleteByDatasets(datasetIds []string, actorId string) (int64, error)
+
 	SaveQuizResults(entries []domainmetric.QuizResult) error
 	SaveAppleLogins(entries []domainmetric.AppleLogin) error
 	SaveSalesFLP(entries []domainmetric.SalesFLP) error
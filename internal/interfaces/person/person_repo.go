@@ -1,15 +1,33 @@
 package interfaceperson
 
 import (
+	"time"
+
 	domainperson "teamleader-management/internal/domain/person"
 	"teamleader-management/pkg/filter"
+
+	"gorm.io/gorm"
 )
 
 type RepoPersonInterface interface {
+	WithTx(tx *gorm.DB) RepoPersonInterface
 	Store(m domainperson.Person) error
+	StoreMultiple(persons []domainperson.Person) error
 	GetByID(id string) (domainperson.Person, error)
 	GetByHondaID(hondaId string) (domainperson.Person, error)
+	GetByHondaIDs(hondaIds []string) ([]domainperson.Person, error)
+	GetByIDs(ids []string) ([]domainperson.Person, error)
 	GetAll(params filter.BaseParams) ([]domainperson.Person, int64, error)
 	Update(m domainperson.Person) error
 	Deactivate(id string) error
 }
+
+type RepoSupervisorAssignmentInterface interface {
+	WithTx(tx *gorm.DB) RepoSupervisorAssignmentInterface
+	Store(m domainperson.SupervisorAssignment) error
+	GetCurrent(personId string) (domainperson.SupervisorAssignment, error)
+	GetHistory(personId string) ([]domainperson.SupervisorAssignment, error)
+	GetTeamMemberIds(supervisorId string, date time.Time) ([]string, error)
+	TeamMembers(supervisorId string, date time.Time, ids []string) (map[string]domainperson.Person, error)
+	End(id string, effectiveTo time.Time, actorId string) error
+}
@@ -1,6 +1,9 @@
 package interfaceperson
 
 import (
+	"io"
+	"time"
+
 	domainperson "teamleader-management/internal/domain/person"
 	"teamleader-management/internal/dto"
 	"teamleader-management/pkg/filter"
@@ -11,5 +14,13 @@ type ServicePersonInterface interface {
 	GetByID(id string) (domainperson.Person, error)
 	GetAll(params filter.BaseParams) ([]domainperson.Person, int64, error)
 	Update(id string, req dto.PersonUpdate, actorId string) (domainperson.Person, error)
-	Delete(id string) error
+	Delete(id string, actorId string) error
+
+	AssignSupervisor(personId string, req dto.SupervisorAssign, actorId string) (domainperson.Person, error)
+	TransferTeam(req dto.TeamTransfer, actorId string) (dto.TeamTransferResponse, error)
+	GetSupervisorHistory(personId string) ([]domainperson.SupervisorAssignment, error)
+	GetTeam(supervisorId string, date time.Time) ([]domainperson.Person, error)
+
+	ImportRoster(upload io.Reader, fileName, contentType string, dryRun bool, actorId string) (dto.PersonImportResponse, error)
+	ExportRoster(params filter.BaseParams) (string, []byte, error)
 }
@@ -0,0 +1,171 @@
+package repositorydataset
+
+import (
+	"fmt"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+type connectorRepo struct {
+	DB *gorm.DB
+}
+
+func NewDatasetConnectorRepo(db *gorm.DB) interfacedataset.RepoDatasetConnectorInterface {
+	return &connectorRepo{DB: db}
+}
+
+func (r *connectorRepo) Store(m domaindataset.DatasetConnector) error {
+	return r.DB.Create(&m).Error
+}
+
+func (r *connectorRepo) GetByID(id string) (domaindataset.DatasetConnector, error) {
+	var ret domaindataset.DatasetConnector
+	if err := r.DB.Where("id = ?", id).First(&ret).Error; err != nil {
+		return domaindataset.DatasetConnector{}, err
+	}
+	return ret, nil
+}
+
+func (r *connectorRepo) GetAll(params filter.BaseParams) ([]domaindataset.DatasetConnector, int64, error) {
+	var (
+		ret       []domaindataset.DatasetConnector
+		totalData int64
+	)
+
+	query := r.DB.Model(&domaindataset.DatasetConnector{})
+
+	if params.Search != "" {
+		searchPattern := "%" + params.Search + "%"
+		query = query.Where("LOWER(name) LIKE LOWER(?)", searchPattern)
+	}
+
+	for key, value := range params.Filters {
+		s, ok := value.(string)
+		if !ok || s == "" {
+			continue
+		}
+		switch key {
+		case "dataset_type", "last_run_status":
+			query = query.Where(key+" = ?", s)
+		case "active":
+			query = query.Where("active = ?", s == "true")
+		}
+	}
+
+	if err := query.Count(&totalData).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if params.OrderBy != "" && params.OrderDirection != "" {
+		validColumns := map[string]bool{
+			"name":         true,
+			"dataset_type": true,
+			"next_run_at":  true,
+			"last_run_at":  true,
+			"created_at":   true,
+		}
+		if !validColumns[params.OrderBy] {
+			return nil, 0, fmt.Errorf("invalid orderBy column: %s", params.OrderBy)
+		}
+		query = query.Order(fmt.Sprintf("%s %s", params.OrderBy, params.OrderDirection))
+	}
+
+	if err := query.Offset(params.Offset).Limit(params.Limit).Find(&ret).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+// GetDue returns the active connectors whose next run is at or before now.
+func (r *connectorRepo) GetDue(now time.Time) ([]domaindataset.DatasetConnector, error) {
+	var ret []domaindataset.DatasetConnector
+	err := r.DB.Where("active = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Find(&ret).Error
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ClaimRun moves the next run of a due connector forward. It reports false when another instance
+// already claimed the run, i.e. next_run_at is no longer the one that was read.
+func (r *connectorRepo) ClaimRun(id string, dueAt time.Time, nextRunAt *time.Time) (bool, error) {
+	res := r.DB.Model(&domaindataset.DatasetConnector{}).
+		Where("id = ? AND next_run_at = ?", id, dueAt).
+		Update("next_run_at", nextRunAt)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *connectorRepo) UpdateLastRun(id string, runAt time.Time, status string) error {
+	return r.DB.Model(&domaindataset.DatasetConnector{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_run_at":     runAt,
+			"last_run_status": status,
+		}).Error
+}
+
+func (r *connectorRepo) Update(m domaindataset.DatasetConnector) error {
+	return r.DB.Save(&m).Error
+}
+
+func (r *connectorRepo) Delete(id string, actorId string) error {
+	if err := r.DB.Model(&domaindataset.DatasetConnector{}).Where("id = ?", id).Update("deleted_by", actorId).Error; err != nil {
+		return err
+	}
+	return r.DB.Where("id = ?", id).Delete(&domaindataset.DatasetConnector{}).Error
+}
+
+func (r *connectorRepo) StoreRun(m domaindataset.DatasetConnectorRun) error {
+	return r.DB.Create(&m).Error
+}
+
+func (r *connectorRepo) UpdateRun(m domaindataset.DatasetConnectorRun) error {
+	return r.DB.Save(&m).Error
+}
+
+// FailRunning closes the runs left RUNNING, e.g. by a restart, as FAILED.
+func (r *connectorRepo) FailRunning(message string) error {
+	return r.DB.Model(&domaindataset.DatasetConnectorRun{}).
+		Where("status = ?", utils.ConnectorRunRunning).
+		Updates(map[string]interface{}{
+			"status":      utils.ConnectorRunFailed,
+			"message":     message,
+			"finished_at": time.Now(),
+		}).Error
+}
+
+func (r *connectorRepo) GetRuns(connectorId string, params filter.BaseParams) ([]domaindataset.DatasetConnectorRun, int64, error) {
+	var (
+		ret       []domaindataset.DatasetConnectorRun
+		totalData int64
+	)
+
+	query := r.DB.Model(&domaindataset.DatasetConnectorRun{}).Where("connector_id = ?", connectorId)
+	if v, ok := params.Filters["status"].(string); ok && v != "" {
+		query = query.Where("status = ?", v)
+	}
+
+	if err := query.Count(&totalData).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("started_at DESC").Offset(params.Offset).Limit(params.Limit).Find(&ret).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+var _ interfacedataset.RepoDatasetConnectorInterface = (*connectorRepo)(nil)
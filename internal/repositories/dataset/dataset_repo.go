@@ -1,10 +1,15 @@
 package repositorydataset
 
 import (
+	"fmt"
+	"time"
+
 	domaindataset "teamleader-management/internal/domain/dataset"
 	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repo struct {
@@ -15,20 +20,126 @@ func NewDatasetRepo(db *gorm.DB) interfacedataset.RepoDatasetInterface {
 	return &repo{DB: db}
 }
 
+func (r *repo) WithTx(tx *gorm.DB) interfacedataset.RepoDatasetInterface {
+	return &repo{DB: tx}
+}
+
 func (r *repo) Store(m domaindataset.DashboardDataset) error {
-	return r.DB.Create(&m).Error
+	return r.DB.Omit(clause.Associations).Create(&m).Error
 }
 
 func (r *repo) GetByID(id string) (domaindataset.DashboardDataset, error) {
 	var ret domaindataset.DashboardDataset
-	if err := r.DB.Where("id = ?", id).First(&ret).Error; err != nil {
+	err := r.DB.Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("changed_at ASC")
+	}).Where("id = ?", id).First(&ret).Error
+	if err != nil {
+		return domaindataset.DashboardDataset{}, err
+	}
+	return ret, nil
+}
+
+// GetForUpdate returns a dataset and locks its row until the end of the transaction of r.
+func (r *repo) GetForUpdate(id string) (domaindataset.DashboardDataset, error) {
+	var ret domaindataset.DashboardDataset
+	if err := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&ret).Error; err != nil {
 		return domaindataset.DashboardDataset{}, err
 	}
 	return ret, nil
 }
 
 func (r *repo) Update(m domaindataset.DashboardDataset) error {
-	return r.DB.Save(&m).Error
+	return r.DB.Omit(clause.Associations).Save(&m).Error
+}
+
+// UpdateWithHistory saves the dataset and its new status history entries atomically.
+func (r *repo) UpdateWithHistory(m domaindataset.DashboardDataset, history []domaindataset.DatasetStatusHistory) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(&m).Error; err != nil {
+			return err
+		}
+		if len(history) == 0 {
+			return nil
+		}
+		return tx.Create(&history).Error
+	})
+}
+
+func (r *repo) UpdateProgress(id string, processedRows int, totalRows *int) error {
+	return r.DB.Model(&domaindataset.DashboardDataset{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"processed_rows": processedRows,
+			"total_rows":     totalRows,
+		}).Error
+}
+
+func (r *repo) GetByStatuses(statuses []string) ([]domaindataset.DashboardDataset, error) {
+	var ret []domaindataset.DashboardDataset
+	if err := r.DB.Where("status IN ?", statuses).Order("uploaded_at ASC").Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetByContentHash returns a live dataset of the type with identical file content.
+// Failed, superseded and archived datasets are ignored so the same file can be uploaded again.
+func (r *repo) GetByContentHash(datasetType, contentHash string) (domaindataset.DashboardDataset, error) {
+	var ret domaindataset.DashboardDataset
+	err := r.DB.Where("type = ? AND content_hash = ? AND status NOT IN ?", datasetType, contentHash,
+		[]string{utils.DatasetStatusFailed, utils.DatasetStatusSuperseded, utils.DatasetStatusArchived}).
+		Order("uploaded_at DESC").
+		First(&ret).Error
+	if err != nil {
+		return domaindataset.DashboardDataset{}, err
+	}
+	return ret, nil
+}
+
+// GetSupersedable returns the imported datasets covering the same type, period and frequency as ds.
+func (r *repo) GetSupersedable(ds domaindataset.DashboardDataset) ([]domaindataset.DashboardDataset, error) {
+	var ret []domaindataset.DashboardDataset
+	err := r.DB.Where("type = ? AND period_date = ? AND period_frequency = ? AND status = ? AND id <> ?",
+		ds.Type, ds.PeriodDate, ds.PeriodFrequency, utils.DatasetStatusDone, ds.Id).
+		Order("uploaded_at ASC").
+		Find(&ret).Error
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r *repo) MarkSuperseded(ids []string, supersededBy, actorId string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB.Model(&domaindataset.DashboardDataset{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":        utils.DatasetStatusSuperseded,
+			"superseded_by": supersededBy,
+			"updated_at":    time.Now(),
+			"updated_by":    actorId,
+		}).Error
+}
+
+// GetSupersededBy returns the datasets a dataset superseded.
+func (r *repo) GetSupersededBy(id string) ([]domaindataset.DashboardDataset, error) {
+	var ret []domaindataset.DashboardDataset
+	err := r.DB.Where("superseded_by = ? AND status = ?", id, utils.DatasetStatusSuperseded).
+		Order("uploaded_at ASC").
+		Find(&ret).Error
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r *repo) Delete(id string, actorId string) error {
+	if err := r.DB.Model(&domaindataset.DashboardDataset{}).Where("id = ?", id).Update("deleted_by", actorId).Error; err != nil {
+		return err
+	}
+	return r.DB.Where("id = ?", id).Delete(&domaindataset.DashboardDataset{}).Error
 }
 
 func (r *repo) GetAll(filters map[string]interface{}) ([]domaindataset.DashboardDataset, int64, error) {
@@ -63,4 +174,88 @@ func (r *repo) GetAll(filters map[string]interface{}) ([]domaindataset.Dashboard
 	return ret, totalData, nil
 }
 
+func (r *repo) StoreErrors(errs []domaindataset.DatasetRowError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(&errs, 500).Error
+}
+
+func (r *repo) GetErrors(datasetId string) ([]domaindataset.DatasetRowError, error) {
+	var ret []domaindataset.DatasetRowError
+	if err := r.DB.Where("dataset_id = ?", datasetId).Order("row_number ASC, column_name ASC").Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r *repo) DeleteErrors(datasetId string) error {
+	return r.DB.Where("dataset_id = ?", datasetId).Delete(&domaindataset.DatasetRowError{}).Error
+}
+
+func (r *repo) StoreStatusHistory(history []domaindataset.DatasetStatusHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	return r.DB.Create(&history).Error
+}
+
+// GetCoverage returns, per type and month, the DONE datasets of a year and the number of distinct persons
+// their metric rows cover. A periodMonth of zero covers the whole year.
+func (r *repo) GetCoverage(periodMonth, periodYear int) ([]domaindataset.DatasetCoverage, error) {
+	query := r.DB.Where("status = ? AND period_year = ?", utils.DatasetStatusDone, periodYear)
+	if periodMonth != 0 {
+		query = query.Where("period_month = ?", periodMonth)
+	}
+	var datasets []domaindataset.DashboardDataset
+	if err := query.Order("period_month ASC, type ASC, uploaded_at ASC").Find(&datasets).Error; err != nil {
+		return nil, err
+	}
+
+	type coverageKey struct {
+		datasetType string
+		periodMonth int
+	}
+	var ret []domaindataset.DatasetCoverage
+	index := make(map[coverageKey]int)
+	idsByType := make(map[string][]string)
+	for _, ds := range datasets {
+		key := coverageKey{ds.Type, ds.PeriodMonth}
+		i, ok := index[key]
+		if !ok {
+			i = len(ret)
+			index[key] = i
+			ret = append(ret, domaindataset.DatasetCoverage{Type: ds.Type, PeriodMonth: ds.PeriodMonth, PeriodYear: ds.PeriodYear})
+		}
+		ret[i].DatasetIds = append(ret[i].DatasetIds, ds.Id)
+		if ds.UploadedAt.After(ret[i].LastUploadedAt) {
+			ret[i].LastUploadedAt = ds.UploadedAt
+		}
+		idsByType[ds.Type] = append(idsByType[ds.Type], ds.Id)
+	}
+
+	for datasetType, ids := range idsByType {
+		table, ok := utils.DatasetMetricTables[datasetType]
+		if !ok {
+			continue
+		}
+		var counts []domaindataset.DatasetCoverage
+		err := r.DB.Table(table+" m").
+			Select("d.period_month, COUNT(DISTINCT m.person_id) AS persons_covered").
+			Joins("JOIN dashboard_datasets d ON d.id = m.dataset_id").
+			Where("m.dataset_id IN ? AND m.deleted_at IS NULL", ids).
+			Group("d.period_month").
+			Scan(&counts).Error
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		for _, c := range counts {
+			if i, ok := index[coverageKey{datasetType, c.PeriodMonth}]; ok {
+				ret[i].PersonsCovered = c.PersonsCovered
+			}
+		}
+	}
+	return ret, nil
+}
+
 var _ interfacedataset.RepoDatasetInterface = (*repo)(nil)
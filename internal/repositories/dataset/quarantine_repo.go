@@ -0,0 +1,118 @@
+package repositorydataset
+
+import (
+	"fmt"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+type quarantineRepo struct {
+	DB *gorm.DB
+}
+
+func NewDatasetQuarantineRepo(db *gorm.DB) interfacedataset.RepoDatasetQuarantineInterface {
+	return &quarantineRepo{DB: db}
+}
+
+func (r *quarantineRepo) WithTx(tx *gorm.DB) interfacedataset.RepoDatasetQuarantineInterface {
+	return &quarantineRepo{DB: tx}
+}
+
+func (r *quarantineRepo) StoreMultiple(rows []domaindataset.DatasetQuarantineRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(&rows, 500).Error
+}
+
+func (r *quarantineRepo) GetByID(id string) (domaindataset.DatasetQuarantineRow, error) {
+	var ret domaindataset.DatasetQuarantineRow
+	if err := r.DB.Where("id = ?", id).First(&ret).Error; err != nil {
+		return domaindataset.DatasetQuarantineRow{}, err
+	}
+	return ret, nil
+}
+
+func (r *quarantineRepo) GetAll(params filter.BaseParams) ([]domaindataset.DatasetQuarantineRow, int64, error) {
+	var (
+		ret       []domaindataset.DatasetQuarantineRow
+		totalData int64
+	)
+
+	query := r.DB.Model(&domaindataset.DatasetQuarantineRow{})
+
+	if params.Search != "" {
+		searchPattern := "%" + params.Search + "%"
+		query = query.Where("LOWER(honda_id) LIKE LOWER(?)", searchPattern)
+	}
+
+	for key, value := range params.Filters {
+		s, ok := value.(string)
+		if !ok || s == "" {
+			continue
+		}
+		switch key {
+		case "status", "dataset_id", "dataset_type", "honda_id":
+			query = query.Where(key+" = ?", s)
+		}
+	}
+
+	if err := query.Count(&totalData).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if params.OrderBy != "" && params.OrderDirection != "" {
+		validColumns := map[string]bool{
+			"created_at":  true,
+			"honda_id":    true,
+			"period_date": true,
+			"row_number":  true,
+			"status":      true,
+		}
+		if !validColumns[params.OrderBy] {
+			return nil, 0, fmt.Errorf("invalid orderBy column: %s", params.OrderBy)
+		}
+		query = query.Order(fmt.Sprintf("%s %s", params.OrderBy, params.OrderDirection))
+	}
+
+	if err := query.Offset(params.Offset).Limit(params.Limit).Find(&ret).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+func (r *quarantineRepo) GetPendingByHondaID(hondaId string) ([]domaindataset.DatasetQuarantineRow, error) {
+	var ret []domaindataset.DatasetQuarantineRow
+	if err := r.DB.Where("honda_id = ? AND status = ?", hondaId, utils.QuarantineStatusPending).
+		Order("period_date ASC, row_number ASC").
+		Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r *quarantineRepo) Update(m domaindataset.DatasetQuarantineRow) error {
+	return r.DB.Save(&m).Error
+}
+
+// DeletePendingByDatasets soft-deletes the rows of the given datasets that were never resolved.
+func (r *quarantineRepo) DeletePendingByDatasets(datasetIds []string, actorId string) error {
+	if len(datasetIds) == 0 {
+		return nil
+	}
+	return r.DB.Model(&domaindataset.DatasetQuarantineRow{}).
+		Where("dataset_id IN ? AND status = ?", datasetIds, utils.QuarantineStatusPending).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": actorId,
+		}).Error
+}
+
+var _ interfacedataset.RepoDatasetQuarantineInterface = (*quarantineRepo)(nil)
@@ -0,0 +1,85 @@
+package repositorydataset
+
+import (
+	"fmt"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/filter"
+
+	"gorm.io/gorm"
+)
+
+type templateRepo struct {
+	DB *gorm.DB
+}
+
+func NewDatasetTemplateRepo(db *gorm.DB) interfacedataset.RepoDatasetTemplateInterface {
+	return &templateRepo{DB: db}
+}
+
+func (r *templateRepo) Store(m domaindataset.DatasetTemplate) error {
+	return r.DB.Create(&m).Error
+}
+
+func (r *templateRepo) GetByID(id string) (domaindataset.DatasetTemplate, error) {
+	var ret domaindataset.DatasetTemplate
+	if err := r.DB.Where("id = ?", id).First(&ret).Error; err != nil {
+		return domaindataset.DatasetTemplate{}, err
+	}
+	return ret, nil
+}
+
+func (r *templateRepo) GetByType(datasetType string) (domaindataset.DatasetTemplate, error) {
+	var ret domaindataset.DatasetTemplate
+	if err := r.DB.Where("dataset_type = ?", datasetType).First(&ret).Error; err != nil {
+		return domaindataset.DatasetTemplate{}, err
+	}
+	return ret, nil
+}
+
+func (r *templateRepo) GetAll(params filter.BaseParams) ([]domaindataset.DatasetTemplate, int64, error) {
+	var (
+		ret       []domaindataset.DatasetTemplate
+		totalData int64
+	)
+
+	query := r.DB.Model(&domaindataset.DatasetTemplate{})
+
+	if params.Search != "" {
+		searchPattern := "%" + params.Search + "%"
+		query = query.Where("LOWER(dataset_type) LIKE LOWER(?)", searchPattern)
+	}
+
+	if err := query.Count(&totalData).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if params.OrderBy != "" && params.OrderDirection != "" {
+		validColumns := map[string]bool{
+			"dataset_type": true,
+			"created_at":   true,
+			"updated_at":   true,
+		}
+		if !validColumns[params.OrderBy] {
+			return nil, 0, fmt.Errorf("invalid orderBy column: %s", params.OrderBy)
+		}
+		query = query.Order(fmt.Sprintf("%s %s", params.OrderBy, params.OrderDirection))
+	}
+
+	if err := query.Offset(params.Offset).Limit(params.Limit).Find(&ret).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+func (r *templateRepo) Update(m domaindataset.DatasetTemplate) error {
+	return r.DB.Save(&m).Error
+}
+
+func (r *templateRepo) Delete(id string) error {
+	return r.DB.Where("id = ?", id).Delete(&domaindataset.DatasetTemplate{}).Error
+}
+
+var _ interfacedataset.RepoDatasetTemplateInterface = (*templateRepo)(nil)
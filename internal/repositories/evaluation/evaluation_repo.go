@@ -164,6 +164,19 @@ func (r *repo) GetOrCreatePeriod(month int, year int) (domainevaluation.Evaluati
 	return domainevaluation.EvaluationPeriod{}, err
 }
 
+// GetPeriodsByYear returns the stored periods of a year.
+func (r *repo) GetPeriodsByYear(year int) ([]domainevaluation.EvaluationPeriod, error) {
+	var periods []domainevaluation.EvaluationPeriod
+	if err := r.DB.Where("period_year = ?", year).Order("period_month ASC").Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+func (r *repo) UpdatePeriod(period domainevaluation.EvaluationPeriod) error {
+	return r.DB.Save(&period).Error
+}
+
 // ========================================
 // Leaderboard
 // ========================================
@@ -2,6 +2,8 @@ package repositorykpiitem
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	domainkpiitem "teamleader-management/internal/domain/kpiitem"
 	interfacekpiitem "teamleader-management/internal/interfaces/kpiitem"
@@ -39,6 +41,22 @@ func (r *repo) GetByNameAndPillar(name, pillarId string) (domainkpiitem.KPIItem,
 	return ret, nil
 }
 
+// GetByNames returns the KPI items whose name matches one of names, ignoring case.
+func (r *repo) GetByNames(names []string) ([]domainkpiitem.KPIItem, error) {
+	var ret []domainkpiitem.KPIItem
+	if len(names) == 0 {
+		return ret, nil
+	}
+	lower := make([]string, len(names))
+	for i, name := range names {
+		lower[i] = strings.ToLower(name)
+	}
+	if err := r.DB.Where("LOWER(name) IN ?", lower).Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
 func (r *repo) GetAll(params filter.BaseParams) ([]domainkpiitem.KPIItem, int64, error) {
 	var (
 		ret       []domainkpiitem.KPIItem
@@ -66,6 +84,10 @@ func (r *repo) GetAll(params filter.BaseParams) ([]domainkpiitem.KPIItem, int64,
 			query = query.Where("applies_to_tl = ?", value)
 		case "applies_to_salesman":
 			query = query.Where("applies_to_salesman = ?", value)
+		case "metric_key":
+			if s, ok := value.(string); ok && s != "" {
+				query = query.Where("metric_key = ?", s)
+			}
 		}
 	}
 
@@ -110,6 +132,10 @@ func NewPersonKPITargetRepo(db *gorm.DB) interfacekpiitem.RepoPersonKPITargetInt
 	return &personTargetRepo{DB: db}
 }
 
+func (r *personTargetRepo) WithTx(tx *gorm.DB) interfacekpiitem.RepoPersonKPITargetInterface {
+	return &personTargetRepo{DB: tx}
+}
+
 func (r *personTargetRepo) Upsert(target domainkpiitem.PersonKPITarget) error {
 	return r.DB.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "person_id"}, {Name: "kpi_item_id"}, {Name: "period_month"}, {Name: "period_year"}},
@@ -117,6 +143,17 @@ func (r *personTargetRepo) Upsert(target domainkpiitem.PersonKPITarget) error {
 	}).Create(&target).Error
 }
 
+// UpsertMultiple inserts the targets, updating the value of those that already exist for the person, KPI item and period.
+func (r *personTargetRepo) UpsertMultiple(targets []domainkpiitem.PersonKPITarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "person_id"}, {Name: "kpi_item_id"}, {Name: "period_month"}, {Name: "period_year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target_value", "updated_at", "updated_by"}),
+	}).CreateInBatches(&targets, 500).Error
+}
+
 func (r *personTargetRepo) Get(personId, kpiItemId string, periodMonth, periodYear int) (domainkpiitem.PersonKPITarget, error) {
 	var ret domainkpiitem.PersonKPITarget
 	if err := r.DB.Where("person_id = ? AND kpi_item_id = ? AND period_month = ? AND period_year = ?", personId, kpiItemId, periodMonth, periodYear).First(&ret).Error; err != nil {
@@ -125,10 +162,84 @@ func (r *personTargetRepo) Get(personId, kpiItemId string, periodMonth, periodYe
 	return ret, nil
 }
 
+func (r *personTargetRepo) GetByPersons(personIds []string) ([]domainkpiitem.PersonKPITarget, error) {
+	var ret []domainkpiitem.PersonKPITarget
+	if len(personIds) == 0 {
+		return ret, nil
+	}
+	if err := r.DB.Where("person_id IN ?", personIds).Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetByPeriod returns the targets of a period with the Honda ID and KPI item name they refer to,
+// optionally limited to one KPI item.
+func (r *personTargetRepo) GetByPeriod(periodMonth, periodYear int, kpiItemId string) ([]domainkpiitem.PersonKPITargetRow, error) {
+	var ret []domainkpiitem.PersonKPITargetRow
+	query := r.DB.Table("person_kpi_targets t").
+		Select("p.honda_id, p.name AS person_name, k.id AS kpi_item_id, k.name AS kpi_item_name, t.period_month, t.period_year, t.target_value").
+		Joins("INNER JOIN persons p ON p.id = t.person_id AND p.deleted_at IS NULL").
+		Joins("INNER JOIN kpi_items k ON k.id = t.kpi_item_id AND k.deleted_at IS NULL").
+		Where("t.deleted_at IS NULL AND t.period_month = ? AND t.period_year = ?", periodMonth, periodYear)
+	if kpiItemId != "" {
+		query = query.Where("t.kpi_item_id = ?", kpiItemId)
+	}
+	if err := query.Order("p.honda_id ASC, k.name ASC").Scan(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
 func (r *personTargetRepo) Delete(personId, kpiItemId string, periodMonth, periodYear int) error {
 	return r.DB.Where("person_id = ? AND kpi_item_id = ? AND period_month = ? AND period_year = ?", personId, kpiItemId, periodMonth, periodYear).
 		Delete(&domainkpiitem.PersonKPITarget{}).Error
 }
 
+type dealerTargetRepo struct {
+	DB *gorm.DB
+}
+
+func NewDealerKPITargetRepo(db *gorm.DB) interfacekpiitem.RepoDealerKPITargetInterface {
+	return &dealerTargetRepo{DB: db}
+}
+
+// Upsert saves the target of a dealer for a KPI item, reviving it if it was deleted.
+func (r *dealerTargetRepo) Upsert(target domainkpiitem.DealerKPITarget) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "dealer_code"}, {Name: "kpi_item_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"target_value": target.TargetValue,
+			"updated_at":   target.UpdatedAt,
+			"updated_by":   target.UpdatedBy,
+			"deleted_at":   nil,
+			"deleted_by":   "",
+		}),
+	}).Create(&target).Error
+}
+
+func (r *dealerTargetRepo) Get(dealerCode, kpiItemId string) (domainkpiitem.DealerKPITarget, error) {
+	var ret domainkpiitem.DealerKPITarget
+	if err := r.DB.Where("dealer_code = ? AND kpi_item_id = ?", dealerCode, kpiItemId).First(&ret).Error; err != nil {
+		return domainkpiitem.DealerKPITarget{}, err
+	}
+	return ret, nil
+}
+
+func (r *dealerTargetRepo) GetByDealer(dealerCode string) ([]domainkpiitem.DealerKPITarget, error) {
+	var ret []domainkpiitem.DealerKPITarget
+	if err := r.DB.Where("dealer_code = ?", dealerCode).Order("kpi_item_id ASC").Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r *dealerTargetRepo) Delete(dealerCode, kpiItemId string, actorId string) error {
+	return r.DB.Model(&domainkpiitem.DealerKPITarget{}).
+		Where("dealer_code = ? AND kpi_item_id = ?", dealerCode, kpiItemId).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by": actorId}).Error
+}
+
 var _ interfacekpiitem.RepoKPIItemInterface = (*repo)(nil)
 var _ interfacekpiitem.RepoPersonKPITargetInterface = (*personTargetRepo)(nil)
+var _ interfacekpiitem.RepoDealerKPITargetInterface = (*dealerTargetRepo)(nil)
@@ -1,8 +1,12 @@
 package repositorymetric
 
 import (
+	"fmt"
+	"time"
+
 	domainmetric "teamleader-management/internal/domain/metric"
 	interfacemetric "teamleader-management/internal/interfaces/metric"
+	"teamleader-management/utils"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -16,6 +20,33 @@ func NewMetricRepo(db *gorm.DB) interfacemetric.RepoMetricInterface {
 	return &repo{DB: db}
 }
 
+func (r *repo) WithTx(tx *gorm.DB) interfacemetric.RepoMetricInterface {
+	return &repo{DB: tx}
+}
+
+// SoftDeleteByDatasets soft-deletes the rows still owned by the given datasets in every metric table
+// and returns the number of affected rows.
+func (r *repo) SoftDeleteByDatasets(datasetIds []string, actorId string) (int64, error) {
+	if len(datasetIds) == 0 {
+		return 0, nil
+	}
+	var total int64
+	now := time.Now()
+	for _, table := range utils.DatasetMetricTableNames() {
+		res := r.DB.Table(table).
+			Where("dataset_id IN ? AND deleted_at IS NULL", datasetIds).
+			Updates(map[string]interface{}{
+				"deleted_at": now,
+				"deleted_by": actorId,
+			})
+		if res.Error != nil {
+			return total, fmt.Errorf("%s: %w", table, res.Error)
+		}
+		total += res.RowsAffected
+	}
+	return total, nil
+}
+
 func (r *repo) SaveQuizResults(entries []domainmetric.QuizResult) error {
 	if len(entries) == 0 {
 		return nil
@@ -30,6 +61,8 @@ func (r *repo) SaveQuizResults(entries []domainmetric.QuizResult) error {
 			"pass_status": clause.Expr{SQL: "EXCLUDED.pass_status"},
 			"updated_at":  clause.Expr{SQL: "EXCLUDED.updated_at"},
 			"updated_by":  clause.Expr{SQL: "EXCLUDED.updated_by"},
+			"deleted_at":  nil,
+			"deleted_by":  "",
 		}),
 	}).Create(&entries).Error
 }
@@ -49,6 +82,8 @@ func (r *repo) SaveAppleLogins(entries []domainmetric.AppleLogin) error {
 			"evening_done": clause.Expr{SQL: "EXCLUDED.evening_done"},
 			"updated_at":   clause.Expr{SQL: "EXCLUDED.updated_at"},
 			"updated_by":   clause.Expr{SQL: "EXCLUDED.updated_by"},
+			"deleted_at":   nil,
+			"deleted_by":   "",
 		}),
 	}).Create(&entries).Error
 }
@@ -66,6 +101,8 @@ func (r *repo) SaveSalesFLP(entries []domainmetric.SalesFLP) error {
 			"flp_amount":  clause.Expr{SQL: "EXCLUDED.flp_amount"},
 			"updated_at":  clause.Expr{SQL: "EXCLUDED.updated_at"},
 			"updated_by":  clause.Expr{SQL: "EXCLUDED.updated_by"},
+			"deleted_at":  nil,
+			"deleted_by":  "",
 		}),
 	}).Create(&entries).Error
 }
@@ -82,6 +119,8 @@ func (r *repo) SaveApplePoints(entries []domainmetric.ApplePoint) error {
 			"points":     clause.Expr{SQL: "EXCLUDED.points"},
 			"updated_at": clause.Expr{SQL: "EXCLUDED.updated_at"},
 			"updated_by": clause.Expr{SQL: "EXCLUDED.updated_by"},
+			"deleted_at": nil,
+			"deleted_by": "",
 		}),
 	}).Create(&entries).Error
 }
@@ -99,6 +138,8 @@ func (r *repo) SaveMyHeroPoints(entries []domainmetric.MyHeroPoint) error {
 			"points":      clause.Expr{SQL: "EXCLUDED.points"},
 			"updated_at":  clause.Expr{SQL: "EXCLUDED.updated_at"},
 			"updated_by":  clause.Expr{SQL: "EXCLUDED.updated_by"},
+			"deleted_at":  nil,
+			"deleted_by":  "",
 		}),
 	}).Create(&entries).Error
 }
@@ -115,6 +156,8 @@ func (r *repo) SaveProspects(entries []domainmetric.Prospect) error {
 			"prospect_count": clause.Expr{SQL: "EXCLUDED.prospect_count"},
 			"updated_at":     clause.Expr{SQL: "EXCLUDED.updated_at"},
 			"updated_by":     clause.Expr{SQL: "EXCLUDED.updated_by"},
+			"deleted_at":     nil,
+			"deleted_by":     "",
 		}),
 	}).Create(&entries).Error
 }
@@ -17,10 +17,21 @@ func NewPersonRepo(db *gorm.DB) interfaceperson.RepoPersonInterface {
 	return &repo{DB: db}
 }
 
+func (r *repo) WithTx(tx *gorm.DB) interfaceperson.RepoPersonInterface {
+	return &repo{DB: tx}
+}
+
 func (r *repo) Store(m domainperson.Person) error {
 	return r.DB.Create(&m).Error
 }
 
+func (r *repo) StoreMultiple(persons []domainperson.Person) error {
+	if len(persons) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(&persons, 500).Error
+}
+
 func (r *repo) GetByID(id string) (domainperson.Person, error) {
 	var ret domainperson.Person
 	if err := r.DB.Where("id = ?", id).First(&ret).Error; err != nil {
@@ -37,6 +48,30 @@ func (r *repo) GetByHondaID(hondaId string) (domainperson.Person, error) {
 	return ret, nil
 }
 
+// GetByHondaIDs returns the persons matching any of the given Honda IDs in a single query.
+func (r *repo) GetByHondaIDs(hondaIds []string) ([]domainperson.Person, error) {
+	var ret []domainperson.Person
+	if len(hondaIds) == 0 {
+		return ret, nil
+	}
+	if err := r.DB.Where("honda_id IN ?", hondaIds).Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetByIDs returns the persons with the given ids, sorted by name.
+func (r *repo) GetByIDs(ids []string) ([]domainperson.Person, error) {
+	var ret []domainperson.Person
+	if len(ids) == 0 {
+		return ret, nil
+	}
+	if err := r.DB.Where("id IN ?", ids).Order("name ASC").Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
 func (r *repo) GetAll(params filter.BaseParams) ([]domainperson.Person, int64, error) {
 	var (
 		ret       []domainperson.Person
@@ -73,6 +108,10 @@ func (r *repo) GetAll(params filter.BaseParams) ([]domainperson.Person, int64, e
 			if s, ok := value.(string); ok && s != "" {
 				query = query.Where("dealer_code = ?", s)
 			}
+		case "supervisor_id":
+			if s, ok := value.(string); ok && s != "" {
+				query = query.Where("supervisor_id = ?", s)
+			}
 		}
 	}
 
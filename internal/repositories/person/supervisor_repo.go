@@ -0,0 +1,94 @@
+package repositoryperson
+
+import (
+	"fmt"
+	"time"
+
+	domainperson "teamleader-management/internal/domain/person"
+	interfaceperson "teamleader-management/internal/interfaces/person"
+
+	"gorm.io/gorm"
+)
+
+type supervisorRepo struct {
+	DB *gorm.DB
+}
+
+func NewSupervisorAssignmentRepo(db *gorm.DB) interfaceperson.RepoSupervisorAssignmentInterface {
+	return &supervisorRepo{DB: db}
+}
+
+func (r *supervisorRepo) WithTx(tx *gorm.DB) interfaceperson.RepoSupervisorAssignmentInterface {
+	return &supervisorRepo{DB: tx}
+}
+
+func (r *supervisorRepo) Store(m domainperson.SupervisorAssignment) error {
+	return r.DB.Create(&m).Error
+}
+
+// GetCurrent returns the open assignment of a person, gorm.ErrRecordNotFound when they report to nobody.
+func (r *supervisorRepo) GetCurrent(personId string) (domainperson.SupervisorAssignment, error) {
+	var ret domainperson.SupervisorAssignment
+	if err := r.DB.Where("person_id = ? AND effective_to IS NULL", personId).First(&ret).Error; err != nil {
+		return domainperson.SupervisorAssignment{}, err
+	}
+	return ret, nil
+}
+
+// GetHistory returns the assignments of a person, the latest first.
+func (r *supervisorRepo) GetHistory(personId string) ([]domainperson.SupervisorAssignment, error) {
+	var ret []domainperson.SupervisorAssignment
+	if err := r.DB.Where("person_id = ?", personId).Order("effective_from DESC").Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetTeamMemberIds returns the persons reporting to a supervisor on a date.
+func (r *supervisorRepo) GetTeamMemberIds(supervisorId string, date time.Time) ([]string, error) {
+	var ret []string
+	err := r.DB.Model(&domainperson.SupervisorAssignment{}).
+		Where("supervisor_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", supervisorId, date, date).
+		Pluck("person_id", &ret).Error
+	return ret, err
+}
+
+// TeamMembers returns the persons of ids by id, failing when one of them does not report to the supervisor
+// on date.
+func (r *supervisorRepo) TeamMembers(supervisorId string, date time.Time, ids []string) (map[string]domainperson.Person, error) {
+	var found []domainperson.Person
+	err := r.DB.Model(&domainperson.Person{}).
+		Joins("INNER JOIN person_supervisor_assignments psa ON psa.person_id = persons.id").
+		Where("psa.supervisor_id = ? AND psa.effective_from <= ? AND (psa.effective_to IS NULL OR psa.effective_to > ?)", supervisorId, date, date).
+		Where("persons.id IN ?", ids).
+		Find(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]domainperson.Person, len(found))
+	for _, p := range found {
+		ret[p.Id] = p
+	}
+	for _, id := range ids {
+		if _, ok := ret[id]; !ok {
+			return nil, fmt.Errorf("salesman %s is not in your team on %s", id, date.Format("2006-01-02"))
+		}
+	}
+	return ret, nil
+}
+
+// End closes an assignment: it no longer applies from effectiveTo on.
+func (r *supervisorRepo) End(id string, effectiveTo time.Time, actorId string) error {
+	return r.DB.Model(&domainperson.SupervisorAssignment{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"effective_to": effectiveTo, "updated_at": time.Now(), "updated_by": actorId}).Error
+}
+
+// TeamMemberJoin joins the assignment that put the salesman of a TL record (by alias) in the team of that TL
+// on the date of the record, so records about persons outside the team at the time are left out.
+func TeamMemberJoin(alias string) string {
+	return fmt.Sprintf("INNER JOIN person_supervisor_assignments psa ON psa.person_id::text = %[1]s.salesman_id::text "+
+		"AND psa.supervisor_id::text = %[1]s.tl_person_id::text AND psa.effective_from <= %[1]s.date::date "+
+		"AND (psa.effective_to IS NULL OR psa.effective_to > %[1]s.date::date)", alias)
+}
+
+var _ interfaceperson.RepoSupervisorAssignmentInterface = (*supervisorRepo)(nil)
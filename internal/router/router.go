@@ -21,6 +21,7 @@ import (
 	sessionHandler "teamleader-management/internal/handlers/http/session"
 	tlHandler "teamleader-management/internal/handlers/http/teamleader"
 	userHandler "teamleader-management/internal/handlers/http/user"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
 	authRepo "teamleader-management/internal/repositories/auth"
 	datasetRepo "teamleader-management/internal/repositories/dataset"
 	evaluationRepo "teamleader-management/internal/repositories/evaluation"
@@ -242,15 +243,18 @@ func (r *Routes) PillarRoutes() {
 func (r *Routes) KPIItemRoutes() {
 	kRepo := kpiRepo.NewKPIItemRepo(r.DB)
 	tRepo := kpiRepo.NewPersonKPITargetRepo(r.DB)
+	dtRepo := kpiRepo.NewDealerKPITargetRepo(r.DB)
 	pRepo := pillarRepo.NewPillarRepo(r.DB)
 	prRepo := personRepo.NewPersonRepo(r.DB)
-	svc := kpiSvc.NewKPIItemService(kRepo, pRepo, prRepo, tRepo)
+	svc := kpiSvc.NewKPIItemService(kRepo, pRepo, prRepo, tRepo, dtRepo, evaluationSvc.NewMetricAggregator(r.DB), evaluationSvc.NewScoringRegistry(), r.DB)
 	h := kpiHandler.NewKPIItemHandler(svc)
 	blacklistRepo := authRepo.NewBlacklistRepo(r.DB)
 	permRepo := permissionRepo.NewPermissionRepo(r.DB)
 	mdw := middlewares.NewMiddleware(blacklistRepo, permRepo)
 
 	r.App.GET("/api/kpi-items", mdw.AuthMiddleware(), mdw.PermissionMiddleware("kpi_items", "list"), h.GetAll)
+	r.App.GET("/api/kpi-items/metrics", mdw.AuthMiddleware(), mdw.PermissionMiddleware("kpi_items", "list"), h.GetMetrics)
+	r.App.GET("/api/kpi-items/scoring-strategies", mdw.AuthMiddleware(), mdw.PermissionMiddleware("kpi_items", "list"), h.GetScoringStrategies)
 
 	kpi := r.App.Group("/api/kpi-item").Use(mdw.AuthMiddleware())
 	{
@@ -260,6 +264,11 @@ func (r *Routes) KPIItemRoutes() {
 		kpi.DELETE("/:id", mdw.PermissionMiddleware("kpi_items", "delete"), h.Delete)
 		kpi.POST("/target", mdw.PermissionMiddleware("kpi_items", "update"), h.UpsertPersonTarget)
 		kpi.DELETE("/target", mdw.PermissionMiddleware("kpi_items", "delete"), h.DeletePersonTarget)
+		kpi.POST("/target/import", mdw.PermissionMiddleware("kpi_items", "update"), h.ImportPersonTargets)
+		kpi.GET("/target/export", mdw.PermissionMiddleware("kpi_items", "view"), h.ExportPersonTargets)
+		kpi.GET("/dealer-target", mdw.PermissionMiddleware("kpi_items", "view"), h.GetDealerTargets)
+		kpi.POST("/dealer-target", mdw.PermissionMiddleware("kpi_items", "update"), h.UpsertDealerTarget)
+		kpi.DELETE("/dealer-target", mdw.PermissionMiddleware("kpi_items", "delete"), h.DeleteDealerTarget)
 	}
 }
 
@@ -267,9 +276,46 @@ func (r *Routes) DatasetRoutes() {
 	repo := datasetRepo.NewDatasetRepo(r.DB)
 	mRepo := metricRepo.NewMetricRepo(r.DB)
 	pRepo := personRepo.NewPersonRepo(r.DB)
-	svc := datasetSvc.NewDatasetService(repo)
-	processor := datasetSvc.NewProcessor(repo, mRepo, pRepo)
-	h := datasetHandler.NewDatasetHandler(svc, processor)
+	tplRepo := datasetRepo.NewDatasetTemplateRepo(r.DB)
+	qRepo := datasetRepo.NewDatasetQuarantineRepo(r.DB)
+	sources := r.datasetSourceStore()
+	evalSvc := r.evaluationService(repo)
+	svc := datasetSvc.NewDatasetService(repo, sources, tplRepo, mRepo, qRepo, evalSvc, r.DB, utils.GetEnv("DATASET_MAX_UPLOAD_MB", 100).(int))
+	tplSvc := datasetSvc.NewDatasetTemplateService(tplRepo, pRepo)
+	processor := datasetSvc.NewProcessor(repo, mRepo, pRepo, tplRepo, qRepo, r.DB, utils.GetEnv("DATASET_INSERT_CHUNK_SIZE", 500).(int))
+	qSvc := datasetSvc.NewDatasetQuarantineService(qRepo, repo, pRepo, personSvc.NewPersonService(pRepo, personRepo.NewSupervisorAssignmentRepo(r.DB), r.DB), processor, r.DB)
+	worker := datasetSvc.NewWorkerPool(
+		processor,
+		repo,
+		sources,
+		utils.GetEnv("DATASET_WORKER_COUNT", 2).(int),
+		utils.GetEnv("DATASET_QUEUE_SIZE", 100).(int),
+	)
+	worker.Start()
+	worker.ResumePending()
+	watcher := datasetSvc.NewDirectoryWatcher(
+		svc,
+		sources,
+		processor,
+		utils.GetEnv("DATASET_WATCH_DIR", "").(string),
+		time.Duration(utils.GetEnv("DATASET_WATCH_INTERVAL_SECONDS", 60).(int))*time.Second,
+		utils.GetEnv("DATASET_WATCH_ACTOR", utils.DatasetSystemActor).(string),
+	)
+	watcher.Start()
+	connectorSvc := datasetSvc.NewDatasetConnectorService(
+		datasetRepo.NewDatasetConnectorRepo(r.DB),
+		svc,
+		sources,
+		processor,
+		time.Duration(utils.GetEnv("DATASET_CONNECTOR_TIMEOUT_SECONDS", 120).(int))*time.Second,
+		time.Duration(utils.GetEnv("DATASET_CONNECTOR_POLL_SECONDS", 60).(int))*time.Second,
+	)
+	connectorSvc.Start()
+	diffSvc := datasetSvc.NewDatasetDiffService(repo, sources, processor, pRepo, evalSvc)
+	h := datasetHandler.NewDatasetHandler(svc, worker, processor, diffSvc)
+	tplHandler := datasetHandler.NewDatasetTemplateHandler(tplSvc)
+	qHandler := datasetHandler.NewDatasetQuarantineHandler(qSvc)
+	connectorHandler := datasetHandler.NewDatasetConnectorHandler(connectorSvc)
 	blacklistRepo := authRepo.NewBlacklistRepo(r.DB)
 	permRepo := permissionRepo.NewPermissionRepo(r.DB)
 	mdw := middlewares.NewMiddleware(blacklistRepo, permRepo)
@@ -277,24 +323,64 @@ func (r *Routes) DatasetRoutes() {
 	upload := r.App.Group("/api/admin/upload").Use(mdw.AuthMiddleware())
 	{
 		upload.POST("/:type", mdw.PermissionMiddleware("datasets", "create"), h.Upload)
+		upload.GET("/:type/template", mdw.PermissionMiddleware("datasets", "view"), tplHandler.DownloadUploadTemplate)
 	}
 
 	ds := r.App.Group("/api/admin/datasets").Use(mdw.AuthMiddleware())
 	{
 		ds.GET("", mdw.PermissionMiddleware("datasets", "list"), h.List)
+		ds.GET("/:id", mdw.PermissionMiddleware("datasets", "view"), h.GetByID)
+		ds.GET("/:id/file", mdw.PermissionMiddleware("datasets", "view"), h.DownloadSource)
+		ds.GET("/:id/diff/:otherId", mdw.PermissionMiddleware("datasets", "view"), h.Diff)
+		ds.POST("/:id/reprocess", mdw.PermissionMiddleware("datasets", "update"), h.Reprocess)
+		ds.GET("/:id/errors", mdw.PermissionMiddleware("datasets", "view"), h.GetErrors)
+		ds.GET("/:id/errors/report", mdw.PermissionMiddleware("datasets", "view"), h.DownloadErrorReport)
 		ds.PUT("/:id/status", mdw.PermissionMiddleware("datasets", "update"), h.UpdateStatus)
+		ds.DELETE("/:id", mdw.PermissionMiddleware("datasets", "delete"), h.Delete)
+	}
+
+	tpl := r.App.Group("/api/admin/dataset-templates").Use(mdw.AuthMiddleware())
+	{
+		tpl.GET("", mdw.PermissionMiddleware("datasets", "list"), tplHandler.GetAll)
+		tpl.POST("", mdw.PermissionMiddleware("datasets", "create"), tplHandler.Create)
+		tpl.GET("/type/:type", mdw.PermissionMiddleware("datasets", "view"), tplHandler.GetEffective)
+		tpl.GET("/:id", mdw.PermissionMiddleware("datasets", "view"), tplHandler.GetByID)
+		tpl.PUT("/:id", mdw.PermissionMiddleware("datasets", "update"), tplHandler.Update)
+		tpl.DELETE("/:id", mdw.PermissionMiddleware("datasets", "delete"), tplHandler.Delete)
+	}
+
+	quarantine := r.App.Group("/api/admin/dataset-quarantine").Use(mdw.AuthMiddleware())
+	{
+		quarantine.GET("", mdw.PermissionMiddleware("datasets", "list"), qHandler.GetAll)
+		quarantine.GET("/:id", mdw.PermissionMiddleware("datasets", "view"), qHandler.GetByID)
+		quarantine.POST("/:id/resolve", mdw.PermissionMiddleware("datasets", "update"), qHandler.Resolve)
+		quarantine.POST("/:id/discard", mdw.PermissionMiddleware("datasets", "update"), qHandler.Discard)
+	}
+
+	connector := r.App.Group("/api/admin/connectors").Use(mdw.AuthMiddleware())
+	{
+		connector.GET("", mdw.PermissionMiddleware("datasets", "list"), connectorHandler.GetAll)
+		connector.POST("", mdw.PermissionMiddleware("datasets", "create"), connectorHandler.Create)
+		connector.GET("/:id", mdw.PermissionMiddleware("datasets", "view"), connectorHandler.GetByID)
+		connector.PUT("/:id", mdw.PermissionMiddleware("datasets", "update"), connectorHandler.Update)
+		connector.DELETE("/:id", mdw.PermissionMiddleware("datasets", "delete"), connectorHandler.Delete)
+		connector.GET("/:id/runs", mdw.PermissionMiddleware("datasets", "view"), connectorHandler.GetRuns)
+		connector.POST("/:id/run", mdw.PermissionMiddleware("datasets", "update"), connectorHandler.Run)
+		connector.POST("/:id/test", mdw.PermissionMiddleware("datasets", "update"), connectorHandler.Test)
 	}
 }
 
 func (r *Routes) PersonRoutes() {
 	repo := personRepo.NewPersonRepo(r.DB)
-	svc := personSvc.NewPersonService(repo)
+	svc := personSvc.NewPersonService(repo, personRepo.NewSupervisorAssignmentRepo(r.DB), r.DB)
 	h := personHandler.NewPersonHandler(svc)
 	blacklistRepo := authRepo.NewBlacklistRepo(r.DB)
 	pRepo := permissionRepo.NewPermissionRepo(r.DB)
 	mdw := middlewares.NewMiddleware(blacklistRepo, pRepo)
 
 	r.App.GET("/api/persons", mdw.AuthMiddleware(), mdw.PermissionMiddleware("persons", "list"), h.GetAll)
+	r.App.GET("/api/persons/export", mdw.AuthMiddleware(), mdw.PermissionMiddleware("persons", "list"), h.ExportRoster)
+	r.App.POST("/api/persons/transfer", mdw.AuthMiddleware(), mdw.PermissionMiddleware("persons", "update"), h.TransferTeam)
 
 	person := r.App.Group("/api/person").Use(mdw.AuthMiddleware())
 	{
@@ -302,6 +388,10 @@ func (r *Routes) PersonRoutes() {
 		person.GET("/:id", mdw.PermissionMiddleware("persons", "view"), h.GetByID)
 		person.PUT("/:id", mdw.PermissionMiddleware("persons", "update"), h.Update)
 		person.DELETE("/:id", mdw.PermissionMiddleware("persons", "delete"), h.Delete)
+		person.POST("/import", mdw.PermissionMiddleware("persons", "create"), h.ImportRoster)
+		person.PUT("/:id/supervisor", mdw.PermissionMiddleware("persons", "update"), h.AssignSupervisor)
+		person.GET("/:id/supervisor-history", mdw.PermissionMiddleware("persons", "view"), h.GetSupervisorHistory)
+		person.GET("/:id/team", mdw.PermissionMiddleware("persons", "view"), h.GetTeam)
 	}
 }
 
@@ -330,6 +420,33 @@ func (r *Routes) SessionRoutes() {
 	logger.WriteLog(logger.LogLevelInfo, "Session management routes registered")
 }
 
+// evaluationService builds the evaluation service with the dataset coverage gate configured from the environment.
+func (r *Routes) evaluationService(datasets interfacedataset.RepoDatasetInterface) *evaluationSvc.ServiceEvaluation {
+	return evaluationSvc.NewEvaluationService(
+		evaluationRepo.NewEvaluationRepo(r.DB),
+		datasets,
+		r.DB,
+		utils.GetEnv("EVALUATION_REQUIRED_DATASETS", "").(string),
+		utils.GetEnv("EVALUATION_COVERAGE_MODE", utils.EvaluationCoverageStrict).(string),
+	)
+}
+
+// datasetSourceStore keeps original dataset files in object storage, or in the local spool
+// directory when DATASET_SOURCE_STORE=spool or the storage provider cannot be initialized.
+func (r *Routes) datasetSourceStore() interfacedataset.DatasetSourceStoreInterface {
+	spool := datasetSvc.NewSpoolStore(utils.GetEnv("DATASET_SPOOL_DIR", "storage/datasets").(string))
+	if utils.GetEnv("DATASET_SOURCE_STORE", "storage").(string) == "spool" {
+		return spool
+	}
+
+	storageProvider, err := media.InitStorage()
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, "Failed to initialize storage provider, dataset files are kept in the local spool: "+err.Error())
+		return spool
+	}
+	return datasetSvc.NewObjectSourceStore(storageProvider, mediaRepo.NewMediaRepo(r.DB))
+}
+
 func (r *Routes) TLRoutes() {
 	// Initialize storage provider for file uploads
 	storageProvider, err := media.InitStorage()
@@ -343,13 +460,14 @@ func (r *Routes) TLRoutes() {
 	attendanceRepo := tlAttendanceRepo.NewTLAttendanceRepo(r.DB)
 	tlsessionRepo := tlSessionRepo.NewTLSessionRepo(r.DB)
 	trainingRepo := tlTrainingRepo.NewTLTrainingRepo(r.DB)
+	teamRepo := personRepo.NewSupervisorAssignmentRepo(r.DB)
 
 	// Initialize services (with storage provider for file uploads)
 	mediaService := mediaSvc.NewMediaService(mediaRepository, storageProvider)
 	activityService := tlActivitySvc.NewTLActivityService(activityRepo, mediaService)
-	attendanceService := tlAttendanceSvc.NewTLAttendanceService(attendanceRepo)
+	attendanceService := tlAttendanceSvc.NewTLAttendanceService(attendanceRepo, teamRepo)
 	sessionService := tlSessionSvc.NewTLSessionService(tlsessionRepo, mediaService)
-	trainingService := tlTrainingSvc.NewTLTrainingService(trainingRepo)
+	trainingService := tlTrainingSvc.NewTLTrainingService(trainingRepo, teamRepo)
 
 	// Initialize handlers
 	activityHandler := tlHandler.NewTLActivityHandler(activityService, mediaService)
@@ -412,11 +530,8 @@ func (r *Routes) TLRoutes() {
 }
 
 func (r *Routes) EvaluationRoutes() {
-	// Initialize repositories
-	evalRepo := evaluationRepo.NewEvaluationRepo(r.DB)
-
 	// Initialize services
-	evalService := evaluationSvc.NewEvaluationService(evalRepo, r.DB)
+	evalService := r.evaluationService(datasetRepo.NewDatasetRepo(r.DB))
 
 	// Initialize handlers
 	evalHandler := evaluationHandler.NewEvaluationHandler(evalService)
@@ -435,6 +550,13 @@ func (r *Routes) EvaluationRoutes() {
 		// Recalculate evaluation (admin only)
 		evaluation.POST("/recalculate", mdw.PermissionMiddleware("evaluations", "update"), evalHandler.RecalculateEvaluation)
 
+		// Dataset coverage of a month or year and the required datasets
+		evaluation.GET("/coverage", mdw.PermissionMiddleware("evaluations", "view"), evalHandler.GetCoverage)
+
+		// Dataset types required before a period is evaluated, overriding EVALUATION_REQUIRED_DATASETS
+		evaluation.PUT("/required-datasets", mdw.PermissionMiddleware("evaluations", "update"), evalHandler.SetRequiredDatasets)
+		evaluation.DELETE("/required-datasets", mdw.PermissionMiddleware("evaluations", "update"), evalHandler.ResetRequiredDatasets)
+
 		// Get evaluation by ID
 		evaluation.GET("/:id", mdw.PermissionMiddleware("evaluations", "view"), evalHandler.GetByID)
 
@@ -9,6 +9,8 @@ import (
 	domainperson "teamleader-management/internal/domain/person"
 	"teamleader-management/internal/dto"
 	interfaceevaluation "teamleader-management/internal/interfaces/evaluation"
+	repositoryperson "teamleader-management/internal/repositories/person"
+	"teamleader-management/utils"
 
 	"gorm.io/gorm"
 )
@@ -369,25 +371,28 @@ func (s *TLDashboardService) getQuickStats(personId string, periodMonth int, per
 		Where("person_id = ? AND session_type = 'briefing' AND date >= ? AND date <= ? AND deleted_at IS NULL", personId, startDate, endDate).
 		Count(&briefingCount)
 
-	// Count training participations
+	// Count training participations of team members
 	var trainingCount int64
-	s.DB.Table("tl_training_participations").
-		Where("person_id = ? AND start_date >= ? AND start_date <= ? AND deleted_at IS NULL", personId, startDate, endDate).
+	s.DB.Table("tl_training_participations r").
+		Joins(repositoryperson.TeamMemberJoin("r")).
+		Where("r.tl_person_id = ? AND r.status = 'hadir' AND r.date >= ? AND r.date <= ? AND r.deleted_at IS NULL", personId, startDate, endDate).
 		Count(&trainingCount)
 
-	// Get team size (TODO: implement proper supervisor relationship)
+	// Get team size at the end of the period
 	var teamSize int64
-	s.DB.Table("persons").
-		Where("role = 'salesman' AND active = true").
+	s.DB.Table("person_supervisor_assignments psa").
+		Joins("INNER JOIN persons p ON p.id = psa.person_id AND p.role = ? AND p.deleted_at IS NULL", utils.RoleSM).
+		Where("psa.supervisor_id = ? AND psa.effective_from <= ? AND (psa.effective_to IS NULL OR psa.effective_to > ?)", personId, endDate, endDate).
 		Count(&teamSize)
 
-	// Calculate attendance rate
+	// Calculate attendance rate of team members
 	var result struct {
 		AvgRate float64
 	}
-	s.DB.Table("tl_attendance_records").
-		Select("COALESCE(AVG(CASE WHEN status = 'hadir' THEN 100.0 ELSE 0.0 END), 0) as avg_rate").
-		Where("tl_person_id = ? AND date >= ? AND date <= ? AND deleted_at IS NULL", personId, startDate, endDate).
+	s.DB.Table("tl_attendance_records r").
+		Select("COALESCE(AVG(CASE WHEN r.status = 'hadir' THEN 100.0 ELSE 0.0 END), 0) as avg_rate").
+		Joins(repositoryperson.TeamMemberJoin("r")).
+		Where("r.tl_person_id = ? AND r.date >= ? AND r.date <= ? AND r.deleted_at IS NULL", personId, startDate, endDate).
 		Scan(&result)
 
 	return dto.TLQuickStats{
@@ -0,0 +1,458 @@
+package servicedataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/cron"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/utils"
+)
+
+// ServiceDatasetConnector manages the HTTP pull connectors and runs them on their cron schedule.
+// A run downloads the payload, registers it through ServiceDataset.Create and processes it right away.
+type ServiceDatasetConnector struct {
+	Repo      interfacedataset.RepoDatasetConnectorInterface
+	Datasets  interfacedataset.ServiceDatasetInterface
+	Sources   interfacedataset.DatasetSourceStoreInterface
+	Processor interfacedataset.DatasetProcessorInterface
+	Client    *http.Client
+
+	pollInterval time.Duration
+	running      sync.Map // connector id -> struct{}, prevents overlapping runs of a connector
+	start        sync.Once
+}
+
+func NewDatasetConnectorService(repo interfacedataset.RepoDatasetConnectorInterface, datasets interfacedataset.ServiceDatasetInterface, sources interfacedataset.DatasetSourceStoreInterface, processor interfacedataset.DatasetProcessorInterface, timeout time.Duration, pollInterval time.Duration) *ServiceDatasetConnector {
+	if pollInterval <= 0 {
+		pollInterval = time.Minute
+	}
+	return &ServiceDatasetConnector{
+		Repo:         repo,
+		Datasets:     datasets,
+		Sources:      sources,
+		Processor:    processor,
+		Client:       &http.Client{Timeout: timeout},
+		pollInterval: pollInterval,
+	}
+}
+
+func (s *ServiceDatasetConnector) Create(req dto.DatasetConnectorCreate, actorId string) (domaindataset.DatasetConnector, error) {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	frequency := strings.ToUpper(strings.TrimSpace(req.PeriodFrequency))
+	if frequency == "" {
+		frequency = utils.PeriodDaily
+	}
+
+	entity := domaindataset.DatasetConnector{
+		Id:              utils.CreateUUID(),
+		Name:            strings.TrimSpace(req.Name),
+		URL:             strings.TrimSpace(req.URL),
+		AuthHeader:      strings.TrimSpace(req.AuthHeader),
+		AuthValue:       req.AuthValue,
+		Format:          strings.ToLower(req.Format),
+		DatasetType:     strings.ToUpper(strings.TrimSpace(req.DatasetType)),
+		PeriodFrequency: frequency,
+		PeriodLagDays:   req.PeriodLagDays,
+		Schedule:        strings.TrimSpace(req.Schedule),
+		Active:          active,
+		CreatedAt:       time.Now(),
+		CreatedBy:       actorId,
+	}
+	if err := prepareConnector(&entity, time.Now()); err != nil {
+		return domaindataset.DatasetConnector{}, err
+	}
+
+	if err := s.Repo.Store(entity); err != nil {
+		return domaindataset.DatasetConnector{}, err
+	}
+	return entity, nil
+}
+
+func (s *ServiceDatasetConnector) GetByID(id string) (domaindataset.DatasetConnector, error) {
+	return s.Repo.GetByID(id)
+}
+
+func (s *ServiceDatasetConnector) GetAll(params filter.BaseParams) ([]domaindataset.DatasetConnector, int64, error) {
+	params.Filters = filter.WhitelistFilter(params.Filters, []string{"dataset_type", "active", "last_run_status"})
+	return s.Repo.GetAll(params)
+}
+
+func (s *ServiceDatasetConnector) Update(id string, req dto.DatasetConnectorUpdate, actorId string) (domaindataset.DatasetConnector, error) {
+	c, err := s.Repo.GetByID(id)
+	if err != nil {
+		return domaindataset.DatasetConnector{}, err
+	}
+
+	if req.Name != nil {
+		c.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		c.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.AuthHeader != nil {
+		c.AuthHeader = strings.TrimSpace(*req.AuthHeader)
+	}
+	if req.AuthValue != nil {
+		c.AuthValue = *req.AuthValue
+	}
+	if req.Format != nil {
+		c.Format = strings.ToLower(*req.Format)
+	}
+	if req.DatasetType != nil {
+		c.DatasetType = strings.ToUpper(strings.TrimSpace(*req.DatasetType))
+	}
+	if req.PeriodFrequency != nil {
+		c.PeriodFrequency = strings.ToUpper(strings.TrimSpace(*req.PeriodFrequency))
+	}
+	if req.PeriodLagDays != nil {
+		c.PeriodLagDays = *req.PeriodLagDays
+	}
+	if req.Schedule != nil {
+		c.Schedule = strings.TrimSpace(*req.Schedule)
+	}
+	if req.Active != nil {
+		c.Active = *req.Active
+	}
+	if err := prepareConnector(&c, time.Now()); err != nil {
+		return domaindataset.DatasetConnector{}, err
+	}
+
+	c.UpdatedAt = time.Now()
+	c.UpdatedBy = actorId
+	if err := s.Repo.Update(c); err != nil {
+		return domaindataset.DatasetConnector{}, err
+	}
+	return c, nil
+}
+
+func (s *ServiceDatasetConnector) Delete(id string, actorId string) error {
+	if _, err := s.Repo.GetByID(id); err != nil {
+		return err
+	}
+	return s.Repo.Delete(id, actorId)
+}
+
+func (s *ServiceDatasetConnector) GetRuns(id string, params filter.BaseParams) ([]domaindataset.DatasetConnectorRun, int64, error) {
+	if _, err := s.Repo.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	params.Filters = filter.WhitelistFilter(params.Filters, []string{"status"})
+	return s.Repo.GetRuns(id, params)
+}
+
+// RunNow starts a run of the connector in the background; its outcome is recorded on the returned run.
+func (s *ServiceDatasetConnector) RunNow(id string, actorId string) (domaindataset.DatasetConnectorRun, error) {
+	c, err := s.Repo.GetByID(id)
+	if err != nil {
+		return domaindataset.DatasetConnectorRun{}, err
+	}
+	return s.startRun(c, utils.ConnectorTriggerManual, actorId)
+}
+
+// Test downloads the payload of the connector and previews it like a dry-run upload. Nothing is stored.
+func (s *ServiceDatasetConnector) Test(id string, actorId string) (dto.DatasetPreviewResponse, error) {
+	c, err := s.Repo.GetByID(id)
+	if err != nil {
+		return dto.DatasetPreviewResponse{}, err
+	}
+
+	periodDate := connectorPeriodDate(c, time.Now())
+	resp, err := s.fetch(c, periodDate)
+	if err != nil {
+		return dto.DatasetPreviewResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	req := connectorUploadRequest(c, periodDate)
+	req.DryRun = true
+	ds, spooled, err := s.Datasets.Create(c.DatasetType, req, resp.Body, connectorFileName(c, periodDate), resp.Header.Get("Content-Type"), actorId)
+	if err != nil {
+		return dto.DatasetPreviewResponse{}, err
+	}
+	defer spooled.Close()
+
+	return s.Processor.Preview(ds, spooled, actorId)
+}
+
+// Start runs the due connectors every poll interval. Runs left RUNNING by a previous process are closed
+// as FAILED first. Calling it more than once has no effect.
+func (s *ServiceDatasetConnector) Start() {
+	s.start.Do(func() {
+		if err := s.Repo.FailRunning("interrupted by a restart"); err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetConnector] Repo.FailRunning; Error: %+v", err))
+		}
+		go func() {
+			ticker := time.NewTicker(s.pollInterval)
+			defer ticker.Stop()
+			for {
+				s.runDue(time.Now())
+				<-ticker.C
+			}
+		}()
+		logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("[DatasetConnector] scheduler started, polling every %s", s.pollInterval))
+	})
+}
+
+// runDue starts the connectors whose next run has come. Each run is claimed by moving next_run_at
+// forward first, so that several instances of the application never run a connector twice.
+func (s *ServiceDatasetConnector) runDue(now time.Time) {
+	due, err := s.Repo.GetDue(now)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetConnector] Repo.GetDue; Error: %+v", err))
+		return
+	}
+
+	for _, c := range due {
+		next := nextConnectorRun(c.Schedule, now)
+		claimed, err := s.Repo.ClaimRun(c.Id, *c.NextRunAt, next)
+		if err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetConnector][%s] Repo.ClaimRun; Error: %+v", c.Id, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if _, err := s.startRun(c, utils.ConnectorTriggerSchedule, utils.DatasetSystemActor); err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetConnector][%s] %s", c.Id, err))
+		}
+	}
+}
+
+// startRun records a RUNNING run and executes it in the background.
+func (s *ServiceDatasetConnector) startRun(c domaindataset.DatasetConnector, trigger, actorId string) (domaindataset.DatasetConnectorRun, error) {
+	if _, busy := s.running.LoadOrStore(c.Id, struct{}{}); busy {
+		return domaindataset.DatasetConnectorRun{}, errors.New("connector is already running")
+	}
+
+	run := domaindataset.DatasetConnectorRun{
+		Id:          utils.CreateUUID(),
+		ConnectorId: c.Id,
+		Trigger:     trigger,
+		Status:      utils.ConnectorRunRunning,
+		StartedAt:   time.Now(),
+		TriggeredBy: actorId,
+	}
+	if err := s.Repo.StoreRun(run); err != nil {
+		s.running.Delete(c.Id)
+		return domaindataset.DatasetConnectorRun{}, err
+	}
+
+	go func() {
+		defer s.running.Delete(c.Id)
+		s.execute(c, run, actorId)
+	}()
+	return run, nil
+}
+
+// execute downloads and imports the payload of a connector and records the outcome of the run.
+func (s *ServiceDatasetConnector) execute(c domaindataset.DatasetConnector, run domaindataset.DatasetConnectorRun, actorId string) {
+	logPrefix := fmt.Sprintf("[DatasetConnector][%s][%s]", c.Name, run.Id)
+	logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("%s; %s run started", logPrefix, run.Trigger))
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.finishRun(&run, utils.ConnectorRunFailed, fmt.Sprintf("run aborted: %v", r))
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; panic: %v", logPrefix, r))
+		}
+	}()
+
+	status, message := s.importPayload(c, &run, actorId)
+	s.finishRun(&run, status, message)
+
+	level := logger.LogLevelInfo
+	if status == utils.ConnectorRunFailed {
+		level = logger.LogLevelError
+	}
+	logger.WriteLog(level, fmt.Sprintf("%s; %s: %s", logPrefix, status, message))
+}
+
+func (s *ServiceDatasetConnector) importPayload(c domaindataset.DatasetConnector, run *domaindataset.DatasetConnectorRun, actorId string) (string, string) {
+	periodDate := connectorPeriodDate(c, run.StartedAt)
+	resp, err := s.fetch(c, periodDate)
+	if resp != nil {
+		run.HttpStatus = &resp.StatusCode
+	}
+	if err != nil {
+		return utils.ConnectorRunFailed, err.Error()
+	}
+	defer resp.Body.Close()
+
+	body := &countingReader{r: resp.Body}
+	ds, spooled, err := s.Datasets.Create(c.DatasetType, connectorUploadRequest(c, periodDate), body, connectorFileName(c, periodDate), resp.Header.Get("Content-Type"), actorId)
+	run.BytesRead = body.n
+	var duplicate *DuplicateUploadError
+	if errors.As(err, &duplicate) {
+		run.DatasetId = &duplicate.Existing.Id
+		return utils.ConnectorRunSkipped, err.Error()
+	}
+	if err != nil {
+		return utils.ConnectorRunFailed, err.Error()
+	}
+	defer spooled.Close()
+
+	run.DatasetId = &ds.Id
+	processed, err := importSpooled(s.Sources, s.Processor, ds, spooled, actorId)
+	if err != nil {
+		return utils.ConnectorRunFailed, err.Error()
+	}
+	return utils.ConnectorRunSuccess, fmt.Sprintf("%d row(s) imported into dataset %s", processed.ProcessedRows, processed.Id)
+}
+
+// fetch requests the payload of the connector for a period. Responses other than 2xx are returned with an error.
+func (s *ServiceDatasetConnector) fetch(c domaindataset.DatasetConnector, periodDate time.Time) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, expandConnectorURL(c.URL, periodDate), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.HasAuth() {
+		req.Header.Set(c.AuthHeader, c.AuthValue)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return resp, fmt.Errorf("endpoint returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp, nil
+}
+
+func (s *ServiceDatasetConnector) finishRun(run *domaindataset.DatasetConnectorRun, status, message string) {
+	finishedAt := time.Now()
+	run.Status = status
+	run.Message = &message
+	run.FinishedAt = &finishedAt
+	if err := s.Repo.UpdateRun(*run); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetConnector][%s] Repo.UpdateRun; Error: %+v", run.Id, err))
+	}
+	if err := s.Repo.UpdateLastRun(run.ConnectorId, run.StartedAt, status); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetConnector][%s] Repo.UpdateLastRun; Error: %+v", run.ConnectorId, err))
+	}
+}
+
+// prepareConnector validates a connector and schedules its next run; inactive connectors have none.
+func prepareConnector(c *domaindataset.DatasetConnector, now time.Time) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if !utils.AllowedDatasetTypes[c.DatasetType] {
+		return fmt.Errorf("invalid dataset type: %s", c.DatasetType)
+	}
+	if !utils.AllowedPeriodFrequencies[c.PeriodFrequency] {
+		return errors.New("invalid period_frequency")
+	}
+	if utils.DatasetDailyOnlyTypes[c.DatasetType] && c.PeriodFrequency != utils.PeriodDaily {
+		return fmt.Errorf("%s connectors can only import with %s frequency", c.DatasetType, utils.PeriodDaily)
+	}
+	switch c.Format {
+	case "xlsx", "csv", "json":
+	default:
+		return errors.New("format must be xlsx, csv or json")
+	}
+	if c.PeriodLagDays < 0 {
+		return errors.New("period_lag_days must not be negative")
+	}
+	if (c.AuthHeader == "") != (c.AuthValue == "") {
+		return errors.New("auth_header and auth_value must be set together")
+	}
+
+	u, err := url.Parse(expandConnectorURL(c.URL, now))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	schedule, err := cron.Parse(c.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return errors.New("invalid schedule: it never runs")
+	}
+
+	c.NextRunAt = nil
+	if c.Active {
+		c.NextRunAt = &next
+	}
+	return nil
+}
+
+// nextConnectorRun returns the run after now, or nil when the schedule cannot be parsed anymore.
+func nextConnectorRun(expr string, now time.Time) *time.Time {
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// connectorPeriodDate is the period a run fetches: the run date minus the lag, aligned on the start of
+// the week, month, quarter or year for the coarser frequencies.
+func connectorPeriodDate(c domaindataset.DatasetConnector, runAt time.Time) time.Time {
+	d := time.Date(runAt.Year(), runAt.Month(), runAt.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -c.PeriodLagDays)
+	switch c.PeriodFrequency {
+	case utils.PeriodWeekly:
+		return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+	case utils.PeriodMonthly:
+		return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case utils.PeriodQuarterly:
+		return time.Date(d.Year(), d.Month()-(d.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case utils.PeriodYearly:
+		return time.Date(d.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return d
+}
+
+func connectorUploadRequest(c domaindataset.DatasetConnector, periodDate time.Time) dto.DatasetUploadRequest {
+	return dto.DatasetUploadRequest{
+		Type:            c.DatasetType,
+		PeriodDate:      periodDate.Format("2006-01-02"),
+		PeriodFrequency: c.PeriodFrequency,
+	}
+}
+
+func connectorFileName(c domaindataset.DatasetConnector, periodDate time.Time) string {
+	return fmt.Sprintf("%s_%s.%s", c.DatasetType, periodDate.Format("2006-01-02"), c.Format)
+}
+
+func expandConnectorURL(raw string, periodDate time.Time) string {
+	return strings.NewReplacer(
+		"{period_date}", periodDate.Format("2006-01-02"),
+		"{period_month}", fmt.Sprintf("%02d", periodDate.Month()),
+		"{period_year}", fmt.Sprintf("%d", periodDate.Year()),
+	).Replace(raw)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+var _ interfacedataset.ServiceDatasetConnectorInterface = (*ServiceDatasetConnector)(nil)
@@ -0,0 +1,209 @@
+package servicedataset
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/file"
+	"teamleader-management/utils"
+)
+
+// fakeConnectorRepo keeps the last state of each run.
+type fakeConnectorRepo struct {
+	interfacedataset.RepoDatasetConnectorInterface
+	mu   sync.Mutex
+	runs map[string]domaindataset.DatasetConnectorRun
+}
+
+func (r *fakeConnectorRepo) UpdateRun(m domaindataset.DatasetConnectorRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[m.Id] = m
+	return nil
+}
+
+func (r *fakeConnectorRepo) UpdateLastRun(id string, runAt time.Time, status string) error {
+	return nil
+}
+
+// fakeDatasets registers uploads like ServiceDataset.Create, rejecting a payload already uploaded.
+type fakeDatasets struct {
+	interfacedataset.ServiceDatasetInterface
+	uploaded map[string]domaindataset.DashboardDataset // payload -> dataset
+}
+
+func (d *fakeDatasets) Create(datasetType string, req dto.DatasetUploadRequest, upload io.Reader, fileName, contentType string, actorId string) (domaindataset.DashboardDataset, *file.TempFile, error) {
+	spooled, err := file.SpoolTemp(upload, 1<<20)
+	if err != nil {
+		return domaindataset.DashboardDataset{}, nil, err
+	}
+	content, _ := io.ReadAll(spooled)
+	if existing, ok := d.uploaded[string(content)]; ok {
+		spooled.Close()
+		return domaindataset.DashboardDataset{}, nil, &DuplicateUploadError{Existing: existing}
+	}
+	if err := spooled.Rewind(); err != nil {
+		return domaindataset.DashboardDataset{}, nil, err
+	}
+	ds := domaindataset.DashboardDataset{Id: utils.CreateUUID(), Type: datasetType, FileName: fileName, Status: utils.DatasetStatusUploaded}
+	d.uploaded[string(content)] = ds
+	return ds, spooled, nil
+}
+
+type fakeSources struct {
+	interfacedataset.DatasetSourceStoreInterface
+}
+
+func (fakeSources) Save(ds domaindataset.DashboardDataset, r io.Reader, size int64) error {
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+// fakeProcessor records the payloads it was given.
+type fakeProcessor struct {
+	interfacedataset.DatasetProcessorInterface
+	payloads []string
+}
+
+func (p *fakeProcessor) ProcessStream(ds *domaindataset.DashboardDataset, r io.Reader, actorId string) (domaindataset.DashboardDataset, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return *ds, err
+	}
+	p.payloads = append(p.payloads, string(content))
+	processed := *ds
+	processed.Status = utils.DatasetStatusDone
+	processed.ProcessedRows = strings.Count(string(content), "\n") - 1
+	return processed, nil
+}
+
+func TestConnectorRun(t *testing.T) {
+	const payload = "Honda ID,Sales\nH001,10\nH002,20\n"
+
+	type request struct {
+		path, query, auth string
+	}
+	var (
+		mu       sync.Mutex
+		requests []request
+		status   = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, request{r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Api-Key")})
+		code := status
+		mu.Unlock()
+		if code != http.StatusOK {
+			http.Error(w, "upstream unavailable", code)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	repo := &fakeConnectorRepo{runs: make(map[string]domaindataset.DatasetConnectorRun)}
+	datasets := &fakeDatasets{uploaded: make(map[string]domaindataset.DashboardDataset)}
+	processor := &fakeProcessor{}
+	s := NewDatasetConnectorService(repo, datasets, fakeSources{}, processor, 5*time.Second, time.Minute)
+
+	connector := domaindataset.DatasetConnector{
+		Id:              utils.CreateUUID(),
+		Name:            "sales",
+		URL:             server.URL + "/export/{period_year}/{period_month}?date={period_date}",
+		AuthHeader:      "X-Api-Key",
+		AuthValue:       "secret",
+		Format:          "csv",
+		DatasetType:     utils.DatasetSalesFLP,
+		PeriodFrequency: utils.PeriodDaily,
+		PeriodLagDays:   1,
+	}
+	run := func() domaindataset.DatasetConnectorRun {
+		r := domaindataset.DatasetConnectorRun{
+			Id:          utils.CreateUUID(),
+			ConnectorId: connector.Id,
+			Status:      utils.ConnectorRunRunning,
+			StartedAt:   time.Date(2024, time.March, 1, 6, 0, 0, 0, time.UTC),
+		}
+		s.execute(connector, r, utils.DatasetSystemActor)
+		return repo.runs[r.Id]
+	}
+
+	t.Run("good payload reaches the processor", func(t *testing.T) {
+		got := run()
+		if got.Status != utils.ConnectorRunSuccess {
+			t.Fatalf("status = %s (%s), want %s", got.Status, deref(got.Message), utils.ConnectorRunSuccess)
+		}
+		if len(processor.payloads) != 1 || processor.payloads[0] != payload {
+			t.Fatalf("processor got %q, want one payload %q", processor.payloads, payload)
+		}
+		if got.DatasetId == nil || got.HttpStatus == nil || *got.HttpStatus != http.StatusOK {
+			t.Errorf("run has dataset %v and HTTP status %v", got.DatasetId, got.HttpStatus)
+		}
+		if got.BytesRead != int64(len(payload)) {
+			t.Errorf("bytes read = %d, want %d", got.BytesRead, len(payload))
+		}
+	})
+
+	t.Run("auth header and URL placeholders", func(t *testing.T) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(requests) == 0 {
+			t.Fatal("no request reached the server")
+		}
+		// One day of lag before 2024-03-01.
+		want := request{path: "/export/2024/02", query: "date=2024-02-29", auth: "secret"}
+		if requests[0] != want {
+			t.Errorf("request = %+v, want %+v", requests[0], want)
+		}
+	})
+
+	t.Run("duplicate payload is skipped", func(t *testing.T) {
+		got := run()
+		if got.Status != utils.ConnectorRunSkipped {
+			t.Fatalf("status = %s (%s), want %s", got.Status, deref(got.Message), utils.ConnectorRunSkipped)
+		}
+		if got.DatasetId == nil {
+			t.Error("skipped run does not reference the existing dataset")
+		}
+		if len(processor.payloads) != 1 {
+			t.Errorf("processor got %d payloads, want the first one only", len(processor.payloads))
+		}
+	})
+
+	t.Run("non-2xx response fails the run", func(t *testing.T) {
+		mu.Lock()
+		status = http.StatusServiceUnavailable
+		mu.Unlock()
+
+		got := run()
+		if got.Status != utils.ConnectorRunFailed {
+			t.Fatalf("status = %s, want %s", got.Status, utils.ConnectorRunFailed)
+		}
+		if got.HttpStatus == nil || *got.HttpStatus != http.StatusServiceUnavailable {
+			t.Errorf("HTTP status = %v, want %d", got.HttpStatus, http.StatusServiceUnavailable)
+		}
+		if !strings.Contains(deref(got.Message), "503") || !bytes.Contains([]byte(deref(got.Message)), []byte("upstream unavailable")) {
+			t.Errorf("message = %q, want the status and the body of the response", deref(got.Message))
+		}
+		if len(processor.payloads) != 1 {
+			t.Errorf("processor got %d payloads after a failed request", len(processor.payloads))
+		}
+	})
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,193 @@
+package servicedataset
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	interfaceperson "teamleader-management/internal/interfaces/person"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/utils"
+)
+
+// diffTolerance is the difference below which two row values are considered equal.
+const diffTolerance = 1e-9
+
+// ServiceDatasetDiff compares two uploads of a dataset type, typically a report re-issued by Honda against
+// the one it would replace. Both original files are parsed again, so any two datasets whose file is still
+// stored can be compared, whatever their status.
+type ServiceDatasetDiff struct {
+	Repo        interfacedataset.RepoDatasetInterface
+	Sources     interfacedataset.DatasetSourceStoreInterface
+	Processor   interfacedataset.DatasetProcessorInterface
+	PersonRepo  interfaceperson.RepoPersonInterface
+	Evaluations interfacedataset.EvaluationEstimatorInterface
+}
+
+func NewDatasetDiffService(repo interfacedataset.RepoDatasetInterface, sources interfacedataset.DatasetSourceStoreInterface, processor interfacedataset.DatasetProcessorInterface, personRepo interfaceperson.RepoPersonInterface, evaluations interfacedataset.EvaluationEstimatorInterface) *ServiceDatasetDiff {
+	return &ServiceDatasetDiff{
+		Repo:        repo,
+		Sources:     sources,
+		Processor:   processor,
+		PersonRepo:  personRepo,
+		Evaluations: evaluations,
+	}
+}
+
+// Diff compares the rows of dataset id (old) with those of otherId (new) by Honda ID and estimates how
+// the evaluation of the affected TLs would move if the new dataset replaced the old one.
+func (s *ServiceDatasetDiff) Diff(id string, otherId string, actorId string) (dto.DatasetDiffResponse, error) {
+	if id == otherId {
+		return dto.DatasetDiffResponse{}, errors.New("a dataset cannot be compared with itself")
+	}
+	oldDs, err := s.Repo.GetByID(id)
+	if err != nil {
+		return dto.DatasetDiffResponse{}, err
+	}
+	newDs, err := s.Repo.GetByID(otherId)
+	if err != nil {
+		return dto.DatasetDiffResponse{}, err
+	}
+	if oldDs.Type != newDs.Type {
+		return dto.DatasetDiffResponse{}, fmt.Errorf("datasets have different types: %s and %s", oldDs.Type, newDs.Type)
+	}
+
+	oldValues, err := s.values(oldDs, actorId)
+	if err != nil {
+		return dto.DatasetDiffResponse{}, err
+	}
+	newValues, err := s.values(newDs, actorId)
+	if err != nil {
+		return dto.DatasetDiffResponse{}, err
+	}
+
+	res := dto.DatasetDiffResponse{
+		Type:           newDs.Type,
+		Field:          datasetValueFields[newDs.Type],
+		OldDatasetId:   oldDs.Id,
+		NewDatasetId:   newDs.Id,
+		Added:          []dto.DatasetDiffEntry{},
+		Removed:        []dto.DatasetDiffEntry{},
+		Changed:        []dto.DatasetDiffEntry{},
+		ScoreEstimates: []dto.DatasetScoreEstimate{},
+		Summary: dto.DatasetDiffSummary{
+			OldInvalidRows: oldValues.InvalidRows,
+			NewInvalidRows: newValues.InvalidRows,
+		},
+	}
+
+	for hondaId, newRow := range newValues.Values {
+		oldRow, ok := oldValues.Values[hondaId]
+		switch {
+		case !ok:
+			res.Added = append(res.Added, diffEntry(nil, &newRow))
+		case math.Abs(newRow.Value-oldRow.Value) > diffTolerance:
+			res.Changed = append(res.Changed, diffEntry(&oldRow, &newRow))
+		default:
+			res.Summary.Unchanged++
+		}
+	}
+	for hondaId, oldRow := range oldValues.Values {
+		if _, ok := newValues.Values[hondaId]; !ok {
+			res.Removed = append(res.Removed, diffEntry(&oldRow, nil))
+		}
+	}
+	res.Summary.Added = len(res.Added)
+	res.Summary.Removed = len(res.Removed)
+	res.Summary.Changed = len(res.Changed)
+
+	changes := make([]dto.DatasetDiffEntry, 0, len(res.Added)+len(res.Removed)+len(res.Changed))
+	for _, entries := range [][]dto.DatasetDiffEntry{res.Added, res.Removed, res.Changed} {
+		changes = append(changes, entries...)
+		sort.Slice(entries, func(i, j int) bool { return entries[i].HondaId < entries[j].HondaId })
+	}
+	if err := s.fillPersonNames(res.Added, res.Removed, res.Changed); err != nil {
+		return dto.DatasetDiffResponse{}, err
+	}
+
+	if oldDs.PeriodMonth != newDs.PeriodMonth || oldDs.PeriodYear != newDs.PeriodYear {
+		res.ScoreEstimateNote = "the datasets belong to different evaluation periods, scores are not estimated"
+		return res, nil
+	}
+	estimates, err := s.Evaluations.EstimateDatasetChange(oldDs, newDs, changes)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetDiff][%s][%s] EstimateDatasetChange; Error: %+v", oldDs.Id, newDs.Id, err))
+		res.ScoreEstimateNote = fmt.Sprintf("scores could not be estimated: %s", err)
+		return res, nil
+	}
+	res.ScoreEstimates = estimates
+	if oldDs.Status != utils.DatasetStatusDone {
+		res.ScoreEstimateNote = fmt.Sprintf("dataset %s is %s, not the imported one; the estimate assumes its rows are the ones counted today", oldDs.Id, oldDs.Status)
+	}
+	return res, nil
+}
+
+// values parses the original file of a dataset.
+func (s *ServiceDatasetDiff) values(ds domaindataset.DashboardDataset, actorId string) (dto.DatasetValues, error) {
+	original, err := s.Sources.Open(ds.Id)
+	if err != nil {
+		return dto.DatasetValues{}, fmt.Errorf("original file of dataset %s is no longer available", ds.Id)
+	}
+	defer original.Close()
+
+	values, err := s.Processor.Values(ds, original, actorId)
+	if err != nil {
+		return dto.DatasetValues{}, fmt.Errorf("dataset %s: %w", ds.Id, err)
+	}
+	return values, nil
+}
+
+// fillPersonNames sets the name of the person of the entries matched to one.
+func (s *ServiceDatasetDiff) fillPersonNames(groups ...[]dto.DatasetDiffEntry) error {
+	hondaIds := []string{}
+	for _, entries := range groups {
+		for _, e := range entries {
+			if e.PersonId != "" {
+				hondaIds = append(hondaIds, e.HondaId)
+			}
+		}
+	}
+	if len(hondaIds) == 0 {
+		return nil
+	}
+
+	persons, err := s.PersonRepo.GetByHondaIDs(hondaIds)
+	if err != nil {
+		return err
+	}
+	byId := make(map[string]domainperson.Person, len(persons))
+	for _, p := range persons {
+		byId[p.Id] = p
+	}
+	for _, entries := range groups {
+		for i := range entries {
+			entries[i].PersonName = byId[entries[i].PersonId].Name
+		}
+	}
+	return nil
+}
+
+func diffEntry(oldRow, newRow *dto.DatasetRowValue) dto.DatasetDiffEntry {
+	var entry dto.DatasetDiffEntry
+	if oldRow != nil {
+		oldValue := oldRow.Value
+		entry.HondaId, entry.PersonId, entry.OldValue = oldRow.HondaId, oldRow.PersonId, &oldValue
+		entry.Delta -= oldValue
+	}
+	if newRow != nil {
+		newValue := newRow.Value
+		entry.HondaId, entry.NewValue = newRow.HondaId, &newValue
+		if newRow.PersonId != "" {
+			entry.PersonId = newRow.PersonId
+		}
+		entry.Delta += newValue
+	}
+	return entry
+}
+
+var _ interfacedataset.ServiceDatasetDiffInterface = (*ServiceDatasetDiff)(nil)
@@ -0,0 +1,25 @@
+package servicedataset
+
+import (
+	"fmt"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/file"
+)
+
+// importSpooled keeps the original file of a dataset registered by ServiceDataset.Create and processes it
+// synchronously, for imports that run without a user waiting on the request (watched folder, connectors).
+func importSpooled(sources interfacedataset.DatasetSourceStoreInterface, processor interfacedataset.DatasetProcessorInterface, ds domaindataset.DashboardDataset, spooled *file.TempFile, actorId string) (domaindataset.DashboardDataset, error) {
+	if err := sources.Save(ds, spooled, spooled.Size); err != nil {
+		return ds, fmt.Errorf("dataset %s: failed to store dataset file: %w", ds.Id, err)
+	}
+	if err := spooled.Rewind(); err != nil {
+		return ds, err
+	}
+	processed, err := processor.ProcessStream(&ds, spooled, actorId)
+	if err != nil {
+		return processed, fmt.Errorf("dataset %s: %w", ds.Id, err)
+	}
+	return processed, nil
+}
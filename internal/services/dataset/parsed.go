@@ -0,0 +1,210 @@
+package servicedataset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	domainmetric "teamleader-management/internal/domain/metric"
+	"teamleader-management/internal/dto"
+	interfacemetric "teamleader-management/internal/interfaces/metric"
+	"teamleader-management/pkg/file"
+	"teamleader-management/utils"
+)
+
+// previewSampleSize is the number of normalized records returned by a dry run.
+const previewSampleSize = 20
+
+// parsedDataset holds the normalized records of a dataset file until they are flushed to sink.
+// Only the slice matching the dataset type is set. Without a sink (dry run) nothing is written and
+// only the first previewSampleSize records are kept.
+type parsedDataset struct {
+	sink      interfacemetric.RepoMetricInterface
+	totalRows int
+	recorded  int
+	errs      *rowErrorCollector
+
+	persons      map[string]string // honda id -> person id, filled chunk by chunk
+	hondaIds     map[string]bool   // honda id -> person found
+	hondaIdOrder []string
+
+	quizResults  []domainmetric.QuizResult
+	appleLogins  []domainmetric.AppleLogin
+	salesFLP     []domainmetric.SalesFLP
+	applePoints  []domainmetric.ApplePoint
+	myHeroPoints []domainmetric.MyHeroPoint
+	prospects    []domainmetric.Prospect
+
+	quarantined []domaindataset.DatasetQuarantineRow // valid rows whose Honda ID matched no person
+
+	values    map[string]dto.DatasetRowValue // honda id -> row value of every valid row, only set by Processor.Values
+	valueRows int
+}
+
+func newParsedDataset(datasetId string, sink interfacemetric.RepoMetricInterface) *parsedDataset {
+	return &parsedDataset{
+		sink:     sink,
+		errs:     newRowErrorCollector(datasetId, nil),
+		persons:  make(map[string]string),
+		hondaIds: make(map[string]bool),
+	}
+}
+
+func (d *parsedDataset) dryRun() bool {
+	return d.sink == nil
+}
+
+func (d *parsedDataset) trackHondaId(hondaId string, matched bool) {
+	if _, ok := d.hondaIds[hondaId]; !ok {
+		d.hondaIdOrder = append(d.hondaIdOrder, hondaId)
+	}
+	d.hondaIds[hondaId] = matched
+}
+
+// hondaIdsByMatch returns the distinct Honda IDs of the file split by whether a person was found.
+func (d *parsedDataset) hondaIdsByMatch() ([]string, []string) {
+	matched := make([]string, 0, len(d.hondaIdOrder))
+	unmatched := make([]string, 0)
+	for _, id := range d.hondaIdOrder {
+		if d.hondaIds[id] {
+			matched = append(matched, id)
+		} else {
+			unmatched = append(unmatched, id)
+		}
+	}
+	return matched, unmatched
+}
+
+// add appends a record built by a rowConverter to the slice of its type.
+func (d *parsedDataset) add(record interface{}) {
+	d.recorded++
+	if d.dryRun() && d.recorded > previewSampleSize {
+		return
+	}
+	switch r := record.(type) {
+	case domainmetric.QuizResult:
+		d.quizResults = append(d.quizResults, r)
+	case domainmetric.AppleLogin:
+		d.appleLogins = append(d.appleLogins, r)
+	case domainmetric.SalesFLP:
+		d.salesFLP = append(d.salesFLP, r)
+	case domainmetric.ApplePoint:
+		d.applePoints = append(d.applePoints, r)
+	case domainmetric.MyHeroPoint:
+		d.myHeroPoints = append(d.myHeroPoints, r)
+	case domainmetric.Prospect:
+		d.prospects = append(d.prospects, r)
+	}
+}
+
+// collectValue keeps the compared value of a valid row when the values of the file are collected.
+// A Honda ID listed twice keeps its last row, as the import does.
+func (d *parsedDataset) collectValue(row datasetRow, record interface{}) {
+	if d.values == nil {
+		return
+	}
+	d.valueRows++
+	d.values[row.hondaId] = dto.DatasetRowValue{HondaId: row.hondaId, PersonId: row.personId, Value: recordValue(record)}
+}
+
+// recordValue returns the value compared between two datasets of a type, see datasetValueFields.
+// An Apple login counts as 1 when both the morning and the evening login were done.
+func recordValue(record interface{}) float64 {
+	switch r := record.(type) {
+	case domainmetric.QuizResult:
+		if r.Score != nil {
+			return *r.Score
+		}
+	case domainmetric.AppleLogin:
+		if r.MorningDone && r.EveningDone {
+			return 1
+		}
+	case domainmetric.SalesFLP:
+		return float64(r.Amount)
+	case domainmetric.ApplePoint:
+		return float64(r.Points)
+	case domainmetric.MyHeroPoint:
+		return float64(r.Points)
+	case domainmetric.Prospect:
+		return float64(r.ProspectCount)
+	}
+	return 0
+}
+
+// datasetValueFields names the value returned by recordValue for each dataset type.
+var datasetValueFields = map[string]string{
+	utils.DatasetQuiz:          "score",
+	utils.DatasetLoginApple:    "morning_and_evening_done",
+	utils.DatasetSalesFLP:      "flp_amount",
+	utils.DatasetPointApple:    "points",
+	utils.DatasetPointMyHero:   "points",
+	utils.DatasetTotalProspect: "prospect_count",
+}
+
+// quarantine keeps a row aside with its raw cells (by sheet header) and its cells by template field,
+// so that it can be imported once its Honda ID is mapped to a person.
+func (d *parsedDataset) quarantine(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time) {
+	raw := make(domaindataset.QuarantineValues, len(row.cells))
+	for i, value := range row.cells {
+		column := fmt.Sprintf("Column %d", i+1)
+		if i < len(d.errs.header) && strings.TrimSpace(d.errs.header[i]) != "" {
+			column = strings.TrimSpace(d.errs.header[i])
+		}
+		raw[column] = value
+	}
+
+	fields := make(domaindataset.QuarantineValues, len(row.cols))
+	for field, idx := range row.cols {
+		fields[field] = file.Cell(row.cells, idx)
+	}
+
+	d.quarantined = append(d.quarantined, domaindataset.DatasetQuarantineRow{
+		Id:          utils.CreateUUID(),
+		DatasetId:   ds.Id,
+		DatasetType: ds.Type,
+		PeriodDate:  ds.PeriodDate,
+		RowNumber:   row.number,
+		HondaId:     row.hondaId,
+		DealerCode:  optionalCell(row.cells, row.cols.of(fieldDealerCode)),
+		RawPayload:  raw,
+		FieldValues: fields,
+		Status:      utils.QuarantineStatusPending,
+		CreatedAt:   now,
+		CreatedBy:   actorId,
+		UpdatedAt:   now,
+		UpdatedBy:   actorId,
+	})
+}
+
+// recordCount returns the number of records added, including the ones already flushed.
+func (d *parsedDataset) recordCount() int {
+	return d.recorded
+}
+
+// reset drops the records that have been flushed, keeping the slices for the next chunk.
+func (d *parsedDataset) reset() {
+	d.quizResults = d.quizResults[:0]
+	d.appleLogins = d.appleLogins[:0]
+	d.salesFLP = d.salesFLP[:0]
+	d.applePoints = d.applePoints[:0]
+	d.myHeroPoints = d.myHeroPoints[:0]
+	d.prospects = d.prospects[:0]
+}
+
+// sample returns up to limit normalized records.
+func (d *parsedDataset) sample(limit int) []interface{} {
+	records := make([]interface{}, 0, limit)
+	appendAll := func(n int, get func(i int) interface{}) {
+		for i := 0; i < n && len(records) < limit; i++ {
+			records = append(records, get(i))
+		}
+	}
+	appendAll(len(d.quizResults), func(i int) interface{} { return d.quizResults[i] })
+	appendAll(len(d.appleLogins), func(i int) interface{} { return d.appleLogins[i] })
+	appendAll(len(d.salesFLP), func(i int) interface{} { return d.salesFLP[i] })
+	appendAll(len(d.applePoints), func(i int) interface{} { return d.applePoints[i] })
+	appendAll(len(d.myHeroPoints), func(i int) interface{} { return d.myHeroPoints[i] })
+	appendAll(len(d.prospects), func(i int) interface{} { return d.prospects[i] })
+	return records
+}
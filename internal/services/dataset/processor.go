@@ -3,6 +3,8 @@ package servicedataset
 import (
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"teamleader-management/pkg/file"
@@ -10,458 +12,746 @@ import (
 
 	domaindataset "teamleader-management/internal/domain/dataset"
 	domainmetric "teamleader-management/internal/domain/metric"
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
 	interfacedataset "teamleader-management/internal/interfaces/dataset"
 	interfacemetric "teamleader-management/internal/interfaces/metric"
 	interfaceperson "teamleader-management/internal/interfaces/person"
 	"teamleader-management/utils"
+
+	"gorm.io/gorm"
 )
 
+// defaultInsertChunkSize is the number of metric rows written per INSERT when none is configured.
+const defaultInsertChunkSize = 500
+
 type Processor struct {
-	DatasetRepo interfacedataset.RepoDatasetInterface
-	MetricRepo  interfacemetric.RepoMetricInterface
-	PersonRepo  interfaceperson.RepoPersonInterface
+	DatasetRepo    interfacedataset.RepoDatasetInterface
+	MetricRepo     interfacemetric.RepoMetricInterface
+	PersonRepo     interfaceperson.RepoPersonInterface
+	TemplateRepo   interfacedataset.RepoDatasetTemplateInterface
+	QuarantineRepo interfacedataset.RepoDatasetQuarantineInterface
+	DB             *gorm.DB
+	ChunkSize      int // metric rows per INSERT
 }
 
-func NewProcessor(datasetRepo interfacedataset.RepoDatasetInterface, metricRepo interfacemetric.RepoMetricInterface, personRepo interfaceperson.RepoPersonInterface) *Processor {
+func NewProcessor(datasetRepo interfacedataset.RepoDatasetInterface, metricRepo interfacemetric.RepoMetricInterface, personRepo interfaceperson.RepoPersonInterface, templateRepo interfacedataset.RepoDatasetTemplateInterface, quarantineRepo interfacedataset.RepoDatasetQuarantineInterface, db *gorm.DB, chunkSize int) *Processor {
+	if chunkSize < 1 {
+		chunkSize = defaultInsertChunkSize
+	}
 	return &Processor{
-		DatasetRepo: datasetRepo,
-		MetricRepo:  metricRepo,
-		PersonRepo:  personRepo,
+		DatasetRepo:    datasetRepo,
+		MetricRepo:     metricRepo,
+		PersonRepo:     personRepo,
+		TemplateRepo:   templateRepo,
+		QuarantineRepo: quarantineRepo,
+		DB:             db,
+		ChunkSize:      chunkSize,
+	}
+}
+
+// RowValidationError is returned when one or more rows fail validation.
+// Nothing is imported in that case; the collected errors are stored on the dataset.
+type RowValidationError struct {
+	Errors []domaindataset.DatasetRowError
+}
+
+func (e *RowValidationError) Error() string {
+	return fmt.Sprintf("%d row error(s) found, download the validation report for details", len(e.Errors))
+}
+
+// rowErrorCollector gathers every row error of a sheet instead of stopping at the first one.
+type rowErrorCollector struct {
+	datasetId string
+	header    []string
+	errors    []domaindataset.DatasetRowError
+}
+
+func newRowErrorCollector(datasetId string, header []string) *rowErrorCollector {
+	return &rowErrorCollector{datasetId: datasetId, header: header}
+}
+
+// add records an error for the given 1-based sheet row. colIndex < 0 means the error is not tied to a column.
+func (c *rowErrorCollector) add(rowNumber, colIndex int, value, reason string) {
+	column := ""
+	if colIndex >= 0 {
+		column = fmt.Sprintf("Column %d", colIndex+1)
+		if colIndex < len(c.header) && strings.TrimSpace(c.header[colIndex]) != "" {
+			column = strings.TrimSpace(c.header[colIndex])
+		}
 	}
+
+	c.errors = append(c.errors, domaindataset.DatasetRowError{
+		Id:        utils.CreateUUID(),
+		DatasetId: c.datasetId,
+		RowNumber: rowNumber,
+		Column:    column,
+		Value:     value,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
 }
 
-func parseDoneStatus(val string, rowIndex int) (bool, error) {
+func (c *rowErrorCollector) err() error {
+	if len(c.errors) == 0 {
+		return nil
+	}
+	return &RowValidationError{Errors: c.errors}
+}
+
+func parseDoneStatus(val string) (bool, error) {
 	switch val {
 	case "DONE", "YES", "Y", "1", "TRUE":
 		return true, nil
 	case "NOT DONE", "NO", "N", "0", "FALSE":
 		return false, nil
 	default:
-		return false, fmt.Errorf("row %d invalid status value: %s", rowIndex+1, val)
+		return false, fmt.Errorf("invalid status value: %s", val)
 	}
 }
 
-// ProcessStream executes import flow from in-memory file data.
-func (p *Processor) ProcessStream(ds *domaindataset.DashboardDataset, data []byte, actorId string) (domaindataset.DashboardDataset, error) {
-	if ds.Status != utils.DatasetStatusProcessing && ds.Status != utils.DatasetStatusUploaded && ds.Status != utils.DatasetStatusFailed {
+// ProcessStream imports the file read from r. Rows are read one at a time and written in chunks,
+// all within one transaction together with the DONE status, so a failure leaves nothing behind.
+func (p *Processor) ProcessStream(ds *domaindataset.DashboardDataset, r io.Reader, actorId string) (domaindataset.DashboardDataset, error) {
+	if ds.Status != utils.DatasetStatusProcessing && !utils.CanTransitionDatasetStatus(ds.Status, utils.DatasetStatusProcessing) {
 		return *ds, errors.New("dataset is not in a processable state")
 	}
 
-	if err := p.markStatus(ds, utils.DatasetStatusProcessing, actorId); err != nil {
+	startedAt := time.Now()
+	ds.StartedAt = &startedAt
+	ds.FinishedAt = nil
+	ds.ErrorMessage = nil
+	ds.TotalRows = nil
+	ds.ProcessedRows = 0
+	ds.QuarantinedRows = 0
+	if err := p.markStatus(ds, utils.DatasetStatusProcessing, actorId, "import started"); err != nil {
 		return *ds, err
 	}
-
-	var processErr error
-	switch strings.ToUpper(ds.Type) {
-	case utils.DatasetQuiz:
-		processErr = p.processQuiz(ds, data, actorId)
-	case utils.DatasetLoginApple:
-		processErr = p.processAppleLogin(ds, data, actorId)
-	case utils.DatasetSalesFLP:
-		processErr = p.processSalesFLP(ds, data, actorId)
-	case utils.DatasetPointApple:
-		processErr = p.processApplePoints(ds, data, actorId)
-	case utils.DatasetPointMyHero:
-		processErr = p.processMyHeroPoints(ds, data, actorId)
-	case utils.DatasetTotalProspect:
-		processErr = p.processProspects(ds, data, actorId)
-	default:
-		processErr = fmt.Errorf("dataset type %s not supported yet", ds.Type)
+	if err := p.DatasetRepo.DeleteErrors(ds.Id); err != nil {
+		return *ds, err
 	}
 
-	if processErr != nil {
-		_ = p.markStatus(ds, utils.DatasetStatusFailed, actorId)
-		return *ds, processErr
-	}
+	processErr := p.DB.Transaction(func(tx *gorm.DB) error {
+		parsed, err := p.parse(ds, r, actorId, p.MetricRepo.WithTx(tx))
+		if err != nil {
+			return err
+		}
+		if err := parsed.errs.err(); err != nil {
+			return err
+		}
 
-	if err := p.markStatus(ds, utils.DatasetStatusDone, actorId); err != nil {
-		return *ds, err
+		finishedAt := time.Now()
+		ds.FinishedAt = &finishedAt
+		ds.ProcessedRows = *ds.TotalRows
+		ds.QuarantinedRows = len(parsed.quarantined)
+		return p.complete(tx, ds, parsed, actorId)
+	})
+	if processErr == nil {
+		return *ds, nil
 	}
 
-	return *ds, nil
-}
-
-func (p *Processor) markStatus(ds *domaindataset.DashboardDataset, status, actorId string) error {
-	now := time.Now()
-	ds.Status = status
-	ds.UpdatedAt = now
-	ds.UpdatedBy = actorId
-	return p.DatasetRepo.Update(*ds)
+	// The transaction was rolled back, including the move to DONE.
+	ds.Status = utils.DatasetStatusProcessing
+	finishedAt := time.Now()
+	ds.FinishedAt = &finishedAt
+	var validationErr *RowValidationError
+	if errors.As(processErr, &validationErr) {
+		if err := p.DatasetRepo.StoreErrors(validationErr.Errors); err != nil {
+			processErr = fmt.Errorf("%w; failed to store row errors: %v", processErr, err)
+		}
+	}
+	errMsg := processErr.Error()
+	ds.ErrorMessage = &errMsg
+	_ = p.markStatus(ds, utils.DatasetStatusFailed, actorId, errMsg)
+	return *ds, processErr
 }
 
-func (p *Processor) processQuiz(ds *domaindataset.DashboardDataset, data []byte, actorId string) error {
-	rows, err := file.ReadExcelRows(data, 0, 2)
+// complete marks ds as DONE within tx, after the metric rows have been written by parse. In the same
+// transaction it supersedes earlier imports of the same type, period and frequency: their remaining
+// metric rows are soft-deleted and they become SUPERSEDED. Pending quarantine rows of ds and of the
+// superseded imports are replaced by the new ones.
+func (p *Processor) complete(tx *gorm.DB, ds *domaindataset.DashboardDataset, parsed *parsedDataset, actorId string) error {
+	previous, err := p.DatasetRepo.WithTx(tx).GetSupersedable(*ds)
 	if err != nil {
 		return err
 	}
-
-	results := make([]domainmetric.QuizResult, 0, len(rows)-1)
-	now := time.Now()
-	for i, row := range rows {
-		if i == 0 {
-			continue // header
-		}
-		if len(row) < 8 { // No, Honda ID, Nama, Jabatan, Role, Kode Dealer, Nilai, Lulus/Tidak, (Status optional)
-			return fmt.Errorf("row %d has insufficient columns", i+1)
-		}
-		hondaId := strings.TrimSpace(row[1])
-		if hondaId == "" {
-			return fmt.Errorf("row %d missing Honda ID", i+1)
-		}
-		person, err := p.PersonRepo.GetByHondaID(hondaId)
+	previousIds := make([]string, 0, len(previous))
+	history := make([]domaindataset.DatasetStatusHistory, 0, len(previous))
+	for _, prev := range previous {
+		previousIds = append(previousIds, prev.Id)
+		entries, err := changeStatus(&prev, utils.DatasetStatusSuperseded, actorId, fmt.Sprintf("superseded by dataset %s", ds.Id))
 		if err != nil {
-			return fmt.Errorf("row %d person not found for honda_id %s", i+1, hondaId)
+			return err
 		}
+		history = append(history, entries...)
+	}
 
-		var dealerCode *string
-		if len(row) >= 6 && strings.TrimSpace(row[5]) != "" {
-			val := strings.TrimSpace(row[5])
-			dealerCode = &val
-		}
+	done, err := changeStatus(ds, utils.DatasetStatusDone, actorId, fmt.Sprintf("%d row(s) imported", parsed.recordCount()))
+	if err != nil {
+		return err
+	}
 
-		var score *float64
-		if len(row) >= 7 && strings.TrimSpace(row[6]) != "" {
-			val, convErr := strconv.ParseFloat(strings.TrimSpace(row[6]), 64)
-			if convErr != nil {
-				return fmt.Errorf("row %d invalid score: %v", i+1, convErr)
-			}
-			score = &val
-		}
+	if _, err := p.MetricRepo.WithTx(tx).SoftDeleteByDatasets(previousIds, actorId); err != nil {
+		return err
+	}
+	if err := p.DatasetRepo.WithTx(tx).MarkSuperseded(previousIds, ds.Id, actorId); err != nil {
+		return err
+	}
+	if err := p.DatasetRepo.WithTx(tx).StoreStatusHistory(history); err != nil {
+		return err
+	}
+	if err := p.QuarantineRepo.WithTx(tx).DeletePendingByDatasets(append(previousIds, ds.Id), actorId); err != nil {
+		return err
+	}
+	if err := p.QuarantineRepo.WithTx(tx).StoreMultiple(parsed.quarantined); err != nil {
+		return err
+	}
+	return p.DatasetRepo.WithTx(tx).UpdateWithHistory(*ds, done)
+}
 
-		var passStatus *string
-		if len(row) >= 8 && strings.TrimSpace(row[7]) != "" {
-			val := strings.TrimSpace(row[7])
-			passStatus = &val
-		}
+// Preview parses and validates the file read from r with the same rules as ProcessStream without persisting anything.
+func (p *Processor) Preview(ds domaindataset.DashboardDataset, r io.Reader, actorId string) (dto.DatasetPreviewResponse, error) {
+	parsed, err := p.parse(&ds, r, actorId, nil)
+	if err != nil {
+		return dto.DatasetPreviewResponse{}, err
+	}
 
-		result := domainmetric.QuizResult{
-			Id:         utils.CreateUUID(),
-			DatasetId:  ds.Id,
-			PeriodDate: ds.PeriodDate,
-			PersonId:   person.Id,
-			HondaId:    hondaId,
-			DealerCode: dealerCode,
-			Score:      score,
-			PassStatus: passStatus,
-			CreatedAt:  now,
-			CreatedBy:  actorId,
-			UpdatedAt:  now,
-			UpdatedBy:  actorId,
-		}
-		results = append(results, result)
+	rowErrors := make([]dto.DatasetRowErrorItem, 0, len(parsed.errs.errors))
+	for _, e := range parsed.errs.errors {
+		rowErrors = append(rowErrors, dto.DatasetRowErrorItem{
+			RowNumber: e.RowNumber,
+			Column:    e.Column,
+			Value:     e.Value,
+			Reason:    e.Reason,
+		})
 	}
 
-	if err := p.MetricRepo.SaveQuizResults(results); err != nil {
-		return err
+	supersedes := make([]string, 0)
+	if previous, err := p.DatasetRepo.GetSupersedable(ds); err == nil {
+		for _, prev := range previous {
+			supersedes = append(supersedes, prev.Id)
+		}
 	}
 
-	return nil
+	matched, unmatched := parsed.hondaIdsByMatch()
+	validRows := parsed.recordCount()
+	quarantinedRows := len(parsed.quarantined)
+	return dto.DatasetPreviewResponse{
+		Type:              ds.Type,
+		PeriodDate:        ds.PeriodDate.Format("2006-01-02"),
+		PeriodFrequency:   ds.PeriodFrequency,
+		FileName:          ds.FileName,
+		TotalRows:         parsed.totalRows,
+		ValidRows:         validRows,
+		QuarantinedRows:   quarantinedRows,
+		InvalidRows:       parsed.totalRows - validRows - quarantinedRows,
+		MatchedHondaIds:   matched,
+		UnmatchedHondaIds: unmatched,
+		Sample:            parsed.sample(previewSampleSize),
+		Errors:            rowErrors,
+		Supersedes:        supersedes,
+	}, nil
 }
 
-func (p *Processor) processAppleLogin(ds *domaindataset.DashboardDataset, data []byte, actorId string) error {
-	rows, err := file.ReadExcelRows(data, 0, 2)
-	if err != nil {
-		return err
+// Values parses the file read from r like Preview and returns the compared value of every valid row by
+// Honda ID, including the rows whose Honda ID matches no person. Nothing is persisted.
+func (p *Processor) Values(ds domaindataset.DashboardDataset, r io.Reader, actorId string) (dto.DatasetValues, error) {
+	convert, ok := rowConverters[strings.ToUpper(ds.Type)]
+	if !ok {
+		return dto.DatasetValues{}, fmt.Errorf("dataset type %s not supported yet", ds.Type)
 	}
 
-	logins := make([]domainmetric.AppleLogin, 0, len(rows)-1)
-	now := time.Now()
-	for i, row := range rows {
-		if i == 0 {
-			continue
-		}
-		if len(row) < 7 { // No, Honda ID, Nama Lengkap, Jabatan, Kode Dealer, Frequent PAGI, Frequent SORE
-			return fmt.Errorf("row %d has insufficient columns", i+1)
-		}
-		hondaId := strings.TrimSpace(row[1])
-		if hondaId == "" {
-			return fmt.Errorf("row %d missing Honda ID", i+1)
-		}
-		person, err := p.PersonRepo.GetByHondaID(hondaId)
-		if err != nil {
-			return fmt.Errorf("row %d person not found for honda_id %s", i+1, hondaId)
-		}
+	out := newParsedDataset(ds.Id, nil)
+	out.values = make(map[string]dto.DatasetRowValue)
+	if err := p.parseRows(&ds, r, actorId, out, convert); err != nil {
+		return dto.DatasetValues{}, err
+	}
+	return dto.DatasetValues{
+		Values:      out.values,
+		TotalRows:   out.totalRows,
+		InvalidRows: out.totalRows - out.valueRows,
+	}, nil
+}
 
-		var dealerCode *string
-		if len(row) >= 5 && strings.TrimSpace(row[4]) != "" {
-			val := strings.TrimSpace(row[4])
-			dealerCode = &val
-		}
+// parse reads the sheet and converts every row into metric records written to sink, collecting row errors
+// along the way. A nil sink is a dry run. The returned error is only set when the file itself cannot be processed.
+func (p *Processor) parse(ds *domaindataset.DashboardDataset, r io.Reader, actorId string, sink interfacemetric.RepoMetricInterface) (*parsedDataset, error) {
+	out := newParsedDataset(ds.Id, sink)
+
+	convert, ok := rowConverters[strings.ToUpper(ds.Type)]
+	if !ok {
+		return nil, fmt.Errorf("dataset type %s not supported yet", ds.Type)
+	}
+	if err := p.parseRows(ds, r, actorId, out, convert); err != nil {
+		return nil, err
+	}
 
-		morningVal := strings.ToUpper(strings.TrimSpace(row[5]))
-		eveningVal := strings.ToUpper(strings.TrimSpace(row[6]))
+	return out, nil
+}
 
-		morningDone, err := parseDoneStatus(morningVal, i)
-		if err != nil {
+// save writes the parsed records to their metric table in chunks of ChunkSize rows.
+func (p *Processor) save(repo interfacemetric.RepoMetricInterface, parsed *parsedDataset) error {
+	switch {
+	case parsed.quizResults != nil:
+		return saveInChunks(parsed.quizResults, p.ChunkSize, repo.SaveQuizResults)
+	case parsed.appleLogins != nil:
+		return saveInChunks(parsed.appleLogins, p.ChunkSize, repo.SaveAppleLogins)
+	case parsed.salesFLP != nil:
+		return saveInChunks(parsed.salesFLP, p.ChunkSize, repo.SaveSalesFLP)
+	case parsed.applePoints != nil:
+		return saveInChunks(parsed.applePoints, p.ChunkSize, repo.SaveApplePoints)
+	case parsed.myHeroPoints != nil:
+		return saveInChunks(parsed.myHeroPoints, p.ChunkSize, repo.SaveMyHeroPoints)
+	case parsed.prospects != nil:
+		return saveInChunks(parsed.prospects, p.ChunkSize, repo.SaveProspects)
+	}
+	return nil
+}
+
+func saveInChunks[T any](entries []T, size int, save func([]T) error) error {
+	if size < 1 {
+		size = defaultInsertChunkSize
+	}
+	for start := 0; start < len(entries); start += size {
+		end := min(start+size, len(entries))
+		if err := save(entries[start:end]); err != nil {
 			return err
 		}
-		eveningDone, err := parseDoneStatus(eveningVal, i)
-		if err != nil {
+	}
+	return nil
+}
+
+// Reingest imports quarantined rows into their metric table for the person they were mapped to.
+// The records are written with tx so that the caller can resolve the rows in the same transaction.
+func (p *Processor) Reingest(tx *gorm.DB, rows []domaindataset.DatasetQuarantineRow, person domainperson.Person, actorId string) error {
+	now := time.Now()
+	for _, q := range rows {
+		convert, ok := rowConverters[strings.ToUpper(q.DatasetType)]
+		if !ok {
+			return fmt.Errorf("dataset type %s not supported yet", q.DatasetType)
+		}
+
+		fields := make([]string, 0, len(q.FieldValues))
+		for field := range q.FieldValues {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		row := datasetRow{
+			cells:    make([]string, len(fields)),
+			number:   q.RowNumber,
+			cols:     make(columnIndex, len(fields)),
+			hondaId:  person.HondaId,
+			personId: person.Id,
+		}
+		for i, field := range fields {
+			row.cells[i] = q.FieldValues[field]
+			row.cols[field] = i
+		}
+
+		ds := &domaindataset.DashboardDataset{Id: q.DatasetId, Type: q.DatasetType, PeriodDate: q.PeriodDate}
+		out := newParsedDataset(q.DatasetId, p.MetricRepo.WithTx(tx))
+		out.errs.header = fields
+		record, ok := convert(ds, row, actorId, now, out)
+		if !ok {
+			return fmt.Errorf("row %d of dataset %s can no longer be imported: %s", q.RowNumber, q.DatasetId, out.errs.errors[0].Reason)
+		}
+		out.add(record)
+		if err := p.flush(out); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		login := domainmetric.AppleLogin{
-			Id:          utils.CreateUUID(),
-			DatasetId:   ds.Id,
-			PeriodDate:  ds.PeriodDate,
-			PersonId:    person.Id,
-			HondaId:     hondaId,
-			DealerCode:  dealerCode,
-			LoginDate:   ds.PeriodDate,
-			MorningDone: morningDone,
-			EveningDone: eveningDone,
-			CreatedAt:   now,
-			CreatedBy:   actorId,
-			UpdatedAt:   now,
-			UpdatedBy:   actorId,
+// flush writes the records collected since the last flush to the sink of out.
+// Nothing is written once a row error was found, since the import will be rolled back anyway.
+func (p *Processor) flush(out *parsedDataset) error {
+	if out.dryRun() {
+		return nil
+	}
+	if len(out.errs.errors) == 0 {
+		if err := p.save(out.sink, out); err != nil {
+			return err
 		}
-		logins = append(logins, login)
 	}
+	out.reset()
+	return nil
+}
 
-	if err := p.MetricRepo.SaveAppleLogins(logins); err != nil {
+func (p *Processor) markStatus(ds *domaindataset.DashboardDataset, status, actorId, reason string) error {
+	history, err := changeStatus(ds, status, actorId, reason)
+	if err != nil {
 		return err
 	}
-
+	if err := p.DatasetRepo.UpdateWithHistory(*ds, history); err != nil {
+		return err
+	}
+	ds.History = append(ds.History, history...)
 	return nil
 }
 
-func (p *Processor) processSalesFLP(ds *domaindataset.DashboardDataset, data []byte, actorId string) error {
-	rows, err := file.ReadExcelRows(data, 0, 2)
-	if err != nil {
-		return err
+// reportProgress persists the number of data rows read so far. The total stays null until the end of the file,
+// the rows of a streamed file are not counted in advance.
+func (p *Processor) reportProgress(ds *domaindataset.DashboardDataset, out *parsedDataset, processedRows int) {
+	out.totalRows = processedRows
+	if out.dryRun() {
+		return
 	}
+	ds.ProcessedRows = processedRows
+	_ = p.DatasetRepo.UpdateProgress(ds.Id, ds.ProcessedRows, ds.TotalRows)
+}
 
-	entries := make([]domainmetric.SalesFLP, 0, len(rows)-1)
-	now := time.Now()
-	for i, row := range rows {
-		if i == 0 {
+// loadPersons looks up, with a single query, the persons of the Honda IDs in rows that were not looked up before.
+func (p *Processor) loadPersons(rows []datasetRow, out *parsedDataset) error {
+	seen := make(map[string]bool)
+	hondaIds := make([]string, 0, len(rows))
+	for _, row := range rows {
+		hondaId := file.Cell(row.cells, row.cols.of(fieldHondaId))
+		if _, known := out.hondaIds[hondaId]; hondaId == "" || known || seen[hondaId] {
 			continue
 		}
-		if len(row) < 5 { // No, Honda ID, Kode Dealer, Nama Sales People, Sales
-			return fmt.Errorf("row %d has insufficient columns", i+1)
-		}
+		seen[hondaId] = true
+		hondaIds = append(hondaIds, hondaId)
+	}
+	if len(hondaIds) == 0 {
+		return nil
+	}
 
-		hondaId := strings.TrimSpace(row[1])
-		if hondaId == "" {
-			return fmt.Errorf("row %d missing Honda ID", i+1)
-		}
-		person, err := p.PersonRepo.GetByHondaID(hondaId)
-		if err != nil {
-			return fmt.Errorf("row %d person not found for honda_id %s", i+1, hondaId)
-		}
+	persons, err := p.PersonRepo.GetByHondaIDs(hondaIds)
+	if err != nil {
+		return fmt.Errorf("failed to look up persons: %w", err)
+	}
+	for _, person := range persons {
+		out.persons[person.HondaId] = person.Id
+	}
+	return nil
+}
 
-		var dealerCode *string
-		if val := strings.TrimSpace(row[2]); val != "" {
-			dealerCode = &val
-		}
+// resolvePerson validates the Honda ID cell and finds the matching person among those loaded by loadPersons.
+// The returned bool is false when the cell is empty (an error is recorded) or no person matches.
+func (p *Processor) resolvePerson(row []string, rowNumber, colIndex int, out *parsedDataset) (string, string, bool) {
+	hondaId := file.Cell(row, colIndex)
+	if hondaId == "" {
+		out.errs.add(rowNumber, colIndex, "", "Honda ID is required")
+		return "", "", false
+	}
+	personId, ok := out.persons[hondaId]
+	out.trackHondaId(hondaId, ok)
+	if !ok {
+		return hondaId, "", false
+	}
+	return hondaId, personId, true
+}
 
-		amountStr := strings.TrimSpace(row[4])
-		if amountStr == "" {
-			return fmt.Errorf("row %d sales amount is required", i+1)
-		}
-		amount, err := utils.ParseInt(amountStr)
-		if err != nil {
-			return fmt.Errorf("row %d invalid %s value: Sales", i+1, err)
-		}
+// openSheet opens the sheet configured in the dataset template, reads up to its header row and locates
+// the columns by header name. It returns the iterator positioned on the first data row and the 1-based
+// sheet row number of the header. The caller must close the iterator.
+func (p *Processor) openSheet(ds *domaindataset.DashboardDataset, r io.Reader, out *parsedDataset) (file.RowIterator, columnIndex, int, error) {
+	tpl, err := resolveTemplate(p.TemplateRepo, ds.Type)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	source, err := file.NewRowSource(datasetFileFormat(ds), r)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	it, err := source.Iterate(tpl.SheetName)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 
-		entry := domainmetric.SalesFLP{
-			Id:         utils.CreateUUID(),
-			DatasetId:  ds.Id,
-			PeriodDate: ds.PeriodDate,
-			PersonId:   person.Id,
-			HondaId:    hondaId,
-			DealerCode: dealerCode,
-			Amount:     amount,
-			CreatedAt:  now,
-			CreatedBy:  actorId,
-			UpdatedAt:  now,
-			UpdatedBy:  actorId,
+	for i := 0; i < tpl.HeaderRow; i++ {
+		if !it.Next() {
+			err := it.Err()
+			if err == nil {
+				err = errors.New("file has no data rows")
+			}
+			it.Close()
+			return nil, nil, 0, err
 		}
-		entries = append(entries, entry)
 	}
 
-	if err := p.MetricRepo.SaveSalesFLP(entries); err != nil {
-		return err
+	header := append([]string(nil), it.Row()...)
+	cols, err := locateColumns(tpl, header)
+	if err != nil {
+		it.Close()
+		return nil, nil, 0, err
 	}
 
+	out.errs.header = header
+	return it, cols, tpl.HeaderRow, nil
+}
+
+// datasetFileFormat returns the stored file format, deriving it from the file name for older datasets.
+func datasetFileFormat(ds *domaindataset.DashboardDataset) string {
+	if ds.FileFormat != "" {
+		return ds.FileFormat
+	}
+	if format, err := file.DetectFormat(ds.FileName, ""); err == nil {
+		return format
+	}
+	return file.FormatXLSX
+}
+
+func optionalCell(row []string, idx int) *string {
+	if val := file.Cell(row, idx); val != "" {
+		return &val
+	}
 	return nil
 }
 
-func (p *Processor) processMyHeroPoints(ds *domaindataset.DashboardDataset, data []byte, actorId string) error {
-	rows, err := file.ReadExcelRows(data, 0, 2)
+// parseIntCell validates a required whole-number cell; label is used in error messages.
+func parseIntCell(row []string, rowNumber, idx int, label string, out *parsedDataset) (int, bool) {
+	val := file.Cell(row, idx)
+	if val == "" {
+		out.errs.add(rowNumber, idx, "", label+" is required")
+		return 0, false
+	}
+	num, err := utils.ParseInt(val)
 	if err != nil {
-		return err
+		out.errs.add(rowNumber, idx, val, fmt.Sprintf("invalid %s, expected a whole number", label))
+		return 0, false
 	}
+	return num, true
+}
 
-	entries := make([]domainmetric.MyHeroPoint, 0, len(rows)-1)
-	now := time.Now()
-
-	for i, row := range rows {
-		if i == 0 {
-			continue
-		}
-		if len(row) < 5 { // No, Honda Id, Kode Dealer, Nama, Jumlah Poin
-			return fmt.Errorf("row %d has insufficient columns", i+1)
-		}
+// datasetRow is one data row of a dataset file with its columns located and its person resolved.
+// personId is empty when the Honda ID did not match any person.
+type datasetRow struct {
+	cells    []string
+	number   int // 1-based row number in the sheet
+	cols     columnIndex
+	hondaId  string
+	personId string
+}
 
-		hondaId := strings.TrimSpace(row[1])
-		if hondaId == "" {
-			return fmt.Errorf("row %d missing Honda ID", i+1)
-		}
+// rowConverter validates the type-specific cells of a row and builds its metric record.
+// Errors are collected in out; the returned bool is false when the row is invalid.
+type rowConverter func(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool)
+
+var rowConverters = map[string]rowConverter{
+	utils.DatasetQuiz:          quizRecord,
+	utils.DatasetLoginApple:    appleLoginRecord,
+	utils.DatasetSalesFLP:      salesFLPRecord,
+	utils.DatasetPointApple:    applePointRecord,
+	utils.DatasetPointMyHero:   myHeroPointRecord,
+	utils.DatasetTotalProspect: prospectRecord,
+}
 
-		person, err := p.PersonRepo.GetByHondaID(hondaId)
-		if err != nil {
-			return fmt.Errorf("row %d person not found for honda_id %s", i+1, hondaId)
-		}
+// parseRows streams the data rows of the sheet and converts them with convert, ChunkSize rows at a time.
+// Valid rows whose Honda ID is unknown are quarantined instead of being reported as errors.
+func (p *Processor) parseRows(ds *domaindataset.DashboardDataset, r io.Reader, actorId string, out *parsedDataset, convert rowConverter) error {
+	it, cols, headerRow, err := p.openSheet(ds, r, out)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
 
-		var dealerCode *string
-		if val := strings.TrimSpace(row[2]); val != "" {
-			dealerCode = &val
+	now := time.Now()
+	batch := make([]datasetRow, 0, p.ChunkSize)
+	read := 0
+	for it.Next() {
+		read++
+		if file.IsBlankRow(it.Row()) {
+			continue
 		}
-
-		pointsStr := strings.TrimSpace(row[4])
-		if pointsStr == "" {
-			return fmt.Errorf("row %d jumlah poin is required", i+1)
+		number := headerRow + read
+		if n, ok := it.(file.RowNumberer); ok {
+			// Blank CSV lines are not rows, so the line of the record is reported instead.
+			number = n.RowNumber()
 		}
-		points, err := utils.ParseInt(pointsStr)
-		if err != nil {
-			return fmt.Errorf("row %d invalid %s value: %v", i+1, "Jumlah Poin", err)
+		cells := append([]string(nil), it.Row()...)
+		batch = append(batch, datasetRow{cells: cells, number: number, cols: cols})
+		if len(batch) < p.ChunkSize {
+			continue
 		}
-
-		entry := domainmetric.MyHeroPoint{
-			Id:         utils.CreateUUID(),
-			DatasetId:  ds.Id,
-			PeriodDate: ds.PeriodDate,
-			PersonId:   person.Id,
-			HondaId:    hondaId,
-			DealerCode: dealerCode,
-			Points:     points,
-			CreatedAt:  now,
-			CreatedBy:  actorId,
-			UpdatedAt:  now,
-			UpdatedBy:  actorId,
+		if err := p.convertRows(ds, batch, actorId, now, out, convert); err != nil {
+			return err
 		}
-		entries = append(entries, entry)
+		batch = batch[:0]
+		p.reportProgress(ds, out, read)
 	}
-
-	if err := p.MetricRepo.SaveMyHeroPoints(entries); err != nil {
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if read == 0 {
+		return errors.New("file has no data rows")
+	}
+	if err := p.convertRows(ds, batch, actorId, now, out, convert); err != nil {
 		return err
 	}
 
+	ds.TotalRows = &read
+	p.reportProgress(ds, out, read)
 	return nil
 }
 
-func (p *Processor) processProspects(ds *domaindataset.DashboardDataset, data []byte, actorId string) error {
-	rows, err := file.ReadExcelRows(data, 0, 2)
-	if err != nil {
+// convertRows resolves the persons of a chunk of rows, converts the rows and flushes the records.
+func (p *Processor) convertRows(ds *domaindataset.DashboardDataset, rows []datasetRow, actorId string, now time.Time, out *parsedDataset, convert rowConverter) error {
+	if err := p.loadPersons(rows, out); err != nil {
 		return err
 	}
 
-	entries := make([]domainmetric.Prospect, 0, len(rows)-1)
-	now := time.Now()
+	for _, row := range rows {
+		var found bool
+		row.hondaId, row.personId, found = p.resolvePerson(row.cells, row.number, row.cols.of(fieldHondaId), out)
 
-	for i, row := range rows {
-		if i == 0 {
+		record, ok := convert(ds, row, actorId, now, out)
+		if !ok || row.hondaId == "" {
 			continue
 		}
-		if len(row) < 4 { // No, Honda ID, Nama Sales, Prospek
-			return fmt.Errorf("row %d has insufficient columns", i+1)
+		out.collectValue(row, record)
+		if !found {
+			out.quarantine(ds, row, actorId, now)
+			continue
 		}
+		out.add(record)
+	}
 
-		hondaId := strings.TrimSpace(row[1])
-		if hondaId == "" {
-			return fmt.Errorf("row %d missing Honda ID", i+1)
-		}
-		person, err := p.PersonRepo.GetByHondaID(hondaId)
-		if err != nil {
-			return fmt.Errorf("row %d person not found for honda_id %s", i+1, hondaId)
-		}
+	return p.flush(out)
+}
 
-		prospectStr := strings.TrimSpace(row[3])
-		if prospectStr == "" {
-			return fmt.Errorf("row %d prospek is required", i+1)
-		}
-		prospectCount, err := utils.ParseInt(prospectStr)
+func quizRecord(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool) {
+	var score *float64
+	if val := file.Cell(row.cells, row.cols.of(fieldScore)); val != "" {
+		num, err := strconv.ParseFloat(val, 64)
 		if err != nil {
-			return fmt.Errorf("row %d invalid Prospek value: %v", i+1, err)
+			out.errs.add(row.number, row.cols.of(fieldScore), val, "invalid score, expected a number")
+			return nil, false
 		}
-
-		entry := domainmetric.Prospect{
-			Id:            utils.CreateUUID(),
-			DatasetId:     ds.Id,
-			PeriodDate:    ds.PeriodDate,
-			PersonId:      person.Id,
-			HondaId:       hondaId,
-			ProspectCount: prospectCount,
-			CreatedAt:     now,
-			CreatedBy:     actorId,
-			UpdatedAt:     now,
-			UpdatedBy:     actorId,
-		}
-		entries = append(entries, entry)
-	}
-
-	if err := p.MetricRepo.SaveProspects(entries); err != nil {
-		return err
+		score = &num
 	}
 
-	return nil
+	return domainmetric.QuizResult{
+		Id:         utils.CreateUUID(),
+		DatasetId:  ds.Id,
+		PeriodDate: ds.PeriodDate,
+		PersonId:   row.personId,
+		HondaId:    row.hondaId,
+		DealerCode: optionalCell(row.cells, row.cols.of(fieldDealerCode)),
+		Score:      score,
+		PassStatus: optionalCell(row.cells, row.cols.of(fieldPassStatus)),
+		CreatedAt:  now,
+		CreatedBy:  actorId,
+		UpdatedAt:  now,
+		UpdatedBy:  actorId,
+	}, true
 }
 
-func (p *Processor) processApplePoints(ds *domaindataset.DashboardDataset, data []byte, actorId string) error {
-	rows, err := file.ReadExcelRows(data, 0, 1)
+func appleLoginRecord(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool) {
+	valid := true
+	morningVal := strings.ToUpper(file.Cell(row.cells, row.cols.of(fieldMorningDone)))
+	morningDone, err := parseDoneStatus(morningVal)
 	if err != nil {
-		return err
+		out.errs.add(row.number, row.cols.of(fieldMorningDone), morningVal, err.Error())
+		valid = false
+	}
+	eveningVal := strings.ToUpper(file.Cell(row.cells, row.cols.of(fieldEveningDone)))
+	eveningDone, err := parseDoneStatus(eveningVal)
+	if err != nil {
+		out.errs.add(row.number, row.cols.of(fieldEveningDone), eveningVal, err.Error())
+		valid = false
+	}
+	if !valid {
+		return nil, false
 	}
 
-	entries := make([]domainmetric.ApplePoint, 0, len(rows)-1)
-	now := time.Now()
+	return domainmetric.AppleLogin{
+		Id:          utils.CreateUUID(),
+		DatasetId:   ds.Id,
+		PeriodDate:  ds.PeriodDate,
+		PersonId:    row.personId,
+		HondaId:     row.hondaId,
+		DealerCode:  optionalCell(row.cells, row.cols.of(fieldDealerCode)),
+		LoginDate:   ds.PeriodDate,
+		MorningDone: morningDone,
+		EveningDone: eveningDone,
+		CreatedAt:   now,
+		CreatedBy:   actorId,
+		UpdatedAt:   now,
+		UpdatedBy:   actorId,
+	}, true
+}
 
-	for i, row := range rows {
-		if i == 0 {
-			continue // header
-		}
-		if len(row) < 3 { // Honda Id, Nama Sales, Point Apple
-			return fmt.Errorf("row %d has insufficient columns", i+1)
-		}
+func salesFLPRecord(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool) {
+	amount, ok := parseIntCell(row.cells, row.number, row.cols.of(fieldAmount), "sales amount", out)
+	if !ok {
+		return nil, false
+	}
 
-		hondaId := strings.TrimSpace(row[0])
-		if hondaId == "" {
-			return fmt.Errorf("row %d missing Honda ID", i+1)
-		}
+	return domainmetric.SalesFLP{
+		Id:         utils.CreateUUID(),
+		DatasetId:  ds.Id,
+		PeriodDate: ds.PeriodDate,
+		PersonId:   row.personId,
+		HondaId:    row.hondaId,
+		DealerCode: optionalCell(row.cells, row.cols.of(fieldDealerCode)),
+		Amount:     amount,
+		CreatedAt:  now,
+		CreatedBy:  actorId,
+		UpdatedAt:  now,
+		UpdatedBy:  actorId,
+	}, true
+}
 
-		person, err := p.PersonRepo.GetByHondaID(hondaId)
-		if err != nil {
-			return fmt.Errorf("row %d person not found for honda_id %s", i+1, hondaId)
-		}
+func myHeroPointRecord(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool) {
+	points, ok := parseIntCell(row.cells, row.number, row.cols.of(fieldPoints), "jumlah poin", out)
+	if !ok {
+		return nil, false
+	}
 
-		pointsStr := strings.TrimSpace(row[2])
-		if pointsStr == "" {
-			return fmt.Errorf("row %d point apple is required", i+1)
-		}
-		points, err := utils.ParseInt(pointsStr)
-		if err != nil {
-			return fmt.Errorf("row %d invalid %s value: %v", i+1, "Point Apple", err)
-		}
+	return domainmetric.MyHeroPoint{
+		Id:         utils.CreateUUID(),
+		DatasetId:  ds.Id,
+		PeriodDate: ds.PeriodDate,
+		PersonId:   row.personId,
+		HondaId:    row.hondaId,
+		DealerCode: optionalCell(row.cells, row.cols.of(fieldDealerCode)),
+		Points:     points,
+		CreatedAt:  now,
+		CreatedBy:  actorId,
+		UpdatedAt:  now,
+		UpdatedBy:  actorId,
+	}, true
+}
 
-		entry := domainmetric.ApplePoint{
-			Id:         utils.CreateUUID(),
-			DatasetId:  ds.Id,
-			PeriodDate: ds.PeriodDate,
-			PersonId:   person.Id,
-			HondaId:    hondaId,
-			Points:     points,
-			CreatedAt:  now,
-			CreatedBy:  actorId,
-			UpdatedAt:  now,
-			UpdatedBy:  actorId,
-		}
-		entries = append(entries, entry)
+func prospectRecord(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool) {
+	prospectCount, ok := parseIntCell(row.cells, row.number, row.cols.of(fieldProspectCount), "prospek", out)
+	if !ok {
+		return nil, false
 	}
 
-	if err := p.MetricRepo.SaveApplePoints(entries); err != nil {
-		return err
+	return domainmetric.Prospect{
+		Id:            utils.CreateUUID(),
+		DatasetId:     ds.Id,
+		PeriodDate:    ds.PeriodDate,
+		PersonId:      row.personId,
+		HondaId:       row.hondaId,
+		ProspectCount: prospectCount,
+		CreatedAt:     now,
+		CreatedBy:     actorId,
+		UpdatedAt:     now,
+		UpdatedBy:     actorId,
+	}, true
+}
+
+func applePointRecord(ds *domaindataset.DashboardDataset, row datasetRow, actorId string, now time.Time, out *parsedDataset) (interface{}, bool) {
+	points, ok := parseIntCell(row.cells, row.number, row.cols.of(fieldPoints), "point apple", out)
+	if !ok {
+		return nil, false
 	}
 
-	return nil
+	return domainmetric.ApplePoint{
+		Id:         utils.CreateUUID(),
+		DatasetId:  ds.Id,
+		PeriodDate: ds.PeriodDate,
+		PersonId:   row.personId,
+		HondaId:    row.hondaId,
+		Points:     points,
+		CreatedAt:  now,
+		CreatedBy:  actorId,
+		UpdatedAt:  now,
+		UpdatedBy:  actorId,
+	}, true
 }
@@ -0,0 +1,170 @@
+package servicedataset
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	interfaceperson "teamleader-management/internal/interfaces/person"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+// ServiceDatasetQuarantine lets admins map quarantined rows (unknown Honda IDs) to a person
+// and imports the rows into their metric table once mapped.
+type ServiceDatasetQuarantine struct {
+	Repo        interfacedataset.RepoDatasetQuarantineInterface
+	DatasetRepo interfacedataset.RepoDatasetInterface
+	PersonRepo  interfaceperson.RepoPersonInterface
+	Persons     interfaceperson.ServicePersonInterface
+	Processor   interfacedataset.DatasetProcessorInterface
+	DB          *gorm.DB
+}
+
+func NewDatasetQuarantineService(repo interfacedataset.RepoDatasetQuarantineInterface, datasetRepo interfacedataset.RepoDatasetInterface, personRepo interfaceperson.RepoPersonInterface, persons interfaceperson.ServicePersonInterface, processor interfacedataset.DatasetProcessorInterface, db *gorm.DB) *ServiceDatasetQuarantine {
+	return &ServiceDatasetQuarantine{
+		Repo:        repo,
+		DatasetRepo: datasetRepo,
+		PersonRepo:  personRepo,
+		Persons:     persons,
+		Processor:   processor,
+		DB:          db,
+	}
+}
+
+func (s *ServiceDatasetQuarantine) GetByID(id string) (domaindataset.DatasetQuarantineRow, error) {
+	return s.Repo.GetByID(id)
+}
+
+func (s *ServiceDatasetQuarantine) GetAll(params filter.BaseParams) ([]domaindataset.DatasetQuarantineRow, int64, error) {
+	params.Filters = filter.WhitelistFilter(params.Filters, []string{"status", "dataset_id", "dataset_type", "honda_id"})
+	return s.Repo.GetAll(params)
+}
+
+// Resolve maps a pending row, and with ApplyToAll every pending row sharing its Honda ID,
+// to a person and imports them into their metric table.
+func (s *ServiceDatasetQuarantine) Resolve(id string, req dto.DatasetQuarantineResolve, actorId string) (dto.DatasetQuarantineResolveResponse, error) {
+	row, err := s.Repo.GetByID(id)
+	if err != nil {
+		return dto.DatasetQuarantineResolveResponse{}, err
+	}
+	if row.Status != utils.QuarantineStatusPending {
+		return dto.DatasetQuarantineResolveResponse{}, fmt.Errorf("quarantined row is already %s", row.Status)
+	}
+	if req.PersonId == "" && req.Person == nil {
+		return dto.DatasetQuarantineResolveResponse{}, errors.New("person_id or person is required")
+	}
+
+	rows := []domaindataset.DatasetQuarantineRow{row}
+	if req.ApplyToAll {
+		if rows, err = s.Repo.GetPendingByHondaID(row.HondaId); err != nil {
+			return dto.DatasetQuarantineResolveResponse{}, err
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, r := range rows {
+		if checked[r.DatasetId] {
+			continue
+		}
+		checked[r.DatasetId] = true
+		ds, err := s.DatasetRepo.GetByID(r.DatasetId)
+		if err != nil {
+			return dto.DatasetQuarantineResolveResponse{}, fmt.Errorf("dataset %s not found: %w", r.DatasetId, err)
+		}
+		if ds.Status != utils.DatasetStatusDone {
+			return dto.DatasetQuarantineResolveResponse{}, fmt.Errorf("dataset %s is %s, only rows of imported datasets can be resolved", ds.Id, ds.Status)
+		}
+	}
+
+	var (
+		person  domainperson.Person
+		created bool
+	)
+	if req.PersonId != "" {
+		if person, err = s.PersonRepo.GetByID(req.PersonId); err != nil {
+			return dto.DatasetQuarantineResolveResponse{}, errors.New("person not found")
+		}
+	} else {
+		hondaId := req.Person.HondaId
+		if hondaId == "" {
+			hondaId = row.HondaId
+		}
+		dealerCode := req.Person.DealerCode
+		if dealerCode == nil {
+			dealerCode = row.DealerCode
+		}
+		person, err = s.Persons.Create(dto.PersonCreate{
+			HondaId:    hondaId,
+			Name:       req.Person.Name,
+			JobTitle:   req.Person.JobTitle,
+			Role:       req.Person.Role,
+			DealerCode: dealerCode,
+		}, actorId)
+		if err != nil {
+			return dto.DatasetQuarantineResolveResponse{}, fmt.Errorf("failed to create person: %w", err)
+		}
+		created = true
+	}
+
+	now := time.Now()
+	resolved := make([]string, 0, len(rows))
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.Processor.Reingest(tx, rows, person, actorId); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			r.Status = utils.QuarantineStatusResolved
+			r.ResolvedPersonId = &person.Id
+			r.ResolvedAt = &now
+			r.ResolvedBy = &actorId
+			r.UpdatedAt = now
+			r.UpdatedBy = actorId
+			if err := s.Repo.WithTx(tx).Update(r); err != nil {
+				return err
+			}
+			resolved = append(resolved, r.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.DatasetQuarantineResolveResponse{}, err
+	}
+
+	return dto.DatasetQuarantineResolveResponse{
+		PersonId:      person.Id,
+		HondaId:       person.HondaId,
+		PersonCreated: created,
+		ResolvedRows:  resolved,
+	}, nil
+}
+
+// Discard marks a pending row as not to be imported.
+func (s *ServiceDatasetQuarantine) Discard(id string, actorId string) (domaindataset.DatasetQuarantineRow, error) {
+	row, err := s.Repo.GetByID(id)
+	if err != nil {
+		return domaindataset.DatasetQuarantineRow{}, err
+	}
+	if row.Status != utils.QuarantineStatusPending {
+		return domaindataset.DatasetQuarantineRow{}, fmt.Errorf("quarantined row is already %s", row.Status)
+	}
+
+	now := time.Now()
+	row.Status = utils.QuarantineStatusDiscarded
+	row.ResolvedAt = &now
+	row.ResolvedBy = &actorId
+	row.UpdatedAt = now
+	row.UpdatedBy = actorId
+	if err := s.Repo.Update(row); err != nil {
+		return domaindataset.DatasetQuarantineRow{}, err
+	}
+	return row, nil
+}
+
+var _ interfacedataset.ServiceDatasetQuarantineInterface = (*ServiceDatasetQuarantine)(nil)
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,18 +11,50 @@ import (
 	domaindataset "teamleader-management/internal/domain/dataset"
 	"teamleader-management/internal/dto"
 	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	interfacemetric "teamleader-management/internal/interfaces/metric"
+	"teamleader-management/pkg/file"
+	"teamleader-management/pkg/logger"
 	"teamleader-management/utils"
+
+	"gorm.io/gorm"
 )
 
 type ServiceDataset struct {
-	Repo interfacedataset.RepoDatasetInterface
+	Repo           interfacedataset.RepoDatasetInterface
+	Sources        interfacedataset.DatasetSourceStoreInterface
+	TemplateRepo   interfacedataset.RepoDatasetTemplateInterface
+	MetricRepo     interfacemetric.RepoMetricInterface
+	QuarantineRepo interfacedataset.RepoDatasetQuarantineInterface
+	Evaluations    interfacedataset.EvaluationRecalculatorInterface
+	DB             *gorm.DB
+	MaxUploadBytes int64 // zero means no limit
+}
+
+// DuplicateUploadError is returned by Create when the same file was already uploaded for the dataset type.
+type DuplicateUploadError struct {
+	Existing domaindataset.DashboardDataset
 }
 
-func NewDatasetService(repo interfacedataset.RepoDatasetInterface) *ServiceDataset {
-	return &ServiceDataset{Repo: repo}
+func (e *DuplicateUploadError) Error() string {
+	return fmt.Sprintf("identical file was already uploaded as dataset %s (%s, status %s)", e.Existing.Id, e.Existing.FileName, e.Existing.Status)
 }
 
-func (s *ServiceDataset) Create(datasetType string, req dto.DatasetUploadRequest, file multipart.File, fileHeader *multipart.FileHeader, actorId string) (domaindataset.DashboardDataset, []byte, error) {
+func NewDatasetService(repo interfacedataset.RepoDatasetInterface, sources interfacedataset.DatasetSourceStoreInterface, templateRepo interfacedataset.RepoDatasetTemplateInterface, metricRepo interfacemetric.RepoMetricInterface, quarantineRepo interfacedataset.RepoDatasetQuarantineInterface, evaluations interfacedataset.EvaluationRecalculatorInterface, db *gorm.DB, maxUploadMB int) *ServiceDataset {
+	return &ServiceDataset{
+		Repo:           repo,
+		Sources:        sources,
+		TemplateRepo:   templateRepo,
+		MetricRepo:     metricRepo,
+		QuarantineRepo: quarantineRepo,
+		Evaluations:    evaluations,
+		DB:             db,
+		MaxUploadBytes: int64(maxUploadMB) << 20,
+	}
+}
+
+// Create validates an upload and registers its dataset. The upload is spooled to a temporary file,
+// which the caller reads for processing and must close.
+func (s *ServiceDataset) Create(datasetType string, req dto.DatasetUploadRequest, upload io.Reader, fileName, contentType string, actorId string) (domaindataset.DashboardDataset, *file.TempFile, error) {
 	dType := strings.ToUpper(strings.TrimSpace(datasetType))
 	if !utils.AllowedDatasetTypes[dType] {
 		return domaindataset.DashboardDataset{}, nil, fmt.Errorf("invalid dataset type: %s", dType)
@@ -53,13 +84,27 @@ func (s *ServiceDataset) Create(datasetType string, req dto.DatasetUploadRequest
 	if _, ok := utils.AllowedPeriodFrequencies[periodFrequency]; !ok {
 		return domaindataset.DashboardDataset{}, nil, errors.New("invalid period_frequency")
 	}
+	if utils.DatasetDailyOnlyTypes[dType] && periodFrequency != utils.PeriodDaily {
+		return domaindataset.DashboardDataset{}, nil, fmt.Errorf("%s datasets can only be uploaded with %s frequency", dType, utils.PeriodDaily)
+	}
 	if periodFrequency == utils.PeriodWeekly && periodDate.Weekday() != time.Monday {
 		return domaindataset.DashboardDataset{}, nil, errors.New("period_date must be Monday for WEEKLY frequency")
 	}
 
-	data, err := io.ReadAll(file)
+	fileFormat, err := file.DetectFormat(fileName, contentType)
 	if err != nil {
-		return domaindataset.DashboardDataset{}, nil, fmt.Errorf("failed to read uploaded file: %w", err)
+		return domaindataset.DashboardDataset{}, nil, err
+	}
+
+	tmp, err := file.SpoolTemp(upload, s.MaxUploadBytes)
+	if err != nil {
+		return domaindataset.DashboardDataset{}, nil, err
+	}
+
+	contentHash := tmp.Hash
+	if existing, err := s.Repo.GetByContentHash(dType, contentHash); err == nil && existing.Id != "" {
+		tmp.Close()
+		return domaindataset.DashboardDataset{}, nil, &DuplicateUploadError{Existing: existing}
 	}
 
 	entity := domaindataset.DashboardDataset{
@@ -69,46 +114,334 @@ func (s *ServiceDataset) Create(datasetType string, req dto.DatasetUploadRequest
 		PeriodMonth:     periodMonth,
 		PeriodYear:      periodYear,
 		PeriodFrequency: periodFrequency,
-		FileName:        filepath.Base(fileHeader.Filename),
+		FileName:        filepath.Base(fileName),
+		FileFormat:      fileFormat,
+		ContentHash:     contentHash,
 		UploadedBy:      actorId,
 		UploadedAt:      time.Now(),
-		Status:          utils.DatasetStatusProcessing,
+		Status:          utils.DatasetStatusUploaded,
 		CreatedAt:       time.Now(),
 		CreatedBy:       actorId,
 	}
 
-	if err := s.Repo.Store(entity); err != nil {
+	if req.DryRun {
+		return entity, tmp, nil
+	}
+
+	history := []domaindataset.DatasetStatusHistory{
+		statusHistoryEntry(entity.Id, nil, entity.Status, actorId, "file uploaded", entity.UploadedAt),
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.Repo.WithTx(tx).Store(entity); err != nil {
+			return err
+		}
+		return s.Repo.WithTx(tx).StoreStatusHistory(history)
+	})
+	if err != nil {
+		tmp.Close()
 		return domaindataset.DashboardDataset{}, nil, err
 	}
 
-	return entity, data, nil
+	entity.History = history
+	return entity, tmp, nil
+}
+
+func (s *ServiceDataset) GetByID(id string) (domaindataset.DashboardDataset, error) {
+	return s.Repo.GetByID(id)
 }
 
 func (s *ServiceDataset) List(filters map[string]interface{}) ([]domaindataset.DashboardDataset, int64, error) {
 	return s.Repo.GetAll(filters)
 }
 
-func (s *ServiceDataset) UpdateStatus(id string, status string, actorId string) (domaindataset.DashboardDataset, error) {
+// UpdateStatus lets an admin fail a stuck import or archive a dataset that is no longer in use.
+// The other statuses are only reached through the import flow, e.g. DONE requires the rows to be imported.
+func (s *ServiceDataset) UpdateStatus(id string, status, reason string, actorId string) (domaindataset.DashboardDataset, error) {
 	status = strings.ToUpper(strings.TrimSpace(status))
 	if status == "" {
 		return domaindataset.DashboardDataset{}, errors.New("status is required")
 	}
+	if !utils.DatasetManualStatuses[status] {
+		return domaindataset.DashboardDataset{}, fmt.Errorf("status %s cannot be set manually", status)
+	}
 
 	ds, err := s.Repo.GetByID(id)
 	if err != nil {
 		return domaindataset.DashboardDataset{}, err
 	}
 
-	now := time.Now()
-	ds.Status = status
-	ds.UpdatedAt = now
-	ds.UpdatedBy = actorId
+	reason = strings.TrimSpace(reason)
+	history, err := changeStatus(&ds, status, actorId, reason)
+	if err != nil {
+		return domaindataset.DashboardDataset{}, err
+	}
+	if status == utils.DatasetStatusFailed && reason != "" {
+		ds.ErrorMessage = &reason
+	}
 
-	if err := s.Repo.Update(ds); err != nil {
+	if err := s.Repo.UpdateWithHistory(ds, history); err != nil {
 		return domaindataset.DashboardDataset{}, err
 	}
 
+	ds.History = append(ds.History, history...)
 	return ds, nil
 }
 
+func (s *ServiceDataset) GetErrors(id string) ([]domaindataset.DatasetRowError, error) {
+	if _, err := s.Repo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetErrors(id)
+}
+
+// BuildErrorReport returns a copy of the uploaded sheet with an extra column listing the errors of each row.
+func (s *ServiceDataset) BuildErrorReport(id string) (string, []byte, error) {
+	ds, err := s.Repo.GetByID(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rowErrors, err := s.Repo.GetErrors(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rowErrors) == 0 {
+		return "", nil, errors.New("dataset has no row errors")
+	}
+
+	tpl, err := resolveTemplate(s.TemplateRepo, ds.Type)
+	if err != nil {
+		return "", nil, err
+	}
+
+	original, err := s.Sources.Open(id)
+	if err != nil {
+		return "", nil, errors.New("original file is no longer available")
+	}
+	defer original.Close()
+
+	source, err := file.NewRowSource(datasetFileFormat(&ds), original)
+	if err != nil {
+		return "", nil, err
+	}
+	rows, err := source.Rows(tpl.SheetName, tpl.HeaderRow)
+	if err != nil {
+		return "", nil, err
+	}
+
+	messages := make(map[int][]string)
+	for _, e := range rowErrors {
+		msg := e.Reason
+		if e.Column != "" {
+			msg = fmt.Sprintf("%s: %s", e.Column, e.Reason)
+		}
+		if e.Value != "" {
+			msg = fmt.Sprintf("%s (%s)", msg, e.Value)
+		}
+		messages[e.RowNumber] = append(messages[e.RowNumber], msg)
+	}
+
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	report := make([][]string, len(rows))
+	for i, row := range rows {
+		padded := make([]string, width+1)
+		copy(padded, row)
+		if i == tpl.HeaderRow-1 {
+			padded[width] = "Errors"
+		} else {
+			padded[width] = strings.Join(messages[i+1], "; ")
+		}
+		report[i] = padded
+	}
+
+	content, err := file.WriteExcelRows("Validation Report", report)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fileName := strings.TrimSuffix(ds.FileName, filepath.Ext(ds.FileName)) + "_errors.xlsx"
+	return fileName, content, nil
+}
+
+// GetSourceFile opens the original uploaded file of a dataset and returns it with its download name and
+// content type. The caller must close the reader.
+func (s *ServiceDataset) GetSourceFile(id string) (string, string, io.ReadCloser, error) {
+	ds, err := s.Repo.GetByID(id)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	original, err := s.Sources.Open(id)
+	if err != nil {
+		return "", "", nil, errors.New("original file is no longer available")
+	}
+
+	return datasetDownloadName(ds), datasetContentType(datasetFileFormat(&ds)), original, nil
+}
+
+// Reprocess resets a finished or failed dataset so that its stored original file is imported again,
+// e.g. after a person record or the column template was fixed. Metric rows and pending quarantine rows
+// of the previous run are removed.
+func (s *ServiceDataset) Reprocess(id string, actorId string) (domaindataset.DashboardDataset, error) {
+	ds, err := s.Repo.GetByID(id)
+	if err != nil {
+		return domaindataset.DashboardDataset{}, err
+	}
+
+	switch ds.Status {
+	case utils.DatasetStatusDone, utils.DatasetStatusFailed:
+	case utils.DatasetStatusSuperseded:
+		return domaindataset.DashboardDataset{}, errors.New("dataset was superseded by a newer upload of the same period")
+	case utils.DatasetStatusArchived:
+		return domaindataset.DashboardDataset{}, errors.New("dataset is archived")
+	default:
+		return domaindataset.DashboardDataset{}, errors.New("dataset is already queued or being processed")
+	}
+
+	original, err := s.Sources.Open(ds.Id)
+	if err != nil {
+		return domaindataset.DashboardDataset{}, errors.New("original file is no longer available, please upload again")
+	}
+	original.Close()
+
+	history, err := changeStatus(&ds, utils.DatasetStatusUploaded, actorId, "reprocess requested")
+	if err != nil {
+		return domaindataset.DashboardDataset{}, err
+	}
+	resetImport(&ds)
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.MetricRepo.WithTx(tx).SoftDeleteByDatasets([]string{ds.Id}, actorId); err != nil {
+			return err
+		}
+		if err := s.QuarantineRepo.WithTx(tx).DeletePendingByDatasets([]string{ds.Id}, actorId); err != nil {
+			return err
+		}
+		return s.Repo.WithTx(tx).UpdateWithHistory(ds, history)
+	})
+	if err != nil {
+		return domaindataset.DashboardDataset{}, err
+	}
+
+	ds.History = append(ds.History, history...)
+	return ds, nil
+}
+
+// Delete soft-deletes a dataset together with every metric row and pending quarantine row it produced.
+// Datasets being imported or queued for import cannot be deleted. The import of the deleted dataset overwrote
+// the metric rows of the datasets it superseded, so these go back to UPLOADED and their ids are returned for
+// the caller to queue again; recalculate is refused with a DatasetRecalculationConflictError in that case, since the
+// evaluations can only be recalculated once those imports are done. Otherwise the recalculation runs in the
+// background with the coverage gate checked first, with force, and a refusal is reported in the response.
+func (s *ServiceDataset) Delete(id string, actorId string, recalculate bool, force bool) (dto.DatasetDeleteResponse, error) {
+	var (
+		ds          domaindataset.DashboardDataset
+		rowsDeleted int64
+		restored    []string
+	)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+		var err error
+		ds, err = repo.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		switch ds.Status {
+		case utils.DatasetStatusProcessing:
+			return errors.New("dataset is being processed, try again when it has finished")
+		case utils.DatasetStatusUploaded:
+			return errors.New("dataset is queued for processing, try again when it has finished")
+		}
+
+		previous, err := repo.GetSupersededBy(ds.Id)
+		if err != nil {
+			return err
+		}
+		if recalculate && len(previous) > 0 {
+			return &dto.DatasetRecalculationConflictError{Restored: len(previous)}
+		}
+		for _, prev := range previous {
+			original, err := s.Sources.Open(prev.Id)
+			if err != nil {
+				return fmt.Errorf("the original file of dataset %s, superseded by this one, is no longer available; upload it again before deleting this dataset", prev.Id)
+			}
+			original.Close()
+
+			history, err := changeStatus(&prev, utils.DatasetStatusUploaded, actorId, fmt.Sprintf("restored, dataset %s that superseded it was deleted", ds.Id))
+			if err != nil {
+				return err
+			}
+			prev.SupersededBy = nil
+			resetImport(&prev)
+			if err := repo.UpdateWithHistory(prev, history); err != nil {
+				return err
+			}
+			restored = append(restored, prev.Id)
+		}
+
+		n, err := s.MetricRepo.WithTx(tx).SoftDeleteByDatasets([]string{ds.Id}, actorId)
+		if err != nil {
+			return err
+		}
+		rowsDeleted = n
+		if err := s.QuarantineRepo.WithTx(tx).DeletePendingByDatasets([]string{ds.Id}, actorId); err != nil {
+			return err
+		}
+		deleted := statusHistoryEntry(ds.Id, &ds.Status, utils.DatasetStatusDeleted, actorId, "dataset deleted", time.Now())
+		if err := repo.StoreStatusHistory([]domaindataset.DatasetStatusHistory{deleted}); err != nil {
+			return err
+		}
+		return repo.Delete(ds.Id, actorId)
+	})
+	if err != nil {
+		return dto.DatasetDeleteResponse{}, err
+	}
+
+	s.Sources.Remove(ds.Id)
+	logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("[ServiceDataset][Delete] dataset %s (%s %s) deleted by %s, %d metric row(s) removed, %d superseded dataset(s) restored",
+		ds.Id, ds.Type, ds.PeriodDate.Format("2006-01-02"), actorId, rowsDeleted, len(restored)))
+
+	res := dto.DatasetDeleteResponse{
+		DatasetId:          ds.Id,
+		MetricRowsDeleted:  rowsDeleted,
+		RestoredDatasetIds: restored,
+		PeriodMonth:        ds.PeriodMonth,
+		PeriodYear:         ds.PeriodYear,
+	}
+
+	if recalculate && s.Evaluations != nil {
+		// The coverage gate is checked now so that a refusal is answered rather than only logged.
+		warnings, err := s.Evaluations.CheckCoverage(ds.PeriodMonth, ds.PeriodYear, force)
+		if err != nil {
+			res.RecalculationError = err.Error()
+			return res, nil
+		}
+		res.RecalculationStarted = true
+		res.RecalculationWarnings = warnings
+		go func() {
+			if _, _, err := s.Evaluations.RecalculateEvaluation(ds.PeriodMonth, ds.PeriodYear, "", force); err != nil {
+				logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[ServiceDataset][Delete] RecalculateEvaluation %d-%d; Error: %+v", ds.PeriodYear, ds.PeriodMonth, err))
+			}
+		}()
+	}
+
+	return res, nil
+}
+
+// resetImport clears the outcome of the previous import of a dataset that is imported again.
+func resetImport(ds *domaindataset.DashboardDataset) {
+	ds.TotalRows = nil
+	ds.ProcessedRows = 0
+	ds.QuarantinedRows = 0
+	ds.ErrorMessage = nil
+	ds.StartedAt = nil
+	ds.FinishedAt = nil
+}
+
 var _ interfacedataset.ServiceDatasetInterface = (*ServiceDataset)(nil)
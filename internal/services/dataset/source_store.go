@@ -0,0 +1,126 @@
+package servicedataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	domainmedia "teamleader-management/internal/domain/media"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	interfacemedia "teamleader-management/internal/interfaces/media"
+	"teamleader-management/pkg/file"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/pkg/storage"
+	"teamleader-management/utils"
+)
+
+// ObjectSourceStore keeps the original dataset files in object storage.
+// Each file is linked to its dataset through a media record with entity type "dataset".
+type ObjectSourceStore struct {
+	Storage   storage.StorageProvider
+	MediaRepo interfacemedia.RepoMediaInterface
+}
+
+func NewObjectSourceStore(storageProvider storage.StorageProvider, mediaRepo interfacemedia.RepoMediaInterface) *ObjectSourceStore {
+	return &ObjectSourceStore{
+		Storage:   storageProvider,
+		MediaRepo: mediaRepo,
+	}
+}
+
+func (s *ObjectSourceStore) Save(ds domaindataset.DashboardDataset, r io.Reader, size int64) error {
+	ctx := context.Background()
+	contentType := datasetContentType(datasetFileFormat(&ds))
+
+	fileUrl, err := s.Storage.UploadFileFromReader(ctx, r, size, ds.FileName, utils.UnderscoreToDash(utils.EntityDataset), contentType)
+	if err != nil {
+		return err
+	}
+
+	media := domainmedia.Media{
+		Id:           utils.CreateUUID(),
+		EntityType:   utils.EntityDataset,
+		EntityId:     ds.Id,
+		FileUrl:      fileUrl,
+		FileName:     ds.FileName,
+		FileType:     &contentType,
+		FileSize:     &size,
+		DisplayOrder: 1,
+		CreatedAt:    time.Now(),
+		CreatedBy:    ds.UploadedBy,
+	}
+	if err := s.MediaRepo.Store(media); err != nil {
+		_ = s.Storage.DeleteFile(ctx, fileUrl)
+		return fmt.Errorf("failed to save media record: %w", err)
+	}
+	return nil
+}
+
+func (s *ObjectSourceStore) Open(datasetId string) (io.ReadCloser, error) {
+	media, err := s.media(datasetId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Storage.DownloadFile(context.Background(), s.Storage.ObjectName(media.FileUrl))
+}
+
+func (s *ObjectSourceStore) Remove(datasetId string) {
+	mediaList, err := s.MediaRepo.GetByEntity(utils.EntityDataset, datasetId)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[ObjectSourceStore][Remove] MediaRepo.GetByEntity %s; Error: %+v", datasetId, err))
+		return
+	}
+	for _, media := range mediaList {
+		if err := s.Storage.DeleteFile(context.Background(), media.FileUrl); err != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[ObjectSourceStore][Remove] Storage.DeleteFile %s; Error: %+v", media.FileUrl, err))
+		}
+	}
+	if err := s.MediaRepo.DeleteByEntity(utils.EntityDataset, datasetId); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[ObjectSourceStore][Remove] MediaRepo.DeleteByEntity %s; Error: %+v", datasetId, err))
+	}
+}
+
+// media returns the stored file record of the dataset.
+func (s *ObjectSourceStore) media(datasetId string) (domainmedia.Media, error) {
+	mediaList, err := s.MediaRepo.GetByEntity(utils.EntityDataset, datasetId)
+	if err != nil {
+		return domainmedia.Media{}, err
+	}
+	if len(mediaList) == 0 {
+		return domainmedia.Media{}, errors.New("no file stored for dataset")
+	}
+	return mediaList[0], nil
+}
+
+// datasetContentType returns the MIME type used when storing or serving a file of the given format.
+func datasetContentType(format string) string {
+	switch format {
+	case file.FormatCSV:
+		return utils.ContentTypeCSV
+	case file.FormatJSON:
+		return utils.ContentTypeJSON
+	case file.FormatXLSX:
+		return utils.ContentTypeXLSX
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// datasetDownloadName keeps the uploaded file name, adding the extension of its format when missing.
+func datasetDownloadName(ds domaindataset.DashboardDataset) string {
+	name := ds.FileName
+	if name == "" {
+		name = fmt.Sprintf("%s_%s", ds.Type, ds.PeriodDate.Format("2006-01-02"))
+	}
+	if filepath.Ext(name) == "" {
+		name += "." + datasetFileFormat(&ds)
+	}
+	return name
+}
+
+var _ interfacedataset.DatasetSourceStoreInterface = (*ObjectSourceStore)(nil)
@@ -0,0 +1,48 @@
+package servicedataset
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+)
+
+// SpoolStore keeps uploaded dataset files on local disk. It is used when no object storage is configured.
+type SpoolStore struct {
+	Dir string
+}
+
+func NewSpoolStore(dir string) *SpoolStore {
+	return &SpoolStore{Dir: dir}
+}
+
+func (s *SpoolStore) path(datasetId string) string {
+	return filepath.Join(s.Dir, filepath.Base(datasetId))
+}
+
+func (s *SpoolStore) Save(ds domaindataset.DashboardDataset, r io.Reader, _ int64) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path(ds.Id), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *SpoolStore) Open(datasetId string) (io.ReadCloser, error) {
+	return os.Open(s.path(datasetId))
+}
+
+func (s *SpoolStore) Remove(datasetId string) {
+	_ = os.Remove(s.path(datasetId))
+}
+
+var _ interfacedataset.DatasetSourceStoreInterface = (*SpoolStore)(nil)
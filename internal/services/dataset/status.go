@@ -0,0 +1,47 @@
+package servicedataset
+
+import (
+	"fmt"
+	"time"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/utils"
+)
+
+// changeStatus moves ds to status after checking the transition is allowed, and returns the history
+// entry to store with it. Setting the status a dataset already has is not a transition and returns no entry.
+func changeStatus(ds *domaindataset.DashboardDataset, status, actorId, reason string) ([]domaindataset.DatasetStatusHistory, error) {
+	now := time.Now()
+	if ds.Status == status {
+		ds.UpdatedAt = now
+		ds.UpdatedBy = actorId
+		return nil, nil
+	}
+	if !utils.CanTransitionDatasetStatus(ds.Status, status) {
+		return nil, fmt.Errorf("dataset cannot change from %s to %s", ds.Status, status)
+	}
+
+	entry := statusHistoryEntry(ds.Id, &ds.Status, status, actorId, reason, now)
+	ds.Status = status
+	ds.UpdatedAt = now
+	ds.UpdatedBy = actorId
+	return []domaindataset.DatasetStatusHistory{entry}, nil
+}
+
+func statusHistoryEntry(datasetId string, from *string, to, actorId, reason string, at time.Time) domaindataset.DatasetStatusHistory {
+	entry := domaindataset.DatasetStatusHistory{
+		Id:        utils.CreateUUID(),
+		DatasetId: datasetId,
+		ToStatus:  to,
+		ChangedBy: actorId,
+		ChangedAt: at,
+	}
+	if from != nil {
+		fromStatus := *from
+		entry.FromStatus = &fromStatus
+	}
+	if reason != "" {
+		entry.Reason = &reason
+	}
+	return entry
+}
@@ -0,0 +1,477 @@
+package servicedataset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	interfaceperson "teamleader-management/internal/interfaces/person"
+	"teamleader-management/pkg/file"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+// Logical fields read from a dataset sheet. Templates map them to header names.
+const (
+	fieldHondaId       = "honda_id"
+	fieldDealerCode    = "dealer_code"
+	fieldScore         = "score"
+	fieldPassStatus    = "pass_status"
+	fieldMorningDone   = "morning_done"
+	fieldEveningDone   = "evening_done"
+	fieldAmount        = "amount"
+	fieldPoints        = "points"
+	fieldProspectCount = "prospect_count"
+)
+
+// Informational columns of the standard reports. They are not read by the processor
+// but are part of the generated upload templates.
+const (
+	layoutNo       = "no"
+	layoutName     = "name"
+	layoutJobTitle = "job_title"
+	layoutRole     = "role"
+)
+
+type templateField struct {
+	Name     string
+	Required bool
+	Aliases  []string // built-in header names
+}
+
+// datasetFields lists the fields of each dataset type together with the headers of the standard Honda reports.
+var datasetFields = map[string][]templateField{
+	utils.DatasetQuiz: { // No, Honda ID, Nama, Jabatan, Role, Kode Dealer, Nilai, Lulus/Tidak
+		{Name: fieldHondaId, Required: true, Aliases: []string{"Honda ID"}},
+		{Name: fieldDealerCode, Aliases: []string{"Kode Dealer", "Dealer Code"}},
+		{Name: fieldScore, Required: true, Aliases: []string{"Nilai", "Score"}},
+		{Name: fieldPassStatus, Aliases: []string{"Lulus/Tidak", "Lulus", "Pass Status"}},
+	},
+	utils.DatasetLoginApple: { // No, Honda ID, Nama Lengkap, Jabatan, Kode Dealer, Frequent PAGI, Frequent SORE
+		{Name: fieldHondaId, Required: true, Aliases: []string{"Honda ID"}},
+		{Name: fieldDealerCode, Aliases: []string{"Kode Dealer", "Dealer Code"}},
+		{Name: fieldMorningDone, Required: true, Aliases: []string{"Frequent PAGI", "Pagi"}},
+		{Name: fieldEveningDone, Required: true, Aliases: []string{"Frequent SORE", "Sore"}},
+	},
+	utils.DatasetSalesFLP: { // No, Honda ID, Kode Dealer, Nama Sales People, Sales
+		{Name: fieldHondaId, Required: true, Aliases: []string{"Honda ID"}},
+		{Name: fieldDealerCode, Aliases: []string{"Kode Dealer", "Dealer Code"}},
+		{Name: fieldAmount, Required: true, Aliases: []string{"Sales", "Sales FLP", "Amount"}},
+	},
+	utils.DatasetPointApple: { // Honda Id, Nama Sales, Point Apple
+		{Name: fieldHondaId, Required: true, Aliases: []string{"Honda ID"}},
+		{Name: fieldPoints, Required: true, Aliases: []string{"Point Apple", "Poin Apple", "Points"}},
+	},
+	utils.DatasetPointMyHero: { // No, Honda Id, Kode Dealer, Nama, Jumlah Poin
+		{Name: fieldHondaId, Required: true, Aliases: []string{"Honda ID"}},
+		{Name: fieldDealerCode, Aliases: []string{"Kode Dealer", "Dealer Code"}},
+		{Name: fieldPoints, Required: true, Aliases: []string{"Jumlah Poin", "Poin", "Points"}},
+	},
+	utils.DatasetTotalProspect: { // No, Honda ID, Nama Sales, Prospek
+		{Name: fieldHondaId, Required: true, Aliases: []string{"Honda ID"}},
+		{Name: fieldProspectCount, Required: true, Aliases: []string{"Prospek", "Prospect", "Total Prospek"}},
+	},
+}
+
+type layoutColumn struct {
+	Header string
+	Field  string
+}
+
+// datasetLayouts is the full column layout of the standard report of each dataset type, used for upload templates.
+var datasetLayouts = map[string][]layoutColumn{
+	utils.DatasetQuiz: {
+		{"No", layoutNo}, {"Honda ID", fieldHondaId}, {"Nama", layoutName}, {"Jabatan", layoutJobTitle},
+		{"Role", layoutRole}, {"Kode Dealer", fieldDealerCode}, {"Nilai", fieldScore}, {"Lulus/Tidak", fieldPassStatus},
+	},
+	utils.DatasetLoginApple: {
+		{"No", layoutNo}, {"Honda ID", fieldHondaId}, {"Nama Lengkap", layoutName}, {"Jabatan", layoutJobTitle},
+		{"Kode Dealer", fieldDealerCode}, {"Frequent PAGI", fieldMorningDone}, {"Frequent SORE", fieldEveningDone},
+	},
+	utils.DatasetSalesFLP: {
+		{"No", layoutNo}, {"Honda ID", fieldHondaId}, {"Kode Dealer", fieldDealerCode}, {"Nama Sales People", layoutName},
+		{"Sales", fieldAmount},
+	},
+	utils.DatasetPointApple: {
+		{"Honda Id", fieldHondaId}, {"Nama Sales", layoutName}, {"Point Apple", fieldPoints},
+	},
+	utils.DatasetPointMyHero: {
+		{"No", layoutNo}, {"Honda Id", fieldHondaId}, {"Kode Dealer", fieldDealerCode}, {"Nama", layoutName},
+		{"Jumlah Poin", fieldPoints},
+	},
+	utils.DatasetTotalProspect: {
+		{"No", layoutNo}, {"Honda ID", fieldHondaId}, {"Nama Sales", layoutName}, {"Prospek", fieldProspectCount},
+	},
+}
+
+// templateExampleValues fills the two example rows of an upload template.
+var templateExampleValues = map[string][2]string{
+	layoutNo:           {"1", "2"},
+	layoutName:         {"Budi Santoso", "Siti Rahma"},
+	layoutJobTitle:     {"Sales Counter", "Team Leader"},
+	layoutRole:         {utils.RoleSM, utils.RoleTL},
+	fieldHondaId:       {"HND0001", "HND0002"},
+	fieldDealerCode:    {"D001", "D001"},
+	fieldScore:         {"85", "60"},
+	fieldPassStatus:    {"LULUS", "TIDAK LULUS"},
+	fieldMorningDone:   {"DONE", "NOT DONE"},
+	fieldEveningDone:   {"DONE", "DONE"},
+	fieldAmount:        {"5", "3"},
+	fieldPoints:        {"120", "80"},
+	fieldProspectCount: {"10", "7"},
+}
+
+// templateDropdownFields lists the enumerated fields that get a drop-down list in upload templates.
+var templateDropdownFields = map[string][]string{
+	fieldMorningDone: {"DONE", "NOT DONE"},
+	fieldEveningDone: {"DONE", "NOT DONE"},
+}
+
+const (
+	// templateDropdownRows is the number of data rows covered by drop-down lists when nothing is prefilled.
+	templateDropdownRows = 1000
+	// templateMaxPersons caps the number of persons prefilled into an upload template.
+	templateMaxPersons = 10000
+)
+
+// defaultTemplate returns the built-in template used when no active template is stored for the type.
+func defaultTemplate(datasetType string) domaindataset.DatasetTemplate {
+	columns := domaindataset.TemplateColumns{}
+	for _, f := range datasetFields[datasetType] {
+		columns[f.Name] = append([]string(nil), f.Aliases...)
+	}
+	return domaindataset.DatasetTemplate{
+		DatasetType: datasetType,
+		HeaderRow:   1,
+		Columns:     columns,
+		Active:      true,
+	}
+}
+
+// resolveTemplate returns the active stored template of a dataset type, falling back to the built-in one.
+func resolveTemplate(repo interfacedataset.RepoDatasetTemplateInterface, datasetType string) (domaindataset.DatasetTemplate, error) {
+	datasetType = strings.ToUpper(datasetType)
+	if _, ok := datasetFields[datasetType]; !ok {
+		return domaindataset.DatasetTemplate{}, fmt.Errorf("dataset type %s not supported yet", datasetType)
+	}
+	if repo != nil {
+		tpl, err := repo.GetByType(datasetType)
+		if err == nil && tpl.Active {
+			if tpl.HeaderRow < 1 {
+				tpl.HeaderRow = 1
+			}
+			return tpl, nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return domaindataset.DatasetTemplate{}, err
+		}
+	}
+	return defaultTemplate(datasetType), nil
+}
+
+// normalizeHeader lower-cases a header and drops everything but letters and digits,
+// so "Honda ID", "honda_id" and "HONDA-ID " all match.
+func normalizeHeader(val string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(val) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// columnIndex maps template fields to 0-based column positions of the uploaded sheet.
+type columnIndex map[string]int
+
+// of returns the column of a field, or -1 when the sheet does not contain it.
+func (c columnIndex) of(field string) int {
+	if idx, ok := c[field]; ok {
+		return idx
+	}
+	return -1
+}
+
+// locateColumns finds every template field in the header row.
+func locateColumns(tpl domaindataset.DatasetTemplate, header []string) (columnIndex, error) {
+	positions := make(map[string]int, len(header))
+	for i, h := range header {
+		key := normalizeHeader(h)
+		if _, exists := positions[key]; key != "" && !exists {
+			positions[key] = i
+		}
+	}
+
+	cols := columnIndex{}
+	var missing []string
+	for _, f := range datasetFields[strings.ToUpper(tpl.DatasetType)] {
+		aliases := tpl.Columns[f.Name]
+		found := false
+		for _, alias := range aliases {
+			if idx, ok := positions[normalizeHeader(alias)]; ok {
+				cols[f.Name] = idx
+				found = true
+				break
+			}
+		}
+		if !found && f.Required {
+			missing = append(missing, fmt.Sprintf("%s (%s)", f.Name, strings.Join(aliases, " / ")))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("header row %d is missing required column(s): %s", tpl.HeaderRow, strings.Join(missing, ", "))
+	}
+
+	return cols, nil
+}
+
+// validateTemplateColumns checks that only known fields are mapped and every required field has a header.
+func validateTemplateColumns(datasetType string, columns map[string][]string) (domaindataset.TemplateColumns, error) {
+	fields, ok := datasetFields[datasetType]
+	if !ok {
+		return nil, fmt.Errorf("invalid dataset type: %s", datasetType)
+	}
+
+	known := make(map[string]templateField, len(fields))
+	for _, f := range fields {
+		known[f.Name] = f
+	}
+
+	cleaned := domaindataset.TemplateColumns{}
+	for name, aliases := range columns {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q for %s", name, datasetType)
+		}
+		for _, alias := range aliases {
+			if normalizeHeader(alias) != "" {
+				cleaned[name] = append(cleaned[name], strings.TrimSpace(alias))
+			}
+		}
+	}
+
+	var missing []string
+	for _, f := range fields {
+		if f.Required && len(cleaned[f.Name]) == 0 {
+			missing = append(missing, f.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("columns must define at least one header for: %s", strings.Join(missing, ", "))
+	}
+
+	return cleaned, nil
+}
+
+type ServiceDatasetTemplate struct {
+	Repo       interfacedataset.RepoDatasetTemplateInterface
+	PersonRepo interfaceperson.RepoPersonInterface
+}
+
+func NewDatasetTemplateService(repo interfacedataset.RepoDatasetTemplateInterface, personRepo interfaceperson.RepoPersonInterface) *ServiceDatasetTemplate {
+	return &ServiceDatasetTemplate{Repo: repo, PersonRepo: personRepo}
+}
+
+func (s *ServiceDatasetTemplate) Create(req dto.DatasetTemplateCreate, actorId string) (domaindataset.DatasetTemplate, error) {
+	dType := strings.ToUpper(strings.TrimSpace(req.DatasetType))
+	columns, err := validateTemplateColumns(dType, req.Columns)
+	if err != nil {
+		return domaindataset.DatasetTemplate{}, err
+	}
+
+	if existing, err := s.Repo.GetByType(dType); err == nil && existing.Id != "" {
+		return domaindataset.DatasetTemplate{}, errors.New("template for this dataset type already exists")
+	}
+
+	headerRow := req.HeaderRow
+	if headerRow < 1 {
+		headerRow = 1
+	}
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	entity := domaindataset.DatasetTemplate{
+		Id:          utils.CreateUUID(),
+		DatasetType: dType,
+		SheetName:   strings.TrimSpace(req.SheetName),
+		HeaderRow:   headerRow,
+		Columns:     columns,
+		Active:      active,
+		CreatedAt:   time.Now(),
+		CreatedBy:   actorId,
+	}
+
+	if err := s.Repo.Store(entity); err != nil {
+		return domaindataset.DatasetTemplate{}, err
+	}
+
+	return entity, nil
+}
+
+func (s *ServiceDatasetTemplate) GetByID(id string) (domaindataset.DatasetTemplate, error) {
+	return s.Repo.GetByID(id)
+}
+
+// GetEffective returns the template the processor currently uses for a dataset type.
+func (s *ServiceDatasetTemplate) GetEffective(datasetType string) (domaindataset.DatasetTemplate, error) {
+	return resolveTemplate(s.Repo, datasetType)
+}
+
+func (s *ServiceDatasetTemplate) GetAll(params filter.BaseParams) ([]domaindataset.DatasetTemplate, int64, error) {
+	return s.Repo.GetAll(params)
+}
+
+func (s *ServiceDatasetTemplate) Update(id string, req dto.DatasetTemplateUpdate, actorId string) (domaindataset.DatasetTemplate, error) {
+	tpl, err := s.Repo.GetByID(id)
+	if err != nil {
+		return domaindataset.DatasetTemplate{}, err
+	}
+
+	if req.SheetName != nil {
+		tpl.SheetName = strings.TrimSpace(*req.SheetName)
+	}
+	if req.HeaderRow != nil {
+		if *req.HeaderRow < 1 {
+			return domaindataset.DatasetTemplate{}, errors.New("header_row must be at least 1")
+		}
+		tpl.HeaderRow = *req.HeaderRow
+	}
+	if req.Columns != nil {
+		columns, err := validateTemplateColumns(tpl.DatasetType, req.Columns)
+		if err != nil {
+			return domaindataset.DatasetTemplate{}, err
+		}
+		tpl.Columns = columns
+	}
+	if req.Active != nil {
+		tpl.Active = *req.Active
+	}
+
+	tpl.UpdatedAt = time.Now()
+	tpl.UpdatedBy = actorId
+
+	if err := s.Repo.Update(tpl); err != nil {
+		return domaindataset.DatasetTemplate{}, err
+	}
+
+	return tpl, nil
+}
+
+func (s *ServiceDatasetTemplate) Delete(id string) error {
+	if _, err := s.Repo.GetByID(id); err != nil {
+		return err
+	}
+	return s.Repo.Delete(id)
+}
+
+// BuildUploadTemplate generates an XLSX upload template for a dataset type, following its effective template.
+// With req.Prefill the data rows list the active persons instead of example values.
+func (s *ServiceDatasetTemplate) BuildUploadTemplate(datasetType string, req dto.DatasetTemplateDownloadRequest) (string, []byte, error) {
+	tpl, err := resolveTemplate(s.Repo, datasetType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	layout := datasetLayouts[tpl.DatasetType]
+	header := make([]string, len(layout))
+	for i, col := range layout {
+		header[i] = col.Header
+		if aliases := tpl.Columns[col.Field]; len(aliases) > 0 {
+			header[i] = aliases[0]
+		}
+	}
+
+	var dataRows [][]string
+	if req.Prefill {
+		filters := map[string]interface{}{"active": true}
+		if req.Role != "" {
+			filters["role"] = req.Role
+		}
+		if req.DealerCode != "" {
+			filters["dealer_code"] = req.DealerCode
+		}
+		persons, _, err := s.PersonRepo.GetAll(filter.BaseParams{
+			Filters:        filters,
+			OrderBy:        "honda_id",
+			OrderDirection: "asc",
+			Limit:          templateMaxPersons,
+		})
+		if err != nil {
+			return "", nil, err
+		}
+
+		for i, person := range persons {
+			row := make([]string, len(layout))
+			for j, col := range layout {
+				switch col.Field {
+				case layoutNo:
+					row[j] = strconv.Itoa(i + 1)
+				case fieldHondaId:
+					row[j] = person.HondaId
+				case layoutName:
+					row[j] = person.Name
+				case layoutRole:
+					row[j] = person.Role
+				case layoutJobTitle:
+					if person.JobTitle != nil {
+						row[j] = *person.JobTitle
+					}
+				case fieldDealerCode:
+					if person.DealerCode != nil {
+						row[j] = *person.DealerCode
+					}
+				}
+			}
+			dataRows = append(dataRows, row)
+		}
+	} else {
+		for n := 0; n < 2; n++ {
+			row := make([]string, len(layout))
+			for j, col := range layout {
+				row[j] = templateExampleValues[col.Field][n]
+			}
+			dataRows = append(dataRows, row)
+		}
+	}
+
+	// Blank rows keep the header on the configured header row.
+	rows := make([][]string, tpl.HeaderRow-1, tpl.HeaderRow+len(dataRows))
+	rows = append(rows, header)
+	rows = append(rows, dataRows...)
+
+	lastRow := tpl.HeaderRow + templateDropdownRows
+	if len(rows) > lastRow {
+		lastRow = len(rows)
+	}
+	var dropdowns []file.ExcelDropdown
+	for i, col := range layout {
+		if values, ok := templateDropdownFields[col.Field]; ok {
+			dropdowns = append(dropdowns, file.ExcelDropdown{Column: i, Values: values, FromRow: tpl.HeaderRow + 1, ToRow: lastRow})
+		}
+	}
+
+	sheetName := tpl.SheetName
+	if sheetName == "" {
+		sheetName = tpl.DatasetType
+	}
+	content, err := file.WriteExcelRowsWithDropdowns(sheetName, rows, dropdowns)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s_template.xlsx", strings.ToLower(tpl.DatasetType)), content, nil
+}
+
+var _ interfacedataset.ServiceDatasetTemplateInterface = (*ServiceDatasetTemplate)(nil)
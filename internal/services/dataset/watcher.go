@@ -0,0 +1,222 @@
+package servicedataset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/utils"
+)
+
+// Subfolders of the watched directory that receive the files once they are handled.
+const (
+	watchDoneDir   = "done"
+	watchFailedDir = "failed"
+)
+
+// watchFilePattern matches dropped file names such as LOGIN_APPLE_2026-10-16.xlsx, SALES_FLP_2026-10.csv
+// or POINT_APPLE_2026-10-12_WEEKLY.xlsx: the dataset type, the period date (or month) and an optional frequency.
+var watchFilePattern = regexp.MustCompile(`(?i)^([A-Z_]+?)[ _-](\d{4}-\d{2}(?:-\d{2})?)(?:[ _-](DAILY|WEEKLY|MONTHLY|QUARTERLY|YEARLY))?\.(xlsx|xlsm|csv|json)$`)
+
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// DirectoryWatcher imports the dataset files dropped in a local directory. The directory is polled rather
+// than watched for events, as shared folders are often network mounts that do not report changes.
+// A file is only picked up once its size and modification time are unchanged between two scans, so that
+// files still being copied are left alone. Imported files are moved to the done subfolder, rejected ones
+// to the failed subfolder together with a .error.txt file holding the reason.
+type DirectoryWatcher struct {
+	Service   interfacedataset.ServiceDatasetInterface
+	Sources   interfacedataset.DatasetSourceStoreInterface
+	Processor interfacedataset.DatasetProcessorInterface
+
+	dir      string
+	interval time.Duration
+	actorId  string
+	seen     map[string]watchedFile
+	mu       sync.Mutex
+	start    sync.Once
+}
+
+func NewDirectoryWatcher(service interfacedataset.ServiceDatasetInterface, sources interfacedataset.DatasetSourceStoreInterface, processor interfacedataset.DatasetProcessorInterface, dir string, interval time.Duration, actorId string) *DirectoryWatcher {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if actorId == "" {
+		actorId = utils.DatasetSystemActor
+	}
+	return &DirectoryWatcher{
+		Service:   service,
+		Sources:   sources,
+		Processor: processor,
+		dir:       dir,
+		interval:  interval,
+		actorId:   actorId,
+		seen:      make(map[string]watchedFile),
+	}
+}
+
+// Start polls the directory in the background. It does nothing when no directory is configured,
+// and calling it more than once has no effect.
+func (w *DirectoryWatcher) Start() {
+	if w.dir == "" {
+		return
+	}
+	w.start.Do(func() {
+		for _, sub := range []string{watchDoneDir, watchFailedDir} {
+			if err := os.MkdirAll(filepath.Join(w.dir, sub), 0o755); err != nil {
+				logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetWatcher] cannot prepare %s; Error: %+v", w.dir, err))
+				return
+			}
+		}
+		go func() {
+			ticker := time.NewTicker(w.interval)
+			defer ticker.Stop()
+			for {
+				w.Scan()
+				<-ticker.C
+			}
+		}()
+		logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("[DatasetWatcher] watching %s every %s", w.dir, w.interval))
+	})
+}
+
+// Scan handles the files of the directory that did not change since the previous scan.
+func (w *DirectoryWatcher) Scan() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetWatcher] ReadDir %s; Error: %+v", w.dir, err))
+		return
+	}
+
+	current := make(map[string]watchedFile, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || isPartialFile(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		state := watchedFile{size: info.Size(), modTime: info.ModTime()}
+		if prev, ok := w.seen[name]; !ok || prev != state {
+			current[name] = state
+			continue
+		}
+		w.ingest(name)
+	}
+	w.seen = current
+}
+
+// ingest imports one file as the system actor and moves it to the done or failed subfolder.
+func (w *DirectoryWatcher) ingest(name string) {
+	logPrefix := fmt.Sprintf("[DatasetWatcher][%s]", name)
+	path := filepath.Join(w.dir, name)
+
+	datasetId, err := w.importFile(path, name)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; import failed; Error: %+v", logPrefix, err))
+		w.moveTo(path, watchFailedDir, err)
+		return
+	}
+
+	logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("%s; imported as dataset %s", logPrefix, datasetId))
+	w.moveTo(path, watchDoneDir, nil)
+}
+
+// importFile registers the dataset described by the file name and processes it synchronously.
+// It returns the dataset id, which is also set when processing failed after registration.
+func (w *DirectoryWatcher) importFile(path, name string) (string, error) {
+	req, err := parseWatchFileName(name)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	ds, spooled, err := w.Service.Create(req.Type, req, f, name, "", w.actorId)
+	if err != nil {
+		return "", err
+	}
+	defer spooled.Close()
+
+	processed, err := importSpooled(w.Sources, w.Processor, ds, spooled, w.actorId)
+	return processed.Id, err
+}
+
+// moveTo moves a handled file to a subfolder, adding a timestamp when the name is taken.
+// For failed files the reason is written next to it.
+func (w *DirectoryWatcher) moveTo(path, sub string, reason error) {
+	name := filepath.Base(path)
+	target := filepath.Join(w.dir, sub, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		target = filepath.Join(w.dir, sub, fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102150405"), ext))
+	}
+	if err := os.Rename(path, target); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetWatcher][%s] cannot move to %s; Error: %+v", name, sub, err))
+		return
+	}
+	if reason != nil {
+		_ = os.WriteFile(target+".error.txt", []byte(reason.Error()+"\n"), 0o644)
+	}
+}
+
+// isPartialFile reports hidden, lock and in-transfer files, which are never picked up.
+func isPartialFile(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~$") {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".part", ".partial", ".tmp", ".crdownload":
+		return true
+	}
+	return false
+}
+
+// parseWatchFileName infers the dataset type, period and frequency of a dropped file from its name.
+// A month without a day means a MONTHLY dataset anchored on the first day of the month.
+func parseWatchFileName(name string) (dto.DatasetUploadRequest, error) {
+	m := watchFilePattern.FindStringSubmatch(name)
+	if m == nil {
+		return dto.DatasetUploadRequest{}, errors.New("file name does not match <TYPE>_<YYYY-MM-DD>[_<FREQUENCY>].<xlsx|csv|json>")
+	}
+
+	req := dto.DatasetUploadRequest{
+		Type:            strings.ToUpper(m[1]),
+		PeriodDate:      m[2],
+		PeriodFrequency: strings.ToUpper(m[3]),
+	}
+	if !utils.AllowedDatasetTypes[req.Type] {
+		return dto.DatasetUploadRequest{}, fmt.Errorf("unknown dataset type in file name: %s", req.Type)
+	}
+	if len(req.PeriodDate) == len("2006-01") {
+		req.PeriodDate += "-01"
+		if req.PeriodFrequency == "" {
+			req.PeriodFrequency = utils.PeriodMonthly
+		}
+	}
+	return req, nil
+}
+
+var _ interfacedataset.DatasetWatcherInterface = (*DirectoryWatcher)(nil)
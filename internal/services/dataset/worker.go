@@ -0,0 +1,155 @@
+package servicedataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/utils"
+)
+
+type datasetJob struct {
+	DatasetId string
+	ActorId   string
+}
+
+// WorkerPool runs dataset imports in the background with a bounded number of workers.
+// Uploaded files are kept in the source store so that jobs interrupted by a restart can be resumed
+// and finished datasets can be processed again.
+type WorkerPool struct {
+	Processor interfacedataset.DatasetProcessorInterface
+	Repo      interfacedataset.RepoDatasetInterface
+	Sources   interfacedataset.DatasetSourceStoreInterface
+
+	workers int
+	jobs    chan datasetJob
+	start   sync.Once
+}
+
+func NewWorkerPool(processor interfacedataset.DatasetProcessorInterface, repo interfacedataset.RepoDatasetInterface, sources interfacedataset.DatasetSourceStoreInterface, workers, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &WorkerPool{
+		Processor: processor,
+		Repo:      repo,
+		Sources:   sources,
+		workers:   workers,
+		jobs:      make(chan datasetJob, queueSize),
+	}
+}
+
+// Start launches the workers. Calling it more than once has no effect.
+func (w *WorkerPool) Start() {
+	w.start.Do(func() {
+		for i := 1; i <= w.workers; i++ {
+			go w.run(i)
+		}
+		logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("[DatasetWorkerPool] started %d worker(s)", w.workers))
+	})
+}
+
+// Enqueue stores the uploaded file and schedules the dataset for processing.
+func (w *WorkerPool) Enqueue(ds domaindataset.DashboardDataset, upload io.Reader, size int64, actorId string) error {
+	if err := w.Sources.Save(ds, upload, size); err != nil {
+		return fmt.Errorf("failed to store dataset file: %w", err)
+	}
+	return w.Requeue(ds, actorId)
+}
+
+// Requeue schedules a dataset whose original file is already in the source store.
+func (w *WorkerPool) Requeue(ds domaindataset.DashboardDataset, actorId string) error {
+	select {
+	case w.jobs <- datasetJob{DatasetId: ds.Id, ActorId: actorId}:
+		return nil
+	default:
+		errMsg := "dataset queue is full, please reprocess the dataset later"
+		w.fail(ds, actorId, errMsg)
+		return errors.New(errMsg)
+	}
+}
+
+// fail marks a dataset that could not be processed as FAILED.
+func (w *WorkerPool) fail(ds domaindataset.DashboardDataset, actorId, errMsg string) {
+	history, err := changeStatus(&ds, utils.DatasetStatusFailed, actorId, errMsg)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetWorkerPool][%s] %s; Error: %+v", ds.Id, errMsg, err))
+		return
+	}
+	ds.ErrorMessage = &errMsg
+	if err := w.Repo.UpdateWithHistory(ds, history); err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetWorkerPool][%s] Repo.UpdateWithHistory; Error: %+v", ds.Id, err))
+	}
+}
+
+// ResumePending re-schedules datasets left in UPLOADED or PROCESSING state, e.g. after a crash.
+func (w *WorkerPool) ResumePending() {
+	pending, err := w.Repo.GetByStatuses([]string{utils.DatasetStatusUploaded, utils.DatasetStatusProcessing})
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[DatasetWorkerPool][ResumePending] Repo.GetByStatuses; Error: %+v", err))
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("[DatasetWorkerPool][ResumePending] resuming %d dataset(s)", len(pending)))
+	go func() {
+		for _, ds := range pending {
+			actor := ds.UpdatedBy
+			if actor == "" {
+				actor = ds.UploadedBy
+			}
+			w.jobs <- datasetJob{DatasetId: ds.Id, ActorId: actor}
+		}
+	}()
+}
+
+func (w *WorkerPool) run(workerId int) {
+	for job := range w.jobs {
+		w.handle(workerId, job)
+	}
+}
+
+func (w *WorkerPool) handle(workerId int, job datasetJob) {
+	logPrefix := fmt.Sprintf("[DatasetWorkerPool][Worker-%d][%s]", workerId, job.DatasetId)
+
+	ds, err := w.Repo.GetByID(job.DatasetId)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Repo.GetByID; Error: %+v", logPrefix, err))
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; panic: %v", logPrefix, r))
+			w.fail(ds, job.ActorId, fmt.Sprintf("processing aborted: %v", r))
+		}
+	}()
+
+	source, err := w.Sources.Open(ds.Id)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Sources.Open; Error: %+v", logPrefix, err))
+		w.fail(ds, job.ActorId, "source file is no longer available, please upload again")
+		return
+	}
+	defer source.Close()
+
+	logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("%s; processing %s dataset", logPrefix, ds.Type))
+	processed, err := w.Processor.ProcessStream(&ds, source, job.ActorId)
+	if err != nil {
+		logger.WriteLog(logger.LogLevelError, fmt.Sprintf("%s; Processor.ProcessStream; Error: %+v", logPrefix, err))
+		return
+	}
+
+	logger.WriteLog(logger.LogLevelInfo, fmt.Sprintf("%s; done, %d row(s) imported", logPrefix, processed.ProcessedRows))
+}
+
+var _ interfacedataset.DatasetWorkerInterface = (*WorkerPool)(nil)
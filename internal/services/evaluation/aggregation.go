@@ -0,0 +1,192 @@
+package serviceevaluation
+
+import (
+	"fmt"
+	"time"
+
+	"teamleader-management/utils"
+)
+
+// How the rows of a dataset type are combined over an evaluation month
+const (
+	aggregateSum     = "SUM"     // every row counts, each upload holds the amount of its own period
+	aggregateAverage = "AVERAGE" // rows are averaged, e.g. quiz scores
+	aggregateLatest  = "LATEST"  // uploads are snapshots, only the most recent one counts
+)
+
+// cumulativeAggregation applies to metrics reported as amounts: daily and monthly uploads are summed,
+// while weekly, quarterly and yearly uploads are running totals of which only the latest counts.
+var cumulativeAggregation = map[string]string{
+	utils.PeriodDaily:     aggregateSum,
+	utils.PeriodWeekly:    aggregateLatest,
+	utils.PeriodMonthly:   aggregateSum,
+	utils.PeriodQuarterly: aggregateLatest,
+	utils.PeriodYearly:    aggregateLatest,
+}
+
+// datasetAggregation lists the aggregation of each dataset type per upload frequency.
+// LOGIN_APPLE is not listed: its daily rows become a compliance percentage, see getAppleLoginCompliance.
+var datasetAggregation = map[string]map[string]string{
+	utils.DatasetSalesFLP:      cumulativeAggregation,
+	utils.DatasetPointApple:    cumulativeAggregation,
+	utils.DatasetPointMyHero:   cumulativeAggregation,
+	utils.DatasetTotalProspect: cumulativeAggregation,
+	utils.DatasetQuiz: {
+		utils.PeriodDaily:     aggregateAverage,
+		utils.PeriodWeekly:    aggregateAverage,
+		utils.PeriodMonthly:   aggregateAverage,
+		utils.PeriodQuarterly: aggregateLatest,
+		utils.PeriodYearly:    aggregateLatest,
+	},
+}
+
+// defaultWorkingDays are the days a TL is expected to log in to Apple.
+var defaultWorkingDays = map[time.Weekday]bool{
+	time.Monday:    true,
+	time.Tuesday:   true,
+	time.Wednesday: true,
+	time.Thursday:  true,
+	time.Friday:    true,
+}
+
+// datasetMetric is the evaluation metric fed by a dataset type and the metric table column it is read from.
+type datasetMetric struct {
+	key    string
+	table  string
+	column string
+}
+
+// datasetMetrics lists the metric of each dataset type, see GetMetricsForPerson.
+var datasetMetrics = map[string]datasetMetric{
+	utils.DatasetSalesFLP:      {key: "sales_flp", table: utils.DatasetMetricTables[utils.DatasetSalesFLP], column: "flp_amount"},
+	utils.DatasetQuiz:          {key: "quiz_score", table: utils.DatasetMetricTables[utils.DatasetQuiz], column: "score"},
+	utils.DatasetLoginApple:    {key: "apple_logins", table: utils.DatasetMetricTables[utils.DatasetLoginApple]},
+	utils.DatasetPointApple:    {key: "apple_points", table: utils.DatasetMetricTables[utils.DatasetPointApple], column: "points"},
+	utils.DatasetPointMyHero:   {key: "myhero_points", table: utils.DatasetMetricTables[utils.DatasetPointMyHero], column: "points"},
+	utils.DatasetTotalProspect: {key: "total_prospects", table: utils.DatasetMetricTables[utils.DatasetTotalProspect], column: "prospect_count"},
+}
+
+func aggregationRule(datasetType, frequency string) string {
+	if rule, ok := datasetAggregation[datasetType][frequency]; ok {
+		return rule
+	}
+	return aggregateSum
+}
+
+// metricRow is a row of a metric table counted for a month, with the dataset it was imported from.
+type metricRow struct {
+	DatasetId       string
+	PeriodFrequency string
+	PeriodDate      time.Time
+	UploadedAt      time.Time
+	Value           *float64
+}
+
+// aggregateDatasetMetric combines column of a metric table over the DONE datasets of a month.
+func (m *MetricAggregator) aggregateDatasetMetric(datasetType, table, column, personId string, periodMonth int, periodYear int) (float64, error) {
+	rows, err := m.datasetMetricRows(datasetType, table, column, personId, periodMonth, periodYear)
+	if err != nil {
+		return 0, err
+	}
+	return aggregateRows(datasetType, rows), nil
+}
+
+// datasetMetricRows returns the rows of a person in a metric table imported by the DONE datasets of a month.
+func (m *MetricAggregator) datasetMetricRows(datasetType, table, column, personId string, periodMonth int, periodYear int) ([]metricRow, error) {
+	var rows []metricRow
+	err := m.DB.Table(table+" mt").
+		Select(fmt.Sprintf("dd.id AS dataset_id, dd.period_frequency, dd.period_date, dd.uploaded_at, mt.%s AS value", column)).
+		Joins("INNER JOIN dashboard_datasets dd ON mt.dataset_id = dd.id").
+		Where("mt.person_id = ? AND dd.type = ? AND dd.period_month = ? AND dd.period_year = ?", personId, datasetType, periodMonth, periodYear).
+		Where(importedRowsCondition("mt"), utils.DatasetStatusDone).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// aggregateRows combines the rows of a dataset type with its aggregation rule. When a month holds uploads
+// of several frequencies, the frequency of the most recent upload wins, so that daily rows and weekly
+// snapshots of the same metric are never added up. Rows without a value are not counted.
+func aggregateRows(datasetType string, rows []metricRow) float64 {
+	if len(rows) == 0 {
+		return 0
+	}
+	latest := rows[0]
+	for _, r := range rows[1:] {
+		if r.PeriodDate.After(latest.PeriodDate) || (r.PeriodDate.Equal(latest.PeriodDate) && r.UploadedAt.After(latest.UploadedAt)) {
+			latest = r
+		}
+	}
+
+	rule := aggregationRule(datasetType, latest.PeriodFrequency)
+	sum, count := 0.0, 0
+	for _, r := range rows {
+		if r.PeriodFrequency != latest.PeriodFrequency || r.Value == nil {
+			continue
+		}
+		if rule == aggregateLatest && r.DatasetId != latest.DatasetId {
+			continue
+		}
+		sum += *r.Value
+		count++
+	}
+	if rule == aggregateAverage && count > 0 {
+		return sum / float64(count)
+	}
+	return sum
+}
+
+// datasetMetricValue returns the current value of the metric fed by a dataset type for a month.
+func (m *MetricAggregator) datasetMetricValue(datasetType, personId string, periodMonth int, periodYear int) (float64, error) {
+	if datasetType == utils.DatasetLoginApple {
+		startDate, endDate := monthBounds(periodMonth, periodYear)
+		return m.getAppleLoginCompliance(personId, startDate, endDate)
+	}
+	metric := datasetMetrics[datasetType]
+	return m.aggregateDatasetMetric(datasetType, metric.table, metric.column, personId, periodMonth, periodYear)
+}
+
+// countWorkingDays returns the working days between startDate and endDate, not counting the days after today.
+func (m *MetricAggregator) countWorkingDays(startDate, endDate time.Time) int {
+	if now := time.Now(); now.Before(endDate) {
+		endDate = now
+	}
+	workingDays := 0
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		if m.WorkingDays[d.Weekday()] {
+			workingDays++
+		}
+	}
+	return workingDays
+}
+
+// getAppleLoginCompliance returns the percentage of working days between startDate and endDate on which
+// the person did both the morning and the evening Apple login. Days after today are not counted yet.
+func (m *MetricAggregator) getAppleLoginCompliance(personId string, startDate, endDate time.Time) (float64, error) {
+	workingDays := m.countWorkingDays(startDate, endDate)
+	if workingDays == 0 {
+		return 0, nil
+	}
+
+	var loginDates []time.Time
+	err := m.DB.Table("apple_logins al").
+		Distinct("al.login_date").
+		Joins("INNER JOIN dashboard_datasets dd ON al.dataset_id = dd.id").
+		Where("al.person_id = ? AND al.login_date >= ? AND al.login_date <= ?", personId, startDate, endDate).
+		Where("al.morning_done = ? AND al.evening_done = ?", true, true).
+		Where(importedRowsCondition("al"), utils.DatasetStatusDone).
+		Pluck("al.login_date", &loginDates).Error
+	if err != nil {
+		return 0, err
+	}
+
+	compliantDays := 0
+	for _, d := range loginDates {
+		if m.WorkingDays[d.Weekday()] {
+			compliantDays++
+		}
+	}
+	return float64(compliantDays) / float64(workingDays) * 100, nil
+}
@@ -0,0 +1,54 @@
+package serviceevaluation
+
+import (
+	"testing"
+	"time"
+
+	"teamleader-management/utils"
+)
+
+func TestAggregateRows(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC) }
+	value := func(v float64) *float64 { return &v }
+	row := func(datasetId, frequency string, d int, v *float64) metricRow {
+		return metricRow{DatasetId: datasetId, PeriodFrequency: frequency, PeriodDate: day(d), UploadedAt: day(d), Value: v}
+	}
+
+	tests := []struct {
+		name        string
+		datasetType string
+		rows        []metricRow
+		want        float64
+	}{
+		{"no rows", utils.DatasetSalesFLP, nil, 0},
+		{"daily amounts are summed", utils.DatasetSalesFLP, []metricRow{
+			row("a", utils.PeriodDaily, 1, value(10)),
+			row("b", utils.PeriodDaily, 2, value(15)),
+		}, 25},
+		{"weekly snapshots keep the latest", utils.DatasetSalesFLP, []metricRow{
+			row("a", utils.PeriodWeekly, 4, value(10)),
+			row("b", utils.PeriodWeekly, 11, value(30)),
+		}, 30},
+		{"quiz scores are averaged", utils.DatasetQuiz, []metricRow{
+			row("a", utils.PeriodDaily, 1, value(80)),
+			row("b", utils.PeriodDaily, 2, value(60)),
+			row("c", utils.PeriodDaily, 3, value(70)),
+		}, 70},
+		{"rows without a value are not averaged", utils.DatasetQuiz, []metricRow{
+			row("a", utils.PeriodDaily, 1, value(80)),
+			row("b", utils.PeriodDaily, 2, nil),
+		}, 80},
+		{"the frequency of the latest upload wins", utils.DatasetSalesFLP, []metricRow{
+			row("a", utils.PeriodDaily, 1, value(10)),
+			row("b", utils.PeriodDaily, 2, value(15)),
+			row("c", utils.PeriodWeekly, 4, value(40)),
+		}, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregateRows(tt.datasetType, tt.rows); got != tt.want {
+				t.Errorf("aggregateRows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
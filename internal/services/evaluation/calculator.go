@@ -2,9 +2,11 @@ package serviceevaluation
 
 import (
 	"fmt"
+	"strings"
 
 	domainevaluation "teamleader-management/internal/domain/evaluation"
 	domainkpiitem "teamleader-management/internal/domain/kpiitem"
+	domainperson "teamleader-management/internal/domain/person"
 	"teamleader-management/utils"
 
 	"gorm.io/gorm"
@@ -14,12 +16,14 @@ import (
 type EvaluationCalculator struct {
 	DB               *gorm.DB
 	MetricAggregator *MetricAggregator
+	Scoring          *ScoringRegistry
 }
 
 func NewEvaluationCalculator(db *gorm.DB) *EvaluationCalculator {
 	return &EvaluationCalculator{
 		DB:               db,
 		MetricAggregator: NewMetricAggregator(db),
+		Scoring:          NewScoringRegistry(),
 	}
 }
 
@@ -31,14 +35,10 @@ type CalculationResult struct {
 
 // Calculate performs the evaluation calculation for a person in a period
 func (c *EvaluationCalculator) Calculate(personId string, periodMonth int, periodYear int) (*CalculationResult, error) {
-	// 1. Get all KPI items for TL
-	kpiItems, err := c.getKPIItems()
+	// 1. Get all KPI items for TL, each bound to a known metric
+	kpiItems, err := c.getBoundKPIItems()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get KPI items: %w", err)
-	}
-
-	if len(kpiItems) == 0 {
-		return nil, fmt.Errorf("no KPI items found for evaluation")
+		return nil, err
 	}
 
 	// 2. Get metrics for the person
@@ -47,12 +47,21 @@ func (c *EvaluationCalculator) Calculate(personId string, periodMonth int, perio
 		return nil, fmt.Errorf("failed to aggregate metrics: %w", err)
 	}
 
-	// 3. Calculate score for each KPI
+	// 3. Resolve the target of each KPI for the person
+	targets, err := c.getTargetResolver(personId, periodMonth, periodYear)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets: %w", err)
+	}
+
+	// 4. Calculate score for each KPI
 	var details []domainevaluation.EvaluationDetail
 	var totalScore float64
 
 	for _, kpi := range kpiItems {
-		detail, score := c.calculateKPIScore(kpi, metrics)
+		detail, score, err := c.calculateKPIScore(kpi, targets.resolve(kpi), metrics)
+		if err != nil {
+			return nil, err
+		}
 		details = append(details, detail)
 		totalScore += score
 	}
@@ -64,90 +73,126 @@ func (c *EvaluationCalculator) Calculate(personId string, periodMonth int, perio
 }
 
 // calculateKPIScore calculates score for a single KPI item
-func (c *EvaluationCalculator) calculateKPIScore(kpi domainkpiitem.KPIItem, metrics map[string]*MetricValue) (domainevaluation.EvaluationDetail, float64) {
+func (c *EvaluationCalculator) calculateKPIScore(kpi domainkpiitem.KPIItem, target resolvedTarget, metrics map[string]*MetricValue) (domainevaluation.EvaluationDetail, float64, error) {
 	detail := domainevaluation.EvaluationDetail{
-		Id:        utils.CreateUUID(),
-		KpiItemId: kpi.Id,
-		Score:     0,
+		Id:           utils.CreateUUID(),
+		KpiItemId:    kpi.Id,
+		TargetValue:  target.Value,
+		TargetSource: target.Source,
+		Score:        0,
 	}
 
-	// Map KPI names to metric keys
-	metricKey := c.getMetricKey(kpi.Name)
-	metric, exists := metrics[metricKey]
-
+	metric, exists := metrics[*kpi.MetricKey]
 	if !exists {
-		// No data for this KPI, score is 0
-		return detail, 0
+		return detail, 0, fmt.Errorf("metric %s of KPI item %q was not computed", *kpi.MetricKey, kpi.Name)
 	}
 
 	actualValue := metric.Value
 	detail.ActualValue = &actualValue
 
-	// Calculate score based on KPI type
-	score := c.calculateScore(kpi, actualValue)
+	// Calculate score with the scoring strategy of the KPI
+	score, err := c.calculateScore(kpi, target.Value, actualValue)
+	if err != nil {
+		return detail, 0, err
+	}
 
 	// Calculate achievement ratio if target is set
-	if kpi.TargetValue != nil && *kpi.TargetValue > 0 {
-		achievementRatio := actualValue / *kpi.TargetValue * 100
+	if target.Value != nil && *target.Value > 0 {
+		achievementRatio := actualValue / *target.Value * 100
 		detail.AchievementRatio = &achievementRatio
 	}
 
 	detail.Score = score
-	return detail, score
+	return detail, score, nil
 }
 
-// calculateScore determines the score based on achievement
-func (c *EvaluationCalculator) calculateScore(kpi domainkpiitem.KPIItem, actualValue float64) float64 {
-	weight := kpi.Weight
+// calculateScore scores the actual value of a KPI against its target with the scoring strategy of the KPI item
+func (c *EvaluationCalculator) calculateScore(kpi domainkpiitem.KPIItem, target *float64, actualValue float64) (float64, error) {
+	return c.Scoring.Score(kpi, ScoringInput{
+		Actual: actualValue,
+		Target: target,
+		Weight: kpi.Weight,
+	})
+}
 
-	// If no target is set, use simple presence scoring
-	if kpi.TargetValue == nil || *kpi.TargetValue == 0 {
-		// For non-target metrics, give full score if value > 0
-		if actualValue > 0 {
-			return weight
-		}
-		return 0
-	}
+// resolvedTarget is the target a KPI is scored against for a person, with where it came from.
+type resolvedTarget struct {
+	Value  *float64
+	Source string // utils.TargetSourcePerson, utils.TargetSourceDealer or utils.TargetSourceKPI
+}
 
-	// Calculate achievement ratio
-	achievementRatio := actualValue / *kpi.TargetValue
+// targetResolver holds the person and dealer targets of one person and period, by KPI item id.
+type targetResolver struct {
+	person map[string]float64
+	dealer map[string]float64
+}
 
-	// Cap at 100% (don't give bonus for exceeding target)
-	if achievementRatio > 1.0 {
-		achievementRatio = 1.0
+// getTargetResolver loads the targets set for a person in a period and the defaults of their dealer.
+func (c *EvaluationCalculator) getTargetResolver(personId string, periodMonth int, periodYear int) (targetResolver, error) {
+	t := targetResolver{person: make(map[string]float64), dealer: make(map[string]float64)}
+
+	var personTargets []domainkpiitem.PersonKPITarget
+	if err := c.DB.Where("person_id = ? AND period_month = ? AND period_year = ?", personId, periodMonth, periodYear).
+		Find(&personTargets).Error; err != nil {
+		return t, err
+	}
+	for _, pt := range personTargets {
+		t.person[pt.KPIItemId] = pt.TargetValue
 	}
 
-	// Score is proportional to achievement
-	score := weight * achievementRatio
+	var person domainperson.Person
+	if err := c.DB.Where("id = ?", personId).First(&person).Error; err != nil {
+		return t, err
+	}
+	if person.DealerCode == nil || *person.DealerCode == "" {
+		return t, nil
+	}
+	var dealerTargets []domainkpiitem.DealerKPITarget
+	if err := c.DB.Where("dealer_code = ?", *person.DealerCode).Find(&dealerTargets).Error; err != nil {
+		return t, err
+	}
+	for _, dt := range dealerTargets {
+		t.dealer[dt.KPIItemId] = dt.TargetValue
+	}
+	return t, nil
+}
 
-	return score
+// resolve returns the target of a KPI: the person's target of the period, else the default of their
+// dealer, else the target of the KPI item (which may be unset).
+func (t targetResolver) resolve(kpi domainkpiitem.KPIItem) resolvedTarget {
+	if v, ok := t.person[kpi.Id]; ok {
+		return resolvedTarget{Value: &v, Source: utils.TargetSourcePerson}
+	}
+	if v, ok := t.dealer[kpi.Id]; ok {
+		return resolvedTarget{Value: &v, Source: utils.TargetSourceDealer}
+	}
+	return resolvedTarget{Value: kpi.TargetValue, Source: utils.TargetSourceKPI}
 }
 
-// getMetricKey maps KPI name to metric key
-func (c *EvaluationCalculator) getMetricKey(kpiName string) string {
-	// Map KPI item names to metric keys
-	mapping := map[string]string{
-		"Quantity Activity":        "quantity_activity",
-		"Sales FLP":                "sales_flp",
-		"Disiplin & Kehadiran Tim": "attendance",
-		"Sesi Coaching":            "coaching_sessions",
-		"Sesi Briefing":            "briefing_sessions",
-		"Jumlah Tim":               "team_size",
-		"Kuis":                     "quiz_score",
-		"Partisipasi Training":     "training_participation",
-		"Login Apple":              "apple_logins",
-		"Point Apple":              "apple_points",
-		"Point my Hero":            "myhero_points",
-		"Jumlah Prospek":           "total_prospects",
-		"Ratio Prospek":            "prospect_ratio",
-	}
-
-	if key, found := mapping[kpiName]; found {
-		return key
-	}
-
-	// Fallback: use lowercase with underscores
-	return kpiName
+// getBoundKPIItems returns the KPI items for TL evaluation after checking that each one is bound to a metric
+// of the registry. An unbound KPI is an error instead of a silent zero score.
+func (c *EvaluationCalculator) getBoundKPIItems() ([]domainkpiitem.KPIItem, error) {
+	kpiItems, err := c.getKPIItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get KPI items: %w", err)
+	}
+	if len(kpiItems) == 0 {
+		return nil, fmt.Errorf("no KPI items found for evaluation")
+	}
+
+	var unbound []string
+	for _, kpi := range kpiItems {
+		switch {
+		case kpi.MetricKey == nil || *kpi.MetricKey == "":
+			unbound = append(unbound, fmt.Sprintf("%q has no metric_key", kpi.Name))
+		case !c.MetricAggregator.HasMetric(*kpi.MetricKey):
+			unbound = append(unbound, fmt.Sprintf("%q has unknown metric_key %q", kpi.Name, *kpi.MetricKey))
+		}
+	}
+	if len(unbound) > 0 {
+		return nil, fmt.Errorf("KPI items are not bound to a metric: %s", strings.Join(unbound, "; "))
+	}
+	return kpiItems, nil
 }
 
 // getKPIItems retrieves all KPI items for TL evaluation
@@ -0,0 +1,197 @@
+package serviceevaluation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	domainevaluation "teamleader-management/internal/domain/evaluation"
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/logger"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+// GetCoverage returns, for each month of the period and each dataset type, whether a DONE dataset exists
+// and how many persons it covers. A periodMonth of zero returns the twelve months of the year.
+// Each month is checked against its own required datasets, or the default ones when it has none.
+func (s *ServiceEvaluation) GetCoverage(periodMonth int, periodYear int) (dto.DatasetCoverageResponse, error) {
+	coverage, err := s.DatasetRepo.GetCoverage(periodMonth, periodYear)
+	if err != nil {
+		return dto.DatasetCoverageResponse{}, fmt.Errorf("failed to get dataset coverage: %w", err)
+	}
+	periods, err := s.Repo.GetPeriodsByYear(periodYear)
+	if err != nil {
+		return dto.DatasetCoverageResponse{}, fmt.Errorf("failed to get evaluation periods: %w", err)
+	}
+	periodByMonth := make(map[int]domainevaluation.EvaluationPeriod, len(periods))
+	for _, p := range periods {
+		periodByMonth[p.PeriodMonth] = p
+	}
+
+	months := []int{periodMonth}
+	if periodMonth == 0 {
+		months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	}
+
+	types := make([]string, 0, len(utils.AllowedDatasetTypes))
+	for t := range utils.AllowedDatasetTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	res := dto.DatasetCoverageResponse{
+		Mode:             s.CoverageMode,
+		RequiredDatasets: s.RequiredDatasets,
+		Periods:          make([]dto.DatasetCoveragePeriod, 0, len(months)),
+	}
+	for _, month := range months {
+		requiredDatasets, source := s.requiredDatasets(periodByMonth[month])
+		required := make(map[string]bool, len(requiredDatasets))
+		for _, t := range requiredDatasets {
+			required[t] = true
+		}
+		period := dto.DatasetCoveragePeriod{
+			PeriodMonth:       month,
+			PeriodYear:        periodYear,
+			RequiredDatasets:  requiredDatasets,
+			RequirementSource: source,
+			Complete:          true,
+			Missing:           []string{},
+			Datasets:          make([]dto.DatasetCoverageEntry, 0, len(types)),
+		}
+		for _, t := range types {
+			entry := dto.DatasetCoverageEntry{Type: t, Required: required[t], DatasetIds: []string{}}
+			for _, c := range coverage {
+				if c.Type != t || c.PeriodMonth != month {
+					continue
+				}
+				lastUploadedAt := c.LastUploadedAt
+				entry.Available = true
+				entry.DatasetIds = c.DatasetIds
+				entry.PersonsCovered = c.PersonsCovered
+				entry.LastUploadedAt = &lastUploadedAt
+			}
+			if entry.Required && !entry.Available {
+				period.Complete = false
+				period.Missing = append(period.Missing, t)
+			}
+			period.Datasets = append(period.Datasets, entry)
+		}
+		res.Periods = append(res.Periods, period)
+	}
+	return res, nil
+}
+
+// CheckCoverage runs the coverage gate of a period without calculating anything.
+func (s *ServiceEvaluation) CheckCoverage(periodMonth int, periodYear int, force bool) ([]string, error) {
+	return s.checkCoverage(periodMonth, periodYear, force)
+}
+
+// checkCoverage verifies that every required dataset was imported for the period. Missing datasets
+// are an error in strict mode unless force is set, and are otherwise returned as warnings.
+func (s *ServiceEvaluation) checkCoverage(periodMonth int, periodYear int, force bool) ([]string, error) {
+	coverage, err := s.GetCoverage(periodMonth, periodYear)
+	if err != nil {
+		return nil, err
+	}
+	period := coverage.Periods[0]
+	if len(period.RequiredDatasets) == 0 || period.Complete {
+		return nil, nil
+	}
+
+	coverageErr := &dto.EvaluationCoverageError{Period: period}
+	if s.CoverageMode == utils.EvaluationCoverageStrict && !force {
+		return nil, coverageErr
+	}
+	return []string{coverageErr.Error()}, nil
+}
+
+// requiredDatasets returns the dataset types required for a period and where they come from.
+func (s *ServiceEvaluation) requiredDatasets(period domainevaluation.EvaluationPeriod) ([]string, string) {
+	if period.RequiredDatasets != nil {
+		return []string(period.RequiredDatasets), utils.RequiredDatasetsPeriod
+	}
+	return s.RequiredDatasets, utils.RequiredDatasetsDefault
+}
+
+// SetRequiredDatasets sets the dataset types required before a period is evaluated, in place of the
+// default ones, and returns the coverage of the period against them.
+func (s *ServiceEvaluation) SetRequiredDatasets(req dto.EvaluationRequiredDatasets, actorId string) (dto.DatasetCoveragePeriod, error) {
+	types := make([]string, 0, len(req.DatasetTypes))
+	seen := make(map[string]bool)
+	for _, t := range req.DatasetTypes {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if !utils.AllowedDatasetTypes[t] {
+			return dto.DatasetCoveragePeriod{}, fmt.Errorf("invalid dataset type: %s", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	return s.updateRequiredDatasets(req.PeriodMonth, req.PeriodYear, types, actorId)
+}
+
+// ResetRequiredDatasets makes a period require the default dataset types again.
+func (s *ServiceEvaluation) ResetRequiredDatasets(periodMonth int, periodYear int, actorId string) (dto.DatasetCoveragePeriod, error) {
+	if _, err := s.Repo.GetPeriodByMonthYear(periodMonth, periodYear); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.coveragePeriod(periodMonth, periodYear)
+		}
+		return dto.DatasetCoveragePeriod{}, err
+	}
+	return s.updateRequiredDatasets(periodMonth, periodYear, nil, actorId)
+}
+
+func (s *ServiceEvaluation) updateRequiredDatasets(periodMonth int, periodYear int, types []string, actorId string) (dto.DatasetCoveragePeriod, error) {
+	period, err := s.Repo.GetOrCreatePeriod(periodMonth, periodYear)
+	if err != nil {
+		return dto.DatasetCoveragePeriod{}, err
+	}
+	now := time.Now()
+	period.RequiredDatasets = types
+	period.UpdatedAt = &now
+	period.UpdatedBy = &actorId
+	if err := s.Repo.UpdatePeriod(period); err != nil {
+		return dto.DatasetCoveragePeriod{}, err
+	}
+	return s.coveragePeriod(periodMonth, periodYear)
+}
+
+func (s *ServiceEvaluation) coveragePeriod(periodMonth int, periodYear int) (dto.DatasetCoveragePeriod, error) {
+	coverage, err := s.GetCoverage(periodMonth, periodYear)
+	if err != nil {
+		return dto.DatasetCoveragePeriod{}, err
+	}
+	return coverage.Periods[0], nil
+}
+
+// parseRequiredDatasets reads a comma separated list of dataset types; unknown types are logged and ignored.
+func parseRequiredDatasets(v string) []string {
+	ret := []string{}
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(v, ",") {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if !utils.AllowedDatasetTypes[t] {
+			logger.WriteLog(logger.LogLevelError, fmt.Sprintf("[ServiceEvaluation] ignoring unknown required dataset type: %s", t))
+			continue
+		}
+		seen[t] = true
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+func parseCoverageMode(v string) string {
+	if strings.ToLower(strings.TrimSpace(v)) == utils.EvaluationCoverageWarn {
+		return utils.EvaluationCoverageWarn
+	}
+	return utils.EvaluationCoverageStrict
+}
@@ -0,0 +1,174 @@
+package serviceevaluation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	domaindataset "teamleader-management/internal/domain/dataset"
+	domainkpiitem "teamleader-management/internal/domain/kpiitem"
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
+	"teamleader-management/utils"
+)
+
+// rowChange is the value of the row of a person in the replaced and in the replacing dataset, nil when
+// the dataset has no row for them.
+type rowChange struct {
+	old, new *float64
+}
+
+// EstimateDatasetChange estimates the evaluation of each TL in changes once newDs replaces oldDs. Only the KPI
+// fed by the dataset type is rescored: the rows of the TL counted today lose those of oldDs and those newDs
+// overwrites (same period date), gain the row of newDs and are aggregated again with the rule of the dataset
+// type, so averaged and snapshot metrics are recomputed rather than shifted. Apple login rows are days with
+// both logins and move the compliance by their difference over the working days of the month.
+func (s *ServiceEvaluation) EstimateDatasetChange(oldDs, newDs domaindataset.DashboardDataset, changes []dto.DatasetDiffEntry) ([]dto.DatasetScoreEstimate, error) {
+	estimates := []dto.DatasetScoreEstimate{}
+	metric, ok := datasetMetrics[newDs.Type]
+	if !ok {
+		return nil, fmt.Errorf("dataset type %s feeds no evaluation metric", newDs.Type)
+	}
+
+	byPerson := make(map[string]rowChange)
+	for _, c := range changes {
+		if c.PersonId == "" {
+			continue
+		}
+		byPerson[c.PersonId] = rowChange{
+			old: addValue(byPerson[c.PersonId].old, c.OldValue),
+			new: addValue(byPerson[c.PersonId].new, c.NewValue),
+		}
+	}
+	if len(byPerson) == 0 {
+		return estimates, nil
+	}
+
+	kpiItems, err := s.Calculator.getBoundKPIItems()
+	if err != nil {
+		return nil, err
+	}
+	var kpi *domainkpiitem.KPIItem
+	for i := range kpiItems {
+		if *kpiItems[i].MetricKey == metric.key {
+			kpi = &kpiItems[i]
+			break
+		}
+	}
+	if kpi == nil {
+		// No KPI is scored on this metric, the change cannot move any score.
+		return estimates, nil
+	}
+
+	personIds := make([]string, 0, len(byPerson))
+	for id := range byPerson {
+		personIds = append(personIds, id)
+	}
+	var persons []domainperson.Person
+	if err := s.DB.Where("id IN ? AND role = ?", personIds, utils.RoleTL).Find(&persons).Error; err != nil {
+		return nil, fmt.Errorf("failed to get team leaders: %w", err)
+	}
+	sort.Slice(persons, func(i, j int) bool { return persons[i].Name < persons[j].Name })
+
+	periodMonth, periodYear := newDs.PeriodMonth, newDs.PeriodYear
+	for _, p := range persons {
+		current, err := s.Calculator.MetricAggregator.datasetMetricValue(newDs.Type, p.Id, periodMonth, periodYear)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s of %s: %w", metric.key, p.Id, err)
+		}
+		estimated, err := s.estimatedMetricValue(oldDs, newDs, p.Id, byPerson[p.Id], current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate %s of %s: %w", metric.key, p.Id, err)
+		}
+
+		targets, err := s.Calculator.getTargetResolver(p.Id, periodMonth, periodYear)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get targets of %s: %w", p.Id, err)
+		}
+		target := targets.resolve(*kpi).Value
+		currentKpiScore, err := s.Calculator.calculateScore(*kpi, target, current)
+		if err != nil {
+			return nil, err
+		}
+		estimatedKpiScore, err := s.Calculator.calculateScore(*kpi, target, estimated)
+		if err != nil {
+			return nil, err
+		}
+		estimate := dto.DatasetScoreEstimate{
+			PersonId:       p.Id,
+			PersonName:     p.Name,
+			KpiItemId:      kpi.Id,
+			KpiName:        kpi.Name,
+			CurrentValue:   current,
+			EstimatedValue: estimated,
+			KpiScoreDelta:  estimatedKpiScore - currentKpiScore,
+		}
+		if evaluation, err := s.GetByPersonAndPeriod(p.Id, periodMonth, periodYear); err == nil {
+			currentScore := evaluation.TotalScore
+			estimatedScore := currentScore + estimate.KpiScoreDelta
+			estimate.CurrentScore = &currentScore
+			estimate.EstimatedScore = &estimatedScore
+		}
+		estimates = append(estimates, estimate)
+	}
+	return estimates, nil
+}
+
+// estimatedMetricValue returns the value of the metric of a person once newDs replaces oldDs.
+func (s *ServiceEvaluation) estimatedMetricValue(oldDs, newDs domaindataset.DashboardDataset, personId string, change rowChange, current float64) (float64, error) {
+	aggregator := s.Calculator.MetricAggregator
+	if newDs.Type == utils.DatasetLoginApple {
+		workingDays := aggregator.countWorkingDays(monthBounds(newDs.PeriodMonth, newDs.PeriodYear))
+		if workingDays == 0 {
+			return current, nil
+		}
+		delta := valueOrZero(change.new) - valueOrZero(change.old)
+		return math.Max(0, math.Min(100, current+delta*100/float64(workingDays))), nil
+	}
+
+	metric := datasetMetrics[newDs.Type]
+	rows, err := aggregator.datasetMetricRows(newDs.Type, metric.table, metric.column, personId, newDs.PeriodMonth, newDs.PeriodYear)
+	if err != nil {
+		return 0, err
+	}
+	replaced := make([]metricRow, 0, len(rows)+1)
+	for _, r := range rows {
+		// Importing newDs overwrites the rows of its period date and supersedes oldDs.
+		if r.DatasetId == oldDs.Id || r.DatasetId == newDs.Id || r.PeriodDate.Equal(newDs.PeriodDate) {
+			continue
+		}
+		replaced = append(replaced, r)
+	}
+	if change.new != nil {
+		replaced = append(replaced, metricRow{
+			DatasetId:       newDs.Id,
+			PeriodFrequency: newDs.PeriodFrequency,
+			PeriodDate:      newDs.PeriodDate,
+			UploadedAt:      newDs.UploadedAt,
+			Value:           change.new,
+		})
+	}
+	return aggregateRows(newDs.Type, replaced), nil
+}
+
+// addValue adds v to sum, keeping nil while neither has a value.
+func addValue(sum, v *float64) *float64 {
+	if v == nil {
+		return sum
+	}
+	total := *v
+	if sum != nil {
+		total += *sum
+	}
+	return &total
+}
+
+func valueOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+var _ interfacedataset.EvaluationEstimatorInterface = (*ServiceEvaluation)(nil)
@@ -4,16 +4,20 @@ import (
 	"fmt"
 	"time"
 
+	repositoryperson "teamleader-management/internal/repositories/person"
+	"teamleader-management/utils"
+
 	"gorm.io/gorm"
 )
 
 // MetricAggregator aggregates metrics from various sources for evaluation
 type MetricAggregator struct {
-	DB *gorm.DB
+	DB          *gorm.DB
+	WorkingDays map[time.Weekday]bool // days counted for the Apple login compliance
 }
 
 func NewMetricAggregator(db *gorm.DB) *MetricAggregator {
-	return &MetricAggregator{DB: db}
+	return &MetricAggregator{DB: db, WorkingDays: defaultWorkingDays}
 }
 
 // MetricValue represents a raw metric value with metadata
@@ -29,8 +33,7 @@ func (m *MetricAggregator) GetMetricsForPerson(personId string, periodMonth int,
 	metrics := make(map[string]*MetricValue)
 
 	// Calculate period boundaries
-	startDate := time.Date(periodYear, time.Month(periodMonth), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 1, 0).Add(-time.Second)
+	startDate, endDate := monthBounds(periodMonth, periodYear)
 
 	// ========================================
 	// SALES PERFORMANCE (50%)
@@ -41,173 +44,128 @@ func (m *MetricAggregator) GetMetricsForPerson(personId string, periodMonth int,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get activity count: %w", err)
 	}
-	metrics["quantity_activity"] = &MetricValue{
-		Value:       float64(activityCount),
-		Unit:        "count",
-		Source:      "TL",
-		Description: "Number of promotional activities (canvassing + pameran)",
-	}
+	metrics["quantity_activity"] = newMetricValue("quantity_activity", float64(activityCount))
 
 	// 2. Sales FLP (25%) - From admin dataset
-	salesFLP, err := m.getSalesFLP(personId, periodMonth, periodYear)
+	salesFLP, err := m.aggregateDatasetMetric(utils.DatasetSalesFLP, "sales_flp", "flp_amount", personId, periodMonth, periodYear)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sales FLP: %w", err)
 	}
-	metrics["sales_flp"] = &MetricValue{
-		Value:       salesFLP,
-		Unit:        "amount",
-		Source:      "ADMIN",
-		Description: "Sales FLP amount",
-	}
+	metrics["sales_flp"] = newMetricValue("sales_flp", salesFLP)
 
 	// ========================================
 	// LEADERSHIP (15%)
 	// ========================================
 
-	// 3. Discipline & Attendance (2.5%) - Attendance percentage
+	// 3. Discipline & Attendance (2.5%) - Attendance percentage of the team
 	attendancePercentage, err := m.getAttendancePercentage(personId, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get attendance: %w", err)
 	}
-	metrics["attendance"] = &MetricValue{
-		Value:       attendancePercentage,
-		Unit:        "percentage",
-		Source:      "TL",
-		Description: "Team attendance rate",
-	}
+	metrics["attendance"] = newMetricValue("attendance", attendancePercentage)
 
 	// 4. Coaching Sessions (2.5%) - Count of coaching sessions
 	coachingCount, err := m.countSessions(personId, startDate, endDate, "coaching")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get coaching count: %w", err)
 	}
-	metrics["coaching_sessions"] = &MetricValue{
-		Value:       float64(coachingCount),
-		Unit:        "count",
-		Source:      "TL",
-		Description: "Number of coaching sessions",
-	}
+	metrics["coaching_sessions"] = newMetricValue("coaching_sessions", float64(coachingCount))
 
 	// 5. Briefing Sessions (2.5%) - Count of briefing sessions
 	briefingCount, err := m.countSessions(personId, startDate, endDate, "briefing")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get briefing count: %w", err)
 	}
-	metrics["briefing_sessions"] = &MetricValue{
-		Value:       float64(briefingCount),
-		Unit:        "count",
-		Source:      "TL",
-		Description: "Number of briefing sessions",
-	}
+	metrics["briefing_sessions"] = newMetricValue("briefing_sessions", float64(briefingCount))
 
-	// 6. Team Size (7.5%) - Number of team members
-	teamSize, err := m.getTeamSize(personId)
+	// 6. Team Size (7.5%) - Number of team members at the end of the period
+	teamSize, err := m.getTeamSize(personId, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get team size: %w", err)
 	}
-	metrics["team_size"] = &MetricValue{
-		Value:       float64(teamSize),
-		Unit:        "count",
-		Source:      "TL",
-		Description: "Number of team members",
-	}
+	metrics["team_size"] = newMetricValue("team_size", float64(teamSize))
 
 	// ========================================
 	// DEVELOPMENT (10%)
 	// ========================================
 
 	// 7. Quiz Score (5%) - From admin dataset
-	quizScore, err := m.getQuizScore(personId, periodMonth, periodYear)
+	quizScore, err := m.aggregateDatasetMetric(utils.DatasetQuiz, "quiz_results", "score", personId, periodMonth, periodYear)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get quiz score: %w", err)
 	}
-	metrics["quiz_score"] = &MetricValue{
-		Value:       quizScore,
-		Unit:        "score",
-		Source:      "ADMIN",
-		Description: "Quiz result score",
-	}
+	metrics["quiz_score"] = newMetricValue("quiz_score", quizScore)
 
-	// 8. Training Participation (5%) - Count of training participations
+	// 8. Training Participation (5%) - Count of training participations of the team
 	trainingCount, err := m.countTrainingParticipations(personId, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get training count: %w", err)
 	}
-	metrics["training_participation"] = &MetricValue{
-		Value:       float64(trainingCount),
-		Unit:        "count",
-		Source:      "TL",
-		Description: "Number of training sessions attended",
-	}
+	metrics["training_participation"] = newMetricValue("training_participation", float64(trainingCount))
 
 	// ========================================
 	// DIGITALIZATION (25%)
 	// ========================================
 
-	// 9. Login Apple (5%) - From admin dataset
-	appleLogins, err := m.getAppleLogins(personId, periodMonth, periodYear)
+	// 9. Login Apple (5%) - From admin dataset, compliance over the working days
+	appleLogins, err := m.getAppleLoginCompliance(personId, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get apple logins: %w", err)
 	}
-	metrics["apple_logins"] = &MetricValue{
-		Value:       float64(appleLogins),
-		Unit:        "count",
-		Source:      "ADMIN",
-		Description: "Number of Apple app logins",
-	}
+	metrics["apple_logins"] = newMetricValue("apple_logins", appleLogins)
 
 	// 10. Point Apple (5%) - From admin dataset
-	applePoints, err := m.getApplePoints(personId, periodMonth, periodYear)
+	applePoints, err := m.aggregateDatasetMetric(utils.DatasetPointApple, "apple_points", "points", personId, periodMonth, periodYear)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get apple points: %w", err)
 	}
-	metrics["apple_points"] = &MetricValue{
-		Value:       float64(applePoints),
-		Unit:        "points",
-		Source:      "ADMIN",
-		Description: "Apple app points earned",
-	}
+	metrics["apple_points"] = newMetricValue("apple_points", applePoints)
 
 	// 11. Point My Hero (5%) - From admin dataset
-	myHeroPoints, err := m.getMyHeroPoints(personId, periodMonth, periodYear)
+	myHeroPoints, err := m.aggregateDatasetMetric(utils.DatasetPointMyHero, "myhero_points", "points", personId, periodMonth, periodYear)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get my hero points: %w", err)
 	}
-	metrics["myhero_points"] = &MetricValue{
-		Value:       float64(myHeroPoints),
-		Unit:        "points",
-		Source:      "ADMIN",
-		Description: "My Hero app points earned",
-	}
+	metrics["myhero_points"] = newMetricValue("myhero_points", myHeroPoints)
 
 	// 12. Total Prospects (5%) - From admin dataset
-	totalProspects, err := m.getTotalProspects(personId, periodMonth, periodYear)
+	totalProspects, err := m.aggregateDatasetMetric(utils.DatasetTotalProspect, "prospects", "prospect_count", personId, periodMonth, periodYear)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total prospects: %w", err)
 	}
-	metrics["total_prospects"] = &MetricValue{
-		Value:       float64(totalProspects),
-		Unit:        "count",
-		Source:      "ADMIN",
-		Description: "Total number of prospects",
+	metrics["total_prospects"] = newMetricValue("total_prospects", totalProspects)
+
+	// Deals closed - From the daily activities, numerator of the prospect ratio
+	dealsClosed, err := m.sumDailyActivityDeals(personId, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deals closed: %w", err)
 	}
+	metrics["deals_closed"] = newMetricValue("deals_closed", float64(dealsClosed))
 
-	// 13. Prospect Ratio (5%) - Calculated (requires target)
-	// Note: This will be calculated in the calculator service based on target
-	metrics["prospect_ratio"] = &MetricValue{
-		Value:       0, // Will be calculated later
-		Unit:        "ratio",
-		Source:      "ADMIN",
-		Description: "Prospect achievement ratio",
+	// 13. Prospect Ratio (5%) - Deals closed over total prospects, and any other ratio metric of the registry
+	if err := addRatioMetrics(metrics); err != nil {
+		return nil, err
 	}
 
 	return metrics, nil
 }
 
+// monthBounds returns the first and the last second of a month.
+func monthBounds(periodMonth int, periodYear int) (time.Time, time.Time) {
+	startDate := time.Date(periodYear, time.Month(periodMonth), 1, 0, 0, 0, 0, time.UTC)
+	return startDate, startDate.AddDate(0, 1, 0).Add(-time.Second)
+}
+
 // ========================================
 // HELPER FUNCTIONS - Query each data source
 // ========================================
 
+// importedRowsCondition restricts a metric table (by alias) joined with dashboard_datasets dd to live rows
+// of DONE datasets, so superseded, failed and deleted uploads are never counted.
+func importedRowsCondition(alias string) string {
+	return fmt.Sprintf("%s.deleted_at IS NULL AND dd.deleted_at IS NULL AND dd.status = ?", alias)
+}
+
 func (m *MetricAggregator) countDailyActivities(personId string, startDate, endDate time.Time) (int64, error) {
 	var count int64
 	err := m.DB.Table("tl_daily_activities").
@@ -216,21 +174,15 @@ func (m *MetricAggregator) countDailyActivities(personId string, startDate, endD
 	return count, err
 }
 
-func (m *MetricAggregator) getSalesFLP(personId string, periodMonth int, periodYear int) (float64, error) {
+func (m *MetricAggregator) sumDailyActivityDeals(personId string, startDate, endDate time.Time) (int64, error) {
 	var result struct {
-		FLPAmount float64
+		Total int64
 	}
-
-	err := m.DB.Table("sales_flp sf").
-		Select("COALESCE(SUM(sf.flp_amount), 0) as flp_amount").
-		Joins("INNER JOIN dashboard_datasets dd ON sf.dataset_id = dd.id").
-		Where("sf.person_id = ? AND dd.period_month = ? AND dd.period_year = ?", personId, periodMonth, periodYear).
+	err := m.DB.Table("tl_daily_activities").
+		Select("COALESCE(SUM(deal_count), 0) as total").
+		Where("person_id = ? AND date >= ? AND date <= ? AND deleted_at IS NULL", personId, startDate, endDate).
 		Scan(&result).Error
-
-	if err != nil {
-		return 0, err
-	}
-	return result.FLPAmount, nil
+	return result.Total, err
 }
 
 func (m *MetricAggregator) getAttendancePercentage(personId string, startDate, endDate time.Time) (float64, error) {
@@ -238,10 +190,11 @@ func (m *MetricAggregator) getAttendancePercentage(personId string, startDate, e
 		AvgPercentage float64
 	}
 
-	// Calculate average attendance percentage across all records in period
-	err := m.DB.Table("tl_attendance_records").
-		Select("COALESCE(AVG(CASE WHEN status = 'hadir' THEN 100.0 ELSE 0.0 END), 0) as avg_percentage").
-		Where("tl_person_id = ? AND date >= ? AND date <= ? AND deleted_at IS NULL", personId, startDate, endDate).
+	// Calculate average attendance percentage across the records of team members in period
+	err := m.DB.Table("tl_attendance_records r").
+		Select("COALESCE(AVG(CASE WHEN r.status = 'hadir' THEN 100.0 ELSE 0.0 END), 0) as avg_percentage").
+		Joins(repositoryperson.TeamMemberJoin("r")).
+		Where("r.tl_person_id = ? AND r.date >= ? AND r.date <= ? AND r.deleted_at IS NULL", personId, startDate, endDate).
 		Scan(&result).Error
 
 	if err != nil {
@@ -259,108 +212,23 @@ func (m *MetricAggregator) countSessions(personId string, startDate, endDate tim
 	return count, err
 }
 
-func (m *MetricAggregator) getTeamSize(personId string) (int64, error) {
-	// Count persons who have this TL as their supervisor
-	// Note: This assumes there's a supervisor_id or similar field
-	// Adjust the query based on actual schema
+// getTeamSize counts the salesmen reporting to a TL on a date.
+func (m *MetricAggregator) getTeamSize(personId string, asOf time.Time) (int64, error) {
 	var count int64
-	err := m.DB.Table("persons").
-		Where("role = 'salesman' AND active = true").
-		// TODO: Add supervisor_id filter when schema is updated
+	err := m.DB.Table("person_supervisor_assignments psa").
+		Joins("INNER JOIN persons p ON p.id = psa.person_id AND p.role = ? AND p.deleted_at IS NULL", utils.RoleSM).
+		Where("psa.supervisor_id = ? AND psa.effective_from <= ? AND (psa.effective_to IS NULL OR psa.effective_to > ?)", personId, asOf, asOf).
 		Count(&count).Error
 	return count, err
 }
 
-func (m *MetricAggregator) getQuizScore(personId string, periodMonth int, periodYear int) (float64, error) {
-	var result struct {
-		Score float64
-	}
-
-	err := m.DB.Table("quiz_results qr").
-		Select("COALESCE(AVG(qr.score), 0) as score").
-		Joins("INNER JOIN dashboard_datasets dd ON qr.dataset_id = dd.id").
-		Where("qr.person_id = ? AND dd.period_month = ? AND dd.period_year = ?", personId, periodMonth, periodYear).
-		Scan(&result).Error
-
-	if err != nil {
-		return 0, err
-	}
-	return result.Score, nil
-}
-
+// countTrainingParticipations counts the trainings team members attended in the period.
 func (m *MetricAggregator) countTrainingParticipations(personId string, startDate, endDate time.Time) (int64, error) {
 	var count int64
-	err := m.DB.Table("tl_training_participations").
-		Where("person_id = ? AND start_date >= ? AND start_date <= ? AND deleted_at IS NULL",
+	err := m.DB.Table("tl_training_participations r").
+		Joins(repositoryperson.TeamMemberJoin("r")).
+		Where("r.tl_person_id = ? AND r.status = 'hadir' AND r.date >= ? AND r.date <= ? AND r.deleted_at IS NULL",
 			personId, startDate, endDate).
 		Count(&count).Error
 	return count, err
 }
-
-func (m *MetricAggregator) getAppleLogins(personId string, periodMonth int, periodYear int) (int, error) {
-	var result struct {
-		LoginCount int
-	}
-
-	err := m.DB.Table("apple_logins al").
-		Select("COALESCE(SUM(al.login_count), 0) as login_count").
-		Joins("INNER JOIN dashboard_datasets dd ON al.dataset_id = dd.id").
-		Where("al.person_id = ? AND dd.period_month = ? AND dd.period_year = ?", personId, periodMonth, periodYear).
-		Scan(&result).Error
-
-	if err != nil {
-		return 0, err
-	}
-	return result.LoginCount, nil
-}
-
-func (m *MetricAggregator) getApplePoints(personId string, periodMonth int, periodYear int) (int, error) {
-	var result struct {
-		Points int
-	}
-
-	err := m.DB.Table("apple_points ap").
-		Select("COALESCE(SUM(ap.points), 0) as points").
-		Joins("INNER JOIN dashboard_datasets dd ON ap.dataset_id = dd.id").
-		Where("ap.person_id = ? AND dd.period_month = ? AND dd.period_year = ?", personId, periodMonth, periodYear).
-		Scan(&result).Error
-
-	if err != nil {
-		return 0, err
-	}
-	return result.Points, nil
-}
-
-func (m *MetricAggregator) getMyHeroPoints(personId string, periodMonth int, periodYear int) (int, error) {
-	var result struct {
-		Points int
-	}
-
-	err := m.DB.Table("myhero_points mp").
-		Select("COALESCE(SUM(mp.points), 0) as points").
-		Joins("INNER JOIN dashboard_datasets dd ON mp.dataset_id = dd.id").
-		Where("mp.person_id = ? AND dd.period_month = ? AND dd.period_year = ?", personId, periodMonth, periodYear).
-		Scan(&result).Error
-
-	if err != nil {
-		return 0, err
-	}
-	return result.Points, nil
-}
-
-func (m *MetricAggregator) getTotalProspects(personId string, periodMonth int, periodYear int) (int, error) {
-	var result struct {
-		ProspectCount int
-	}
-
-	err := m.DB.Table("prospects p").
-		Select("COALESCE(SUM(p.prospect_count), 0) as prospect_count").
-		Joins("INNER JOIN dashboard_datasets dd ON p.dataset_id = dd.id").
-		Where("p.person_id = ? AND dd.period_month = ? AND dd.period_year = ?", personId, periodMonth, periodYear).
-		Scan(&result).Error
-
-	if err != nil {
-		return 0, err
-	}
-	return result.ProspectCount, nil
-}
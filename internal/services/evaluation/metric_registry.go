@@ -0,0 +1,83 @@
+package serviceevaluation
+
+import (
+	"fmt"
+
+	"teamleader-management/internal/dto"
+	"teamleader-management/utils"
+)
+
+// metricRegistry lists every metric computed by GetMetricsForPerson. KPI items bind to a metric through
+// its key, so a key must never be renamed once KPI items use it. A metric with a Numerator and a Denominator
+// is a ratio of two metrics listed before it, in percent; another ratio KPI only needs a new entry here.
+var metricRegistry = []dto.MetricDefinition{
+	{Key: "quantity_activity", Name: "Quantity Activity", Unit: "count", Source: "TL", Description: "Number of promotional activities (canvassing + pameran)"},
+	{Key: "sales_flp", Name: "Sales FLP", Unit: "amount", Source: "ADMIN", DatasetType: utils.DatasetSalesFLP, Description: "Sales FLP amount"},
+	{Key: "attendance", Name: "Team Attendance", Unit: "percentage", Source: "TL", Description: "Attendance rate of the salesmen in the team on the day of the attendance"},
+	{Key: "coaching_sessions", Name: "Coaching Sessions", Unit: "count", Source: "TL", Description: "Number of coaching sessions"},
+	{Key: "briefing_sessions", Name: "Briefing Sessions", Unit: "count", Source: "TL", Description: "Number of briefing sessions"},
+	{Key: "team_size", Name: "Team Size", Unit: "count", Source: "TL", Description: "Number of salesmen in the team at the end of the period"},
+	{Key: "quiz_score", Name: "Quiz Score", Unit: "score", Source: "ADMIN", DatasetType: utils.DatasetQuiz, Description: "Quiz result score"},
+	{Key: "training_participation", Name: "Training Participation", Unit: "count", Source: "TL", Description: "Number of trainings attended by the salesmen in the team on the day of the training"},
+	{Key: "apple_logins", Name: "Apple Login Compliance", Unit: "percentage", Source: "ADMIN", DatasetType: utils.DatasetLoginApple, Description: "Working days with both the morning and the evening Apple login"},
+	{Key: "apple_points", Name: "Apple Points", Unit: "points", Source: "ADMIN", DatasetType: utils.DatasetPointApple, Description: "Apple app points earned"},
+	{Key: "myhero_points", Name: "My Hero Points", Unit: "points", Source: "ADMIN", DatasetType: utils.DatasetPointMyHero, Description: "My Hero app points earned"},
+	{Key: "total_prospects", Name: "Total Prospects", Unit: "count", Source: "ADMIN", DatasetType: utils.DatasetTotalProspect, Description: "Total number of prospects"},
+	{Key: "deals_closed", Name: "Deals Closed", Unit: "count", Source: "TL", Description: "Deals closed, from the daily activities"},
+	{Key: "prospect_ratio", Name: "Prospect Ratio", Unit: "percentage", Source: "SYSTEM", Numerator: "deals_closed", Denominator: "total_prospects", Description: "Prospects converted to deals, in percent"},
+}
+
+// Metrics returns the metrics KPI items can be bound to.
+func (m *MetricAggregator) Metrics() []dto.MetricDefinition {
+	return append([]dto.MetricDefinition(nil), metricRegistry...)
+}
+
+// HasMetric reports whether key is a metric of the registry.
+func (m *MetricAggregator) HasMetric(key string) bool {
+	_, ok := lookupMetric(key)
+	return ok
+}
+
+func lookupMetric(key string) (dto.MetricDefinition, bool) {
+	for _, def := range metricRegistry {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return dto.MetricDefinition{}, false
+}
+
+// addRatioMetrics computes the ratio metrics of the registry from the metrics already in metrics. A ratio
+// over a zero denominator is 0.
+func addRatioMetrics(metrics map[string]*MetricValue) error {
+	for _, def := range metricRegistry {
+		if def.Numerator == "" {
+			continue
+		}
+		numerator, ok := metrics[def.Numerator]
+		if !ok {
+			return fmt.Errorf("ratio metric %s needs metric %s, which is not computed before it", def.Key, def.Numerator)
+		}
+		denominator, ok := metrics[def.Denominator]
+		if !ok {
+			return fmt.Errorf("ratio metric %s needs metric %s, which is not computed before it", def.Key, def.Denominator)
+		}
+		ratio := 0.0
+		if denominator.Value != 0 {
+			ratio = numerator.Value / denominator.Value * 100
+		}
+		metrics[def.Key] = newMetricValue(def.Key, ratio)
+	}
+	return nil
+}
+
+// newMetricValue returns the value of a registry metric with its unit, source and description.
+func newMetricValue(key string, value float64) *MetricValue {
+	def, _ := lookupMetric(key)
+	return &MetricValue{
+		Value:       value,
+		Unit:        def.Unit,
+		Source:      def.Source,
+		Description: def.Description,
+	}
+}
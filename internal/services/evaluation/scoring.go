@@ -0,0 +1,338 @@
+package serviceevaluation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	domainkpiitem "teamleader-management/internal/domain/kpiitem"
+	"teamleader-management/internal/dto"
+	"teamleader-management/utils"
+)
+
+// ScoringInput is what a scoring strategy scores: the actual value of the KPI metric against its target.
+type ScoringInput struct {
+	Actual float64
+	Target *float64 // nil when the KPI has no target
+	Weight float64
+}
+
+// achievement returns actual/target as a percentage, and false when there is no target to compare with.
+func (in ScoringInput) achievement() (float64, bool) {
+	if in.Target == nil || *in.Target == 0 {
+		return 0, false
+	}
+	return in.Actual / *in.Target * 100, true
+}
+
+// ScoringStrategy turns the actual value of a KPI into its score. Params is the scoring_params JSON object
+// of the KPI item; Validate is called when the KPI item is saved, so Score may rely on valid params.
+type ScoringStrategy interface {
+	Describe() dto.ScoringStrategyDefinition
+	Validate(params json.RawMessage) error
+	Score(in ScoringInput, params json.RawMessage) float64
+}
+
+// ScoringRegistry holds the scoring strategies KPI items can use, by scoring_type.
+type ScoringRegistry struct {
+	strategies map[string]ScoringStrategy
+}
+
+// NewScoringRegistry returns a registry with the built-in strategies.
+func NewScoringRegistry() *ScoringRegistry {
+	r := &ScoringRegistry{strategies: make(map[string]ScoringStrategy)}
+	r.Register(utils.ScoringLinearCapped, linearScoring{capped: true})
+	r.Register(utils.ScoringLinearBonus, linearScoring{})
+	r.Register(utils.ScoringStepped, steppedScoring{})
+	r.Register(utils.ScoringPassFail, passFailScoring{})
+	r.Register(utils.ScoringInverse, inverseScoring{})
+	return r
+}
+
+// Register adds or replaces the strategy of a scoring type.
+func (r *ScoringRegistry) Register(scoringType string, strategy ScoringStrategy) {
+	r.strategies[strings.ToUpper(scoringType)] = strategy
+}
+
+// ScoringStrategies describes the registered strategies, sorted by type.
+func (r *ScoringRegistry) ScoringStrategies() []dto.ScoringStrategyDefinition {
+	ret := make([]dto.ScoringStrategyDefinition, 0, len(r.strategies))
+	for scoringType, strategy := range r.strategies {
+		def := strategy.Describe()
+		def.Type = scoringType
+		ret = append(ret, def)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Type < ret[j].Type })
+	return ret
+}
+
+// ValidateScoring checks that a scoring type is registered and that its parameters are valid.
+func (r *ScoringRegistry) ValidateScoring(scoringType string, params json.RawMessage) error {
+	strategy, ok := r.strategies[scoringType]
+	if !ok {
+		return fmt.Errorf("unknown scoring_type %q", scoringType)
+	}
+	if err := strategy.Validate(params); err != nil {
+		return fmt.Errorf("invalid scoring_params for %s: %w", scoringType, err)
+	}
+	return nil
+}
+
+// Score scores the actual value of a KPI item with its strategy; KPI items without one are LINEAR_CAPPED.
+func (r *ScoringRegistry) Score(kpi domainkpiitem.KPIItem, in ScoringInput) (float64, error) {
+	scoringType := kpi.ScoringType
+	if scoringType == "" {
+		scoringType = utils.ScoringLinearCapped
+	}
+	strategy, ok := r.strategies[scoringType]
+	if !ok {
+		return 0, fmt.Errorf("KPI item %q has unknown scoring_type %q", kpi.Name, scoringType)
+	}
+	return strategy.Score(in, json.RawMessage(kpi.ScoringParams)), nil
+}
+
+// decodeParams decodes a scoring_params object into out, rejecting unknown fields. Empty params keep out as is.
+func decodeParams(params json.RawMessage, out interface{}) error {
+	if len(bytes.TrimSpace(params)) == 0 || string(bytes.TrimSpace(params)) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+// presenceScore is the score of a KPI without a target: the full weight as soon as there is a value.
+func presenceScore(in ScoringInput) float64 {
+	if in.Actual > 0 {
+		return in.Weight
+	}
+	return 0
+}
+
+// linearScoring scores the achievement linearly. Capped at 100% for LINEAR_CAPPED; LINEAR_BONUS
+// allows over-achievement up to max_achievement percent.
+type linearScoring struct {
+	capped bool
+}
+
+type linearParams struct {
+	MaxAchievement float64 `json:"max_achievement"`
+}
+
+func (s linearScoring) params(raw json.RawMessage) (linearParams, error) {
+	p := linearParams{MaxAchievement: 100}
+	if s.capped {
+		return p, decodeParams(raw, &struct{}{})
+	}
+	p.MaxAchievement = 120
+	if err := decodeParams(raw, &p); err != nil {
+		return p, err
+	}
+	if p.MaxAchievement < 100 {
+		return p, errors.New("max_achievement must be at least 100")
+	}
+	return p, nil
+}
+
+func (s linearScoring) Describe() dto.ScoringStrategyDefinition {
+	if s.capped {
+		return dto.ScoringStrategyDefinition{
+			Description:   "Weight times the achievement (actual / target), capped at 100%. Without a target, the full weight as soon as there is a value.",
+			ExampleParams: json.RawMessage(`{}`),
+		}
+	}
+	return dto.ScoringStrategyDefinition{
+		Description:   "Weight times the achievement, rewarding over-achievement up to max_achievement percent (default 120). Without a target, the full weight as soon as there is a value.",
+		ExampleParams: json.RawMessage(`{"max_achievement": 120}`),
+	}
+}
+
+func (s linearScoring) Validate(params json.RawMessage) error {
+	_, err := s.params(params)
+	return err
+}
+
+func (s linearScoring) Score(in ScoringInput, params json.RawMessage) float64 {
+	achievement, ok := in.achievement()
+	if !ok {
+		return presenceScore(in)
+	}
+	p, _ := s.params(params)
+	return in.Weight * math.Max(0, math.Min(achievement, p.MaxAchievement)) / 100
+}
+
+// steppedScoring gives the share of the weight of the highest tier whose min_achievement is reached.
+type steppedScoring struct{}
+
+type scoringTier struct {
+	MinAchievement float64 `json:"min_achievement"` // percent of the target
+	Score          float64 `json:"score"`           // percent of the weight
+}
+
+type steppedParams struct {
+	Tiers []scoringTier `json:"tiers"`
+}
+
+func (steppedScoring) params(raw json.RawMessage) (steppedParams, error) {
+	var p steppedParams
+	if err := decodeParams(raw, &p); err != nil {
+		return p, err
+	}
+	if len(p.Tiers) == 0 {
+		return p, errors.New("at least one tier is required")
+	}
+	sort.Slice(p.Tiers, func(i, j int) bool { return p.Tiers[i].MinAchievement < p.Tiers[j].MinAchievement })
+	for i, t := range p.Tiers {
+		if t.Score < 0 {
+			return p, errors.New("tier score must not be negative")
+		}
+		if i > 0 && t.MinAchievement == p.Tiers[i-1].MinAchievement {
+			return p, fmt.Errorf("two tiers start at %v%%", t.MinAchievement)
+		}
+	}
+	return p, nil
+}
+
+func (steppedScoring) Describe() dto.ScoringStrategyDefinition {
+	return dto.ScoringStrategyDefinition{
+		Description:   "Share (score, percent of the weight) of the highest tier whose min_achievement (percent of the target) is reached; nothing below the first tier or without a target.",
+		ExampleParams: json.RawMessage(`{"tiers": [{"min_achievement": 60, "score": 50}, {"min_achievement": 80, "score": 75}, {"min_achievement": 100, "score": 100}]}`),
+	}
+}
+
+func (s steppedScoring) Validate(params json.RawMessage) error {
+	_, err := s.params(params)
+	return err
+}
+
+func (s steppedScoring) Score(in ScoringInput, params json.RawMessage) float64 {
+	achievement, ok := in.achievement()
+	if !ok {
+		return 0
+	}
+	p, _ := s.params(params)
+	share := 0.0
+	for _, t := range p.Tiers {
+		if achievement >= t.MinAchievement {
+			share = t.Score
+		}
+	}
+	return in.Weight * share / 100
+}
+
+// passFailScoring gives the full weight once the threshold is reached. The threshold applies to the
+// achievement (percent of the target) or, with basis "value", to the actual value itself.
+type passFailScoring struct{}
+
+const (
+	passFailBasisAchievement = "achievement"
+	passFailBasisValue       = "value"
+)
+
+type passFailParams struct {
+	Threshold *float64 `json:"threshold"`
+	Basis     string   `json:"basis"`
+}
+
+func (passFailScoring) params(raw json.RawMessage) (passFailParams, error) {
+	var p passFailParams
+	if err := decodeParams(raw, &p); err != nil {
+		return p, err
+	}
+	if p.Basis == "" {
+		p.Basis = passFailBasisAchievement
+	}
+	if p.Basis != passFailBasisAchievement && p.Basis != passFailBasisValue {
+		return p, errors.New(`basis must be "achievement" or "value"`)
+	}
+	if p.Threshold == nil {
+		if p.Basis == passFailBasisValue {
+			return p, errors.New("threshold is required with basis value")
+		}
+		threshold := 100.0
+		p.Threshold = &threshold
+	}
+	return p, nil
+}
+
+func (passFailScoring) Describe() dto.ScoringStrategyDefinition {
+	return dto.ScoringStrategyDefinition{
+		Description:   `Full weight when the threshold is reached, nothing otherwise. The threshold is a percent of the target (default 100) or, with basis "value", a minimum actual value.`,
+		ExampleParams: json.RawMessage(`{"threshold": 90, "basis": "achievement"}`),
+	}
+}
+
+func (s passFailScoring) Validate(params json.RawMessage) error {
+	_, err := s.params(params)
+	return err
+}
+
+func (s passFailScoring) Score(in ScoringInput, params json.RawMessage) float64 {
+	p, _ := s.params(params)
+	value := in.Actual
+	if p.Basis == passFailBasisAchievement {
+		achievement, ok := in.achievement()
+		if !ok {
+			return 0
+		}
+		value = achievement
+	}
+	if value >= *p.Threshold {
+		return in.Weight
+	}
+	return 0
+}
+
+// inverseScoring is for metrics where lower is better: the full weight up to the target, then decreasing
+// linearly to nothing at zero_at percent of the target.
+type inverseScoring struct{}
+
+type inverseParams struct {
+	ZeroAt float64 `json:"zero_at"`
+}
+
+func (inverseScoring) params(raw json.RawMessage) (inverseParams, error) {
+	p := inverseParams{ZeroAt: 200}
+	if err := decodeParams(raw, &p); err != nil {
+		return p, err
+	}
+	if p.ZeroAt <= 100 {
+		return p, errors.New("zero_at must be above 100")
+	}
+	return p, nil
+}
+
+func (inverseScoring) Describe() dto.ScoringStrategyDefinition {
+	return dto.ScoringStrategyDefinition{
+		Description:   "Lower is better: full weight while the actual value stays at or below the target, decreasing linearly to nothing at zero_at percent of the target (default 200). With a zero target, the full weight only while the actual value is not positive. Nothing without a target.",
+		ExampleParams: json.RawMessage(`{"zero_at": 200}`),
+	}
+}
+
+func (s inverseScoring) Validate(params json.RawMessage) error {
+	_, err := s.params(params)
+	return err
+}
+
+func (s inverseScoring) Score(in ScoringInput, params json.RawMessage) float64 {
+	if in.Target != nil && *in.Target == 0 {
+		// A zero target is met only by nothing at all; any positive value is past zero_at percent of it.
+		if in.Actual <= 0 {
+			return in.Weight
+		}
+		return 0
+	}
+	achievement, ok := in.achievement()
+	if !ok {
+		return 0
+	}
+	if achievement <= 100 {
+		return in.Weight
+	}
+	p, _ := s.params(params)
+	return in.Weight * math.Max(0, (p.ZeroAt-achievement)/(p.ZeroAt-100))
+}
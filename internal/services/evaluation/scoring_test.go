@@ -0,0 +1,106 @@
+package serviceevaluation
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"teamleader-management/utils"
+)
+
+func TestScoringStrategies(t *testing.T) {
+	target := func(v float64) *float64 { return &v }
+	registry := NewScoringRegistry()
+
+	tests := []struct {
+		name        string
+		scoringType string
+		params      string
+		in          ScoringInput
+		want        float64
+	}{
+		// LINEAR_CAPPED
+		{"capped: half of the target", utils.ScoringLinearCapped, "", ScoringInput{Actual: 50, Target: target(100), Weight: 20}, 10},
+		{"capped: over-achievement is capped", utils.ScoringLinearCapped, "", ScoringInput{Actual: 150, Target: target(100), Weight: 20}, 20},
+		{"capped: negative actual scores nothing", utils.ScoringLinearCapped, "", ScoringInput{Actual: -10, Target: target(100), Weight: 20}, 0},
+		{"capped: no target, any value", utils.ScoringLinearCapped, "", ScoringInput{Actual: 3, Weight: 20}, 20},
+		{"capped: no target, no value", utils.ScoringLinearCapped, "", ScoringInput{Actual: 0, Weight: 20}, 0},
+		{"capped: zero target is no target", utils.ScoringLinearCapped, "", ScoringInput{Actual: 3, Target: target(0), Weight: 20}, 20},
+
+		// LINEAR_BONUS
+		{"bonus: default cap at 120%", utils.ScoringLinearBonus, "", ScoringInput{Actual: 150, Target: target(100), Weight: 20}, 24},
+		{"bonus: below the cap", utils.ScoringLinearBonus, "", ScoringInput{Actual: 110, Target: target(100), Weight: 20}, 22},
+		{"bonus: max_achievement cap", utils.ScoringLinearBonus, `{"max_achievement": 150}`, ScoringInput{Actual: 200, Target: target(100), Weight: 20}, 30},
+		{"bonus: null params keep the default", utils.ScoringLinearBonus, "null", ScoringInput{Actual: 200, Target: target(100), Weight: 10}, 12},
+
+		// STEPPED
+		{"stepped: below the first tier", utils.ScoringStepped, `{"tiers": [{"min_achievement": 60, "score": 50}, {"min_achievement": 100, "score": 100}]}`, ScoringInput{Actual: 50, Target: target(100), Weight: 20}, 0},
+		{"stepped: highest tier reached", utils.ScoringStepped, `{"tiers": [{"min_achievement": 60, "score": 50}, {"min_achievement": 80, "score": 75}, {"min_achievement": 100, "score": 100}]}`, ScoringInput{Actual: 85, Target: target(100), Weight: 20}, 15},
+		{"stepped: tiers in any order", utils.ScoringStepped, `{"tiers": [{"min_achievement": 100, "score": 100}, {"min_achievement": 60, "score": 50}, {"min_achievement": 80, "score": 75}]}`, ScoringInput{Actual: 85, Target: target(100), Weight: 20}, 15},
+		{"stepped: tier boundary is reached", utils.ScoringStepped, `{"tiers": [{"min_achievement": 60, "score": 50}, {"min_achievement": 100, "score": 100}]}`, ScoringInput{Actual: 100, Target: target(100), Weight: 20}, 20},
+		{"stepped: no target", utils.ScoringStepped, `{"tiers": [{"min_achievement": 0, "score": 100}]}`, ScoringInput{Actual: 85, Weight: 20}, 0},
+
+		// PASS_FAIL
+		{"pass/fail: default threshold is the target", utils.ScoringPassFail, "", ScoringInput{Actual: 100, Target: target(100), Weight: 20}, 20},
+		{"pass/fail: below the default threshold", utils.ScoringPassFail, "", ScoringInput{Actual: 99, Target: target(100), Weight: 20}, 0},
+		{"pass/fail: achievement threshold", utils.ScoringPassFail, `{"threshold": 90}`, ScoringInput{Actual: 45, Target: target(50), Weight: 20}, 20},
+		{"pass/fail: no target on achievement", utils.ScoringPassFail, "", ScoringInput{Actual: 100, Weight: 20}, 0},
+		{"pass/fail: value basis ignores the target", utils.ScoringPassFail, `{"threshold": 5, "basis": "value"}`, ScoringInput{Actual: 5, Target: target(100), Weight: 20}, 20},
+		{"pass/fail: value basis without a target", utils.ScoringPassFail, `{"threshold": 5, "basis": "value"}`, ScoringInput{Actual: 4, Weight: 20}, 0},
+
+		// INVERSE
+		{"inverse: at the target", utils.ScoringInverse, "", ScoringInput{Actual: 10, Target: target(10), Weight: 20}, 20},
+		{"inverse: below the target", utils.ScoringInverse, "", ScoringInput{Actual: 5, Target: target(10), Weight: 20}, 20},
+		{"inverse: default zero_at interpolation", utils.ScoringInverse, "", ScoringInput{Actual: 15, Target: target(10), Weight: 20}, 10},
+		{"inverse: past the default zero_at", utils.ScoringInverse, "", ScoringInput{Actual: 25, Target: target(10), Weight: 20}, 0},
+		{"inverse: zero_at interpolation", utils.ScoringInverse, `{"zero_at": 300}`, ScoringInput{Actual: 15, Target: target(10), Weight: 20}, 15},
+		{"inverse: zero target, nothing", utils.ScoringInverse, "", ScoringInput{Actual: 0, Target: target(0), Weight: 20}, 20},
+		{"inverse: zero target, positive actual", utils.ScoringInverse, "", ScoringInput{Actual: 1, Target: target(0), Weight: 20}, 0},
+		{"inverse: no target", utils.ScoringInverse, "", ScoringInput{Actual: 0, Weight: 20}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := json.RawMessage(tt.params)
+			if err := registry.ValidateScoring(tt.scoringType, params); err != nil {
+				t.Fatalf("ValidateScoring: %v", err)
+			}
+			if got := registry.strategies[tt.scoringType].Score(tt.in, params); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoringParamsValidation(t *testing.T) {
+	registry := NewScoringRegistry()
+
+	tests := []struct {
+		name        string
+		scoringType string
+		params      string
+		wantErr     bool
+	}{
+		{"capped: empty params", utils.ScoringLinearCapped, "", false},
+		{"capped: rejects max_achievement", utils.ScoringLinearCapped, `{"max_achievement": 150}`, true},
+		{"bonus: empty params", utils.ScoringLinearBonus, "", false},
+		{"bonus: max_achievement below 100", utils.ScoringLinearBonus, `{"max_achievement": 90}`, true},
+		{"bonus: unknown field", utils.ScoringLinearBonus, `{"cap": 150}`, true},
+		{"stepped: empty params", utils.ScoringStepped, "", true},
+		{"stepped: negative score", utils.ScoringStepped, `{"tiers": [{"min_achievement": 50, "score": -1}]}`, true},
+		{"stepped: two tiers at the same achievement", utils.ScoringStepped, `{"tiers": [{"min_achievement": 50, "score": 50}, {"min_achievement": 50, "score": 60}]}`, true},
+		{"pass/fail: empty params", utils.ScoringPassFail, "", false},
+		{"pass/fail: value basis needs a threshold", utils.ScoringPassFail, `{"basis": "value"}`, true},
+		{"pass/fail: unknown basis", utils.ScoringPassFail, `{"threshold": 5, "basis": "target"}`, true},
+		{"inverse: empty params", utils.ScoringInverse, "", false},
+		{"inverse: zero_at not above 100", utils.ScoringInverse, `{"zero_at": 100}`, true},
+		{"unknown scoring type", "CURVED", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.ValidateScoring(tt.scoringType, json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScoring error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
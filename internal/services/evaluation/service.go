@@ -9,6 +9,7 @@ import (
 	domainperson "teamleader-management/internal/domain/person"
 	domainpillar "teamleader-management/internal/domain/pillar"
 	"teamleader-management/internal/dto"
+	interfacedataset "teamleader-management/internal/interfaces/dataset"
 	interfaceevaluation "teamleader-management/internal/interfaces/evaluation"
 	"teamleader-management/pkg/filter"
 	"teamleader-management/utils"
@@ -17,22 +18,43 @@ import (
 )
 
 type ServiceEvaluation struct {
-	Repo       interfaceevaluation.RepoEvaluationInterface
-	Calculator *EvaluationCalculator
-	DB         *gorm.DB
+	Repo             interfaceevaluation.RepoEvaluationInterface
+	DatasetRepo      interfacedataset.RepoDatasetInterface
+	Calculator       *EvaluationCalculator
+	DB               *gorm.DB
+	RequiredDatasets []string // dataset types that must be imported before a period is evaluated, unless the period sets its own
+	CoverageMode     string   // utils.EvaluationCoverageStrict or utils.EvaluationCoverageWarn
 }
 
-func NewEvaluationService(repo interfaceevaluation.RepoEvaluationInterface, db *gorm.DB) *ServiceEvaluation {
+func NewEvaluationService(repo interfaceevaluation.RepoEvaluationInterface, datasetRepo interfacedataset.RepoDatasetInterface, db *gorm.DB, requiredDatasets string, coverageMode string) *ServiceEvaluation {
 	return &ServiceEvaluation{
-		Repo:       repo,
-		Calculator: NewEvaluationCalculator(db),
-		DB:         db,
+		Repo:             repo,
+		DatasetRepo:      datasetRepo,
+		Calculator:       NewEvaluationCalculator(db),
+		DB:               db,
+		RequiredDatasets: parseRequiredDatasets(requiredDatasets),
+		CoverageMode:     parseCoverageMode(coverageMode),
 	}
 }
 
 // CalculateEvaluation calculates evaluation for a period
-// If personId is empty, calculates for all TLs
-func (s *ServiceEvaluation) CalculateEvaluation(periodMonth int, periodYear int, personId string) ([]dto.EvaluationResponse, error) {
+// If personId is empty, calculates for all TLs. Required datasets missing for the period are
+// returned as warnings, or refuse the calculation in strict mode unless force is set.
+func (s *ServiceEvaluation) CalculateEvaluation(periodMonth int, periodYear int, personId string, force bool) ([]dto.EvaluationResponse, []string, error) {
+	warnings, err := s.checkCoverage(periodMonth, periodYear, force)
+	if err != nil {
+		return nil, nil, err
+	}
+	// A KPI item without a known metric would fail every TL, and per-TL errors are skipped
+	if _, err := s.Calculator.getBoundKPIItems(); err != nil {
+		return nil, nil, err
+	}
+	results, err := s.calculate(periodMonth, periodYear, personId)
+	return results, warnings, err
+}
+
+// calculate stores the evaluations of a period for one or all TLs
+func (s *ServiceEvaluation) calculate(periodMonth int, periodYear int, personId string) ([]dto.EvaluationResponse, error) {
 	// 1. Get or create evaluation period
 	period, err := s.Repo.GetOrCreatePeriod(periodMonth, periodYear)
 	if err != nil {
@@ -206,11 +228,21 @@ func (s *ServiceEvaluation) GetLeaderboard(periodMonth int, periodYear int, limi
 }
 
 // RecalculateEvaluation deletes existing evaluation and recalculates
-func (s *ServiceEvaluation) RecalculateEvaluation(periodMonth int, periodYear int, personId string) ([]dto.EvaluationResponse, error) {
+// The coverage check runs first, so a refused recalculation keeps the existing evaluations.
+func (s *ServiceEvaluation) RecalculateEvaluation(periodMonth int, periodYear int, personId string, force bool) ([]dto.EvaluationResponse, []string, error) {
+	warnings, err := s.checkCoverage(periodMonth, periodYear, force)
+	if err != nil {
+		return nil, nil, err
+	}
+	// A KPI item without a known metric would fail every TL, and per-TL errors are skipped
+	if _, err := s.Calculator.getBoundKPIItems(); err != nil {
+		return nil, nil, err
+	}
+
 	// Get period
 	period, err := s.Repo.GetPeriodByMonthYear(periodMonth, periodYear)
 	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("failed to get period: %w", err)
+		return nil, nil, fmt.Errorf("failed to get period: %w", err)
 	}
 
 	// Delete existing evaluations if period exists
@@ -226,7 +258,8 @@ func (s *ServiceEvaluation) RecalculateEvaluation(periodMonth int, periodYear in
 	}
 
 	// Recalculate
-	return s.CalculateEvaluation(periodMonth, periodYear, personId)
+	results, err := s.calculate(periodMonth, periodYear, personId)
+	return results, warnings, err
 }
 
 // ========================================
@@ -313,13 +346,20 @@ func (s *ServiceEvaluation) buildKPIBreakdown(details []domainevaluation.Evaluat
 			pillarName = pillar.Name
 		}
 
+		// Details scored before targets were resolved per person were scored against the KPI target.
+		targetValue, targetSource := detail.TargetValue, detail.TargetSource
+		if targetSource == "" {
+			targetValue, targetSource = kpi.TargetValue, utils.TargetSourceKPI
+		}
+
 		item := dto.KpiScoreBreakdown{
 			KpiItemId:        detail.KpiItemId,
 			KpiItemName:      kpi.Name,
 			PillarName:       pillarName,
 			Weight:           kpi.Weight,
 			ActualValue:      detail.ActualValue,
-			TargetValue:      kpi.TargetValue,
+			TargetValue:      targetValue,
+			TargetSource:     targetSource,
 			AchievementRatio: detail.AchievementRatio,
 			Score:            detail.Score,
 			MaxScore:         kpi.Weight,
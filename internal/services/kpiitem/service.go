@@ -1,7 +1,10 @@
 package servicekpiitem
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -12,6 +15,8 @@ import (
 	interfacepillar "teamleader-management/internal/interfaces/pillar"
 	"teamleader-management/pkg/filter"
 	"teamleader-management/utils"
+
+	"gorm.io/gorm"
 )
 
 type ServiceKPIItem struct {
@@ -19,14 +24,22 @@ type ServiceKPIItem struct {
 	PillarRepo       interfacepillar.RepoPillarInterface
 	PersonRepo       interfaceperson.RepoPersonInterface
 	PersonTargetRepo interfacekpiitem.RepoPersonKPITargetInterface
+	DealerTargetRepo interfacekpiitem.RepoDealerKPITargetInterface
+	Metrics          interfacekpiitem.MetricRegistryInterface
+	Scoring          interfacekpiitem.ScoringRegistryInterface
+	DB               *gorm.DB
 }
 
-func NewKPIItemService(kRepo interfacekpiitem.RepoKPIItemInterface, pRepo interfacepillar.RepoPillarInterface, personRepo interfaceperson.RepoPersonInterface, targetRepo interfacekpiitem.RepoPersonKPITargetInterface) *ServiceKPIItem {
+func NewKPIItemService(kRepo interfacekpiitem.RepoKPIItemInterface, pRepo interfacepillar.RepoPillarInterface, personRepo interfaceperson.RepoPersonInterface, targetRepo interfacekpiitem.RepoPersonKPITargetInterface, dealerTargetRepo interfacekpiitem.RepoDealerKPITargetInterface, metrics interfacekpiitem.MetricRegistryInterface, scoring interfacekpiitem.ScoringRegistryInterface, db *gorm.DB) *ServiceKPIItem {
 	return &ServiceKPIItem{
 		KPIRepo:          kRepo,
 		PillarRepo:       pRepo,
 		PersonRepo:       personRepo,
 		PersonTargetRepo: targetRepo,
+		DealerTargetRepo: dealerTargetRepo,
+		Metrics:          metrics,
+		Scoring:          scoring,
+		DB:               db,
 	}
 }
 
@@ -48,10 +61,30 @@ func (s *ServiceKPIItem) Create(req dto.KPIItemCreate, actorId string) (domainkp
 		return domainkpiitem.KPIItem{}, errors.New("kpi item name already exists in this pillar")
 	}
 
+	metricKey, err := s.metricKey(req.MetricKey)
+	if err != nil {
+		return domainkpiitem.KPIItem{}, err
+	}
+	if req.AppliesToTL && metricKey == nil {
+		return domainkpiitem.KPIItem{}, errors.New("metric_key is required for KPI items that apply to TL")
+	}
+
+	scoringType := strings.ToUpper(strings.TrimSpace(req.ScoringType))
+	if scoringType == "" {
+		scoringType = utils.ScoringLinearCapped
+	}
+	scoringParams, err := s.scoring(scoringType, req.ScoringParams)
+	if err != nil {
+		return domainkpiitem.KPIItem{}, err
+	}
+
 	entity := domainkpiitem.KPIItem{
 		Id:                utils.CreateUUID(),
 		PillarId:          req.PillarId,
 		Name:              name,
+		MetricKey:         metricKey,
+		ScoringType:       scoringType,
+		ScoringParams:     scoringParams,
 		Weight:            req.Weight,
 		TargetValue:       req.TargetValue,
 		Unit:              req.Unit,
@@ -80,7 +113,7 @@ func (s *ServiceKPIItem) GetByID(id string) (domainkpiitem.KPIItem, error) {
 }
 
 func (s *ServiceKPIItem) GetAll(params filter.BaseParams) ([]domainkpiitem.KPIItem, int64, error) {
-	params.Filters = filter.WhitelistFilter(params.Filters, []string{"pillar_id", "input_source", "applies_to_tl", "applies_to_salesman"})
+	params.Filters = filter.WhitelistFilter(params.Filters, []string{"pillar_id", "input_source", "applies_to_tl", "applies_to_salesman", "metric_key"})
 	return s.KPIRepo.GetAll(params)
 }
 
@@ -143,6 +176,36 @@ func (s *ServiceKPIItem) Update(id string, req dto.KPIItemUpdate, actorId string
 		kpi.Notes = req.Notes
 	}
 
+	if req.MetricKey != nil {
+		metricKey, err := s.metricKey(req.MetricKey)
+		if err != nil {
+			return domainkpiitem.KPIItem{}, err
+		}
+		kpi.MetricKey = metricKey
+	}
+	if kpi.AppliesToTL && kpi.MetricKey == nil {
+		return domainkpiitem.KPIItem{}, errors.New("metric_key is required for KPI items that apply to TL")
+	}
+
+	if req.ScoringType != nil || req.ScoringParams != nil {
+		if req.ScoringType != nil {
+			kpi.ScoringType = strings.ToUpper(strings.TrimSpace(*req.ScoringType))
+		}
+		if kpi.ScoringType == "" {
+			kpi.ScoringType = utils.ScoringLinearCapped
+		}
+		params := json.RawMessage(kpi.ScoringParams)
+		if req.ScoringParams != nil {
+			params = req.ScoringParams
+		}
+		// Parameters kept from the previous strategy are checked against the new one.
+		scoringParams, err := s.scoring(kpi.ScoringType, params)
+		if err != nil {
+			return domainkpiitem.KPIItem{}, err
+		}
+		kpi.ScoringParams = scoringParams
+	}
+
 	now := time.Now()
 	kpi.UpdatedAt = now
 	kpi.UpdatedBy = actorId
@@ -154,6 +217,48 @@ func (s *ServiceKPIItem) Update(id string, req dto.KPIItemUpdate, actorId string
 	return kpi, nil
 }
 
+// GetMetrics returns the metrics of the evaluation a KPI item can be bound to through metric_key.
+func (s *ServiceKPIItem) GetMetrics() []dto.MetricDefinition {
+	return s.Metrics.Metrics()
+}
+
+// GetScoringStrategies returns the scoring strategies a KPI item can use through scoring_type.
+func (s *ServiceKPIItem) GetScoringStrategies() []dto.ScoringStrategyDefinition {
+	return s.Scoring.ScoringStrategies()
+}
+
+// scoring validates the parameters of a scoring strategy and returns them as stored. Empty parameters
+// are stored as an empty object.
+func (s *ServiceKPIItem) scoring(scoringType string, params json.RawMessage) (domainkpiitem.ScoringParams, error) {
+	trimmed := bytes.TrimSpace(params)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		trimmed = []byte("{}")
+	}
+	if trimmed[0] != '{' {
+		return nil, errors.New("scoring_params must be a JSON object")
+	}
+	if err := s.Scoring.ValidateScoring(scoringType, trimmed); err != nil {
+		return nil, fmt.Errorf("%w, see GET /api/kpi-items/scoring-strategies", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, trimmed); err != nil {
+		return nil, fmt.Errorf("invalid scoring_params: %w", err)
+	}
+	return domainkpiitem.ScoringParams(compact.Bytes()), nil
+}
+
+// metricKey validates a metric key against the registry. An empty key means no metric.
+func (s *ServiceKPIItem) metricKey(key *string) (*string, error) {
+	if key == nil || strings.TrimSpace(*key) == "" {
+		return nil, nil
+	}
+	k := strings.ToLower(strings.TrimSpace(*key))
+	if !s.Metrics.HasMetric(k) {
+		return nil, fmt.Errorf("unknown metric_key %q, see GET /api/kpi-items/metrics", k)
+	}
+	return &k, nil
+}
+
 func (s *ServiceKPIItem) Delete(id string) error {
 	_, err := s.KPIRepo.GetByID(id)
 	if err != nil {
@@ -210,4 +315,48 @@ func (s *ServiceKPIItem) DeletePersonTarget(personId, kpiItemId string, periodMo
 	return s.PersonTargetRepo.Delete(personId, kpiItemId, periodMonth, periodYear)
 }
 
+// GetDealerTargets returns the default targets of a dealer, which apply to its persons without a target
+// of their own for the period.
+func (s *ServiceKPIItem) GetDealerTargets(dealerCode string) ([]domainkpiitem.DealerKPITarget, error) {
+	dealerCode = strings.TrimSpace(dealerCode)
+	if dealerCode == "" {
+		return nil, errors.New("dealer_code is required")
+	}
+	return s.DealerTargetRepo.GetByDealer(dealerCode)
+}
+
+func (s *ServiceKPIItem) UpsertDealerTarget(req dto.DealerKPITargetUpsert, actorId string) (domainkpiitem.DealerKPITarget, error) {
+	dealerCode := strings.TrimSpace(req.DealerCode)
+	if dealerCode == "" {
+		return domainkpiitem.DealerKPITarget{}, errors.New("dealer_code is required")
+	}
+	if _, err := s.KPIRepo.GetByID(req.KPIItemId); err != nil {
+		return domainkpiitem.DealerKPITarget{}, errors.New("kpi item not found")
+	}
+
+	now := time.Now()
+	target := domainkpiitem.DealerKPITarget{
+		Id:          utils.CreateUUID(),
+		DealerCode:  dealerCode,
+		KPIItemId:   req.KPIItemId,
+		TargetValue: req.TargetValue,
+		CreatedAt:   now,
+		CreatedBy:   actorId,
+		UpdatedAt:   now,
+		UpdatedBy:   actorId,
+	}
+	if err := s.DealerTargetRepo.Upsert(target); err != nil {
+		return domainkpiitem.DealerKPITarget{}, err
+	}
+
+	return s.DealerTargetRepo.Get(dealerCode, req.KPIItemId)
+}
+
+func (s *ServiceKPIItem) DeleteDealerTarget(dealerCode, kpiItemId string, actorId string) error {
+	if _, err := s.DealerTargetRepo.Get(dealerCode, kpiItemId); err != nil {
+		return err
+	}
+	return s.DealerTargetRepo.Delete(dealerCode, kpiItemId, actorId)
+}
+
 var _ interfacekpiitem.ServiceKPIItemInterface = (*ServiceKPIItem)(nil)
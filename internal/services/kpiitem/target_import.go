@@ -0,0 +1,276 @@
+package servicekpiitem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	domainkpiitem "teamleader-management/internal/domain/kpiitem"
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/file"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+// Outcome of a row of a bulk target import.
+const (
+	targetActionCreate = "CREATE"
+	targetActionUpdate = "UPDATE"
+	targetActionError  = "ERROR"
+)
+
+// targetColumns is the layout of the bulk target sheet; the person name is informative only.
+var targetColumns = []file.Column{
+	{Field: "honda_id", Aliases: []string{"Honda ID", "HondaID", "Honda Id"}, Required: true},
+	{Field: "person_name", Aliases: []string{"Nama", "Name", "Person Name"}},
+	{Field: "kpi_item", Aliases: []string{"KPI Item", "KPI Item Name", "KPI"}, Required: true},
+	{Field: "period_month", Aliases: []string{"Month", "Bulan", "Period Month"}, Required: true},
+	{Field: "period_year", Aliases: []string{"Year", "Tahun", "Period Year"}, Required: true},
+	{Field: "target_value", Aliases: []string{"Target", "Target Value"}, Required: true},
+}
+
+type targetKey struct {
+	personId    string
+	kpiItemId   string
+	periodMonth int
+	periodYear  int
+}
+
+// ImportPersonTargets upserts the person KPI targets of an XLSX or CSV file. Every row is validated first;
+// targets are only saved, in one transaction, when no row has an error and dryRun is false.
+func (s *ServiceKPIItem) ImportPersonTargets(upload io.Reader, fileName, contentType string, dryRun bool, actorId string) (dto.PersonKPITargetImportResponse, error) {
+	format, err := file.DetectFormat(fileName, contentType)
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+	if format == file.FormatJSON {
+		return dto.PersonKPITargetImportResponse{}, errors.New("unsupported file format, expected XLSX or CSV")
+	}
+
+	source, err := file.NewRowSource(format, upload)
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+	rows, err := source.Rows("", 2)
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+
+	cols, err := file.LocateColumns(rows[0], targetColumns)
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+
+	persons, items, err := s.lookupTargetRefs(rows[1:], cols)
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+
+	personIds := make([]string, 0, len(persons))
+	for _, p := range persons {
+		personIds = append(personIds, p.Id)
+	}
+	current, err := s.PersonTargetRepo.GetByPersons(personIds)
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+	existing := make(map[targetKey]domainkpiitem.PersonKPITarget, len(current))
+	for _, t := range current {
+		existing[targetKey{t.PersonId, t.KPIItemId, t.PeriodMonth, t.PeriodYear}] = t
+	}
+
+	res := dto.PersonKPITargetImportResponse{Rows: make([]dto.PersonKPITargetImportRow, 0, len(rows)-1)}
+	targets := make([]domainkpiitem.PersonKPITarget, 0, len(rows)-1)
+	seen := make(map[targetKey]int)
+	now := time.Now()
+	for i, row := range rows[1:] {
+		if file.IsBlankRow(row) {
+			continue
+		}
+
+		result, target := s.validateTargetRow(row, i+2, cols, persons, items)
+		if len(result.Errors) == 0 {
+			key := targetKey{target.PersonId, target.KPIItemId, target.PeriodMonth, target.PeriodYear}
+			if first, dup := seen[key]; dup {
+				result.Errors = append(result.Errors, fmt.Sprintf("duplicate of row %d", first))
+			} else {
+				seen[key] = result.RowNumber
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			result.Action = targetActionError
+			res.Failed++
+		} else if prev, ok := existing[targetKey{target.PersonId, target.KPIItemId, target.PeriodMonth, target.PeriodYear}]; ok {
+			result.Action = targetActionUpdate
+			res.Updated++
+			prev.TargetValue = target.TargetValue
+			prev.UpdatedAt = now
+			prev.UpdatedBy = actorId
+			targets = append(targets, prev)
+		} else {
+			result.Action = targetActionCreate
+			res.Created++
+			target.Id = utils.CreateUUID()
+			target.CreatedAt = now
+			target.CreatedBy = actorId
+			target.UpdatedAt = now
+			target.UpdatedBy = actorId
+			targets = append(targets, target)
+		}
+		res.Rows = append(res.Rows, result)
+	}
+	res.TotalRows = len(res.Rows)
+	if res.TotalRows == 0 {
+		return dto.PersonKPITargetImportResponse{}, errors.New("file has no data rows")
+	}
+
+	if dryRun || res.Failed > 0 {
+		return res, nil
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.PersonTargetRepo.WithTx(tx).UpsertMultiple(targets)
+	})
+	if err != nil {
+		return dto.PersonKPITargetImportResponse{}, err
+	}
+	res.Saved = true
+	return res, nil
+}
+
+// ExportPersonTargets writes the targets of a period as an XLSX file in the import layout,
+// so that it can be edited and imported again.
+func (s *ServiceKPIItem) ExportPersonTargets(req dto.PersonKPITargetExportRequest) (string, []byte, error) {
+	targets, err := s.PersonTargetRepo.GetByPeriod(req.PeriodMonth, req.PeriodYear, req.KPIItemId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows := make([][]string, 0, len(targets)+1)
+	rows = append(rows, file.HeaderRow(targetColumns))
+	for _, t := range targets {
+		rows = append(rows, []string{
+			t.HondaId,
+			t.PersonName,
+			t.KPIItemName,
+			strconv.Itoa(t.PeriodMonth),
+			strconv.Itoa(t.PeriodYear),
+			strconv.FormatFloat(t.TargetValue, 'f', -1, 64),
+		})
+	}
+
+	content, err := file.WriteExcelRows("Targets", rows)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("kpi_targets_%d-%02d.xlsx", req.PeriodYear, req.PeriodMonth), content, nil
+}
+
+// lookupTargetRefs loads the persons (by Honda ID) and KPI items (by lower-cased name) referenced by the rows.
+func (s *ServiceKPIItem) lookupTargetRefs(rows [][]string, cols map[string]int) (map[string]domainperson.Person, map[string][]domainkpiitem.KPIItem, error) {
+	hondaIds := make([]string, 0, len(rows))
+	names := make([]string, 0)
+	seenNames := make(map[string]bool)
+	for _, row := range rows {
+		if v := file.Cell(row, cols["honda_id"]); v != "" {
+			hondaIds = append(hondaIds, v)
+		}
+		if v := strings.ToLower(file.Cell(row, cols["kpi_item"])); v != "" && !seenNames[v] {
+			seenNames[v] = true
+			names = append(names, v)
+		}
+	}
+
+	personList, err := s.PersonRepo.GetByHondaIDs(hondaIds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up persons: %w", err)
+	}
+	persons := make(map[string]domainperson.Person, len(personList))
+	for _, p := range personList {
+		persons[p.HondaId] = p
+	}
+
+	itemList, err := s.KPIRepo.GetByNames(names)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up kpi items: %w", err)
+	}
+	items := make(map[string][]domainkpiitem.KPIItem, len(itemList))
+	for _, item := range itemList {
+		key := strings.ToLower(item.Name)
+		items[key] = append(items[key], item)
+	}
+	return persons, items, nil
+}
+
+// validateTargetRow checks one data row and returns its report line with the target it describes.
+func (s *ServiceKPIItem) validateTargetRow(row []string, rowNumber int, cols map[string]int, persons map[string]domainperson.Person, items map[string][]domainkpiitem.KPIItem) (dto.PersonKPITargetImportRow, domainkpiitem.PersonKPITarget) {
+	result := dto.PersonKPITargetImportRow{
+		RowNumber: rowNumber,
+		HondaId:   file.Cell(row, cols["honda_id"]),
+		KPIItem:   file.Cell(row, cols["kpi_item"]),
+	}
+	var target domainkpiitem.PersonKPITarget
+
+	person, personFound := persons[result.HondaId]
+	switch {
+	case result.HondaId == "":
+		result.Errors = append(result.Errors, "Honda ID is required")
+	case !personFound:
+		result.Errors = append(result.Errors, "person not found")
+	case !person.Active:
+		result.Errors = append(result.Errors, "person is inactive")
+	default:
+		target.PersonId = person.Id
+	}
+
+	matches := items[strings.ToLower(result.KPIItem)]
+	switch {
+	case result.KPIItem == "":
+		result.Errors = append(result.Errors, "KPI item is required")
+	case len(matches) == 0:
+		result.Errors = append(result.Errors, "kpi item not found")
+	case len(matches) > 1:
+		result.Errors = append(result.Errors, "kpi item name exists in several pillars")
+	default:
+		item := matches[0]
+		target.KPIItemId = item.Id
+		if personFound && person.Role == utils.RoleTL && !item.AppliesToTL {
+			result.Errors = append(result.Errors, "kpi item does not apply to team leaders")
+		}
+		if personFound && person.Role == utils.RoleSM && !item.AppliesToSalesman {
+			result.Errors = append(result.Errors, "kpi item does not apply to salesmen")
+		}
+	}
+
+	month, err := strconv.Atoi(file.Cell(row, cols["period_month"]))
+	if err != nil || month < 1 || month > 12 {
+		result.Errors = append(result.Errors, "month must be 1-12")
+	} else {
+		result.PeriodMonth = month
+		target.PeriodMonth = month
+	}
+
+	year, err := strconv.Atoi(file.Cell(row, cols["period_year"]))
+	if err != nil || year < 2000 {
+		result.Errors = append(result.Errors, "year must be >= 2000")
+	} else {
+		result.PeriodYear = year
+		target.PeriodYear = year
+	}
+
+	value, err := strconv.ParseFloat(file.Cell(row, cols["target_value"]), 64)
+	if err != nil {
+		result.Errors = append(result.Errors, "target must be a number")
+	} else {
+		result.TargetValue = &value
+		target.TargetValue = value
+	}
+
+	return result, target
+}
@@ -0,0 +1,278 @@
+package serviceperson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+	"teamleader-management/pkg/file"
+	"teamleader-management/pkg/filter"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+// Outcome of a row of a roster import.
+const (
+	rosterActionCreate    = "CREATE"
+	rosterActionUpdate    = "UPDATE"
+	rosterActionUnchanged = "UNCHANGED"
+	rosterActionError     = "ERROR"
+)
+
+// rosterColumns is the layout of the roster sheet used by the import and the export.
+var rosterColumns = []file.Column{
+	{Field: "honda_id", Aliases: []string{"Honda ID", "HondaID", "Honda Id"}, Required: true},
+	{Field: "name", Aliases: []string{"Nama", "Name"}},
+	{Field: "job_title", Aliases: []string{"Jabatan", "Job Title"}},
+	{Field: "role", Aliases: []string{"Role"}},
+	{Field: "dealer_code", Aliases: []string{"Kode Dealer", "Dealer Code"}},
+	{Field: "active", Aliases: []string{"Aktif", "Active"}},
+}
+
+// ImportRoster creates or updates persons from an XLSX or CSV roster, matching on Honda ID.
+// Blank cells keep the current value of existing persons. Every row is validated first; persons are only
+// saved, in one transaction, when no row has an error and dryRun is false. The report lists the field
+// changes of every row, so a dry run works as a diff against the current roster.
+func (s *ServicePerson) ImportRoster(upload io.Reader, fileName, contentType string, dryRun bool, actorId string) (dto.PersonImportResponse, error) {
+	format, err := file.DetectFormat(fileName, contentType)
+	if err != nil {
+		return dto.PersonImportResponse{}, err
+	}
+	if format == file.FormatJSON {
+		return dto.PersonImportResponse{}, errors.New("unsupported file format, expected XLSX or CSV")
+	}
+
+	source, err := file.NewRowSource(format, upload)
+	if err != nil {
+		return dto.PersonImportResponse{}, err
+	}
+	rows, err := source.Rows("", 2)
+	if err != nil {
+		return dto.PersonImportResponse{}, err
+	}
+
+	cols, err := file.LocateColumns(rows[0], rosterColumns)
+	if err != nil {
+		return dto.PersonImportResponse{}, err
+	}
+
+	hondaIds := make([]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		if v := file.Cell(row, cols["honda_id"]); v != "" {
+			hondaIds = append(hondaIds, v)
+		}
+	}
+	current, err := s.Repo.GetByHondaIDs(hondaIds)
+	if err != nil {
+		return dto.PersonImportResponse{}, fmt.Errorf("failed to look up persons: %w", err)
+	}
+	existing := make(map[string]domainperson.Person, len(current))
+	for _, p := range current {
+		existing[p.HondaId] = p
+	}
+
+	var (
+		res     = dto.PersonImportResponse{Rows: make([]dto.PersonImportRow, 0, len(rows)-1)}
+		creates []domainperson.Person
+		updates []domainperson.Person
+		seen    = make(map[string]int)
+		now     = time.Now()
+	)
+	for i, row := range rows[1:] {
+		if file.IsBlankRow(row) {
+			continue
+		}
+
+		result := dto.PersonImportRow{RowNumber: i + 2, HondaId: file.Cell(row, cols["honda_id"])}
+		if first, dup := seen[result.HondaId]; dup && result.HondaId != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate of row %d", first))
+		} else {
+			seen[result.HondaId] = result.RowNumber
+		}
+
+		person, isNew := existing[result.HondaId], false
+		if person.Id == "" {
+			isNew = true
+			person = domainperson.Person{HondaId: result.HondaId, Active: true}
+		}
+		changes, errs := applyRosterRow(&person, row, cols, isNew)
+		result.Errors = append(result.Errors, errs...)
+		result.Name = person.Name
+		result.PersonId = person.Id
+
+		switch {
+		case len(result.Errors) > 0:
+			result.Action = rosterActionError
+			res.Failed++
+		case isNew:
+			result.Action = rosterActionCreate
+			result.Changes = changes
+			res.Created++
+			person.Id = utils.CreateUUID()
+			person.CreatedAt = now
+			person.CreatedBy = actorId
+			person.UpdatedAt = now
+			person.UpdatedBy = actorId
+			creates = append(creates, person)
+		case len(changes) > 0:
+			result.Action = rosterActionUpdate
+			result.Changes = changes
+			res.Updated++
+			person.UpdatedAt = now
+			person.UpdatedBy = actorId
+			updates = append(updates, person)
+		default:
+			result.Action = rosterActionUnchanged
+			res.Unchanged++
+		}
+		res.Rows = append(res.Rows, result)
+	}
+	res.TotalRows = len(res.Rows)
+	if res.TotalRows == 0 {
+		return dto.PersonImportResponse{}, errors.New("file has no data rows")
+	}
+
+	if dryRun || res.Failed > 0 {
+		return res, nil
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		repo := s.Repo.WithTx(tx)
+		if err := repo.StoreMultiple(creates); err != nil {
+			return err
+		}
+		for _, p := range updates {
+			if err := repo.Update(p); err != nil {
+				return fmt.Errorf("failed to update %s: %w", p.HondaId, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.PersonImportResponse{}, err
+	}
+	res.Saved = true
+	return res, nil
+}
+
+// ExportRoster writes every person matching the list filters as an XLSX file in the import layout.
+func (s *ServicePerson) ExportRoster(params filter.BaseParams) (string, []byte, error) {
+	params.Filters = filter.WhitelistFilter(params.Filters, []string{"role", "active", "dealer_code"})
+	params.Offset = 0
+	params.Limit = -1
+	persons, _, err := s.Repo.GetAll(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows := make([][]string, 0, len(persons)+1)
+	rows = append(rows, file.HeaderRow(rosterColumns))
+	for _, p := range persons {
+		active := "FALSE"
+		if p.Active {
+			active = "TRUE"
+		}
+		rows = append(rows, []string{p.HondaId, p.Name, derefString(p.JobTitle), p.Role, derefString(p.DealerCode), active})
+	}
+
+	content, err := file.WriteExcelRows("Persons", rows)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("persons_%s.xlsx", time.Now().Format("2006-01-02")), content, nil
+}
+
+// applyRosterRow copies the non-blank cells of row onto person and returns the changed fields with the
+// validation errors. Name and role are required for new persons.
+func applyRosterRow(person *domainperson.Person, row []string, cols map[string]int, isNew bool) (map[string]dto.PersonFieldChange, []string) {
+	changes := make(map[string]dto.PersonFieldChange)
+	var errs []string
+
+	if len(person.HondaId) == 0 {
+		errs = append(errs, "Honda ID is required")
+	} else if len(person.HondaId) > 20 {
+		errs = append(errs, "Honda ID must be at most 20 characters")
+	}
+
+	if name := file.Cell(row, cols["name"]); name != "" {
+		if len(name) < 3 || len(name) > 150 {
+			errs = append(errs, "name must be 3-150 characters")
+		} else if name != person.Name {
+			changes["name"] = dto.PersonFieldChange{From: nullableString(person.Name), To: name}
+			person.Name = name
+		}
+	} else if isNew {
+		errs = append(errs, "name is required")
+	}
+
+	if jobTitle := file.Cell(row, cols["job_title"]); jobTitle != "" {
+		if len(jobTitle) < 2 || len(jobTitle) > 150 {
+			errs = append(errs, "job title must be 2-150 characters")
+		} else if jobTitle != derefString(person.JobTitle) {
+			changes["job_title"] = dto.PersonFieldChange{From: person.JobTitle, To: jobTitle}
+			person.JobTitle = &jobTitle
+		}
+	}
+
+	if role := strings.ToLower(file.Cell(row, cols["role"])); role != "" {
+		if err := utils.ValidateRole(role); err != nil {
+			errs = append(errs, err.Error())
+		} else if role != person.Role {
+			changes["role"] = dto.PersonFieldChange{From: nullableString(person.Role), To: role}
+			person.Role = role
+		}
+	} else if isNew {
+		errs = append(errs, "role is required")
+	}
+
+	if dealerCode := file.Cell(row, cols["dealer_code"]); dealerCode != "" && dealerCode != derefString(person.DealerCode) {
+		changes["dealer_code"] = dto.PersonFieldChange{From: person.DealerCode, To: dealerCode}
+		person.DealerCode = &dealerCode
+	}
+
+	if v := file.Cell(row, cols["active"]); v != "" {
+		active, err := parseActive(v)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else if active != person.Active || isNew {
+			var from interface{}
+			if !isNew {
+				from = person.Active
+			}
+			changes["active"] = dto.PersonFieldChange{From: from, To: active}
+			person.Active = active
+		}
+	}
+
+	return changes, errs
+}
+
+func parseActive(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "true", "yes", "y", "1", "aktif", "active":
+		return true, nil
+	case "false", "no", "n", "0", "tidak aktif", "nonaktif", "inactive":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid active value: %s", v)
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// nullableString reports an empty value as null in the change list.
+func nullableString(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
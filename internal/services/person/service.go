@@ -8,14 +8,18 @@ import (
 	"teamleader-management/pkg/filter"
 	"teamleader-management/utils"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type ServicePerson struct {
-	Repo interfaceperson.RepoPersonInterface
+	Repo        interfaceperson.RepoPersonInterface
+	Supervisors interfaceperson.RepoSupervisorAssignmentInterface
+	DB          *gorm.DB
 }
 
-func NewPersonService(repo interfaceperson.RepoPersonInterface) *ServicePerson {
-	return &ServicePerson{Repo: repo}
+func NewPersonService(repo interfaceperson.RepoPersonInterface, supervisors interfaceperson.RepoSupervisorAssignmentInterface, db *gorm.DB) *ServicePerson {
+	return &ServicePerson{Repo: repo, Supervisors: supervisors, DB: db}
 }
 
 func (s *ServicePerson) Create(req dto.PersonCreate, actorId string) (domainperson.Person, error) {
@@ -60,7 +64,7 @@ func (s *ServicePerson) GetByID(id string) (domainperson.Person, error) {
 }
 
 func (s *ServicePerson) GetAll(params filter.BaseParams) ([]domainperson.Person, int64, error) {
-	params.Filters = filter.WhitelistFilter(params.Filters, []string{"role", "active", "dealer_code"})
+	params.Filters = filter.WhitelistFilter(params.Filters, []string{"role", "active", "dealer_code", "supervisor_id"})
 	return s.Repo.GetAll(params)
 }
 
@@ -111,12 +115,38 @@ func (s *ServicePerson) Update(id string, req dto.PersonUpdate, actorId string)
 	return person, nil
 }
 
-func (s *ServicePerson) Delete(id string) error {
-	_, err := s.Repo.GetByID(id)
+// Delete deactivates a person. A deactivated salesman leaves their team from today on.
+func (s *ServicePerson) Delete(id string, actorId string) error {
+	person, err := s.Repo.GetByID(id)
 	if err != nil {
 		return err
 	}
-	return s.Repo.Deactivate(id)
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		repo, supervisors := s.Repo.WithTx(tx), s.Supervisors.WithTx(tx)
+		if err := repo.Deactivate(id); err != nil {
+			return err
+		}
+		current, err := supervisors.GetCurrent(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// An assignment that started today ends tomorrow, its range cannot be empty.
+		end := today()
+		if !end.After(current.EffectiveFrom) {
+			end = current.EffectiveFrom.AddDate(0, 0, 1)
+		}
+		if err := supervisors.End(current.Id, end, actorId); err != nil {
+			return err
+		}
+		person.Active = false
+		person.SupervisorId = nil
+		person.UpdatedAt = time.Now()
+		person.UpdatedBy = actorId
+		return repo.Update(person)
+	})
 }
 
 var _ interfaceperson.ServicePersonInterface = (*ServicePerson)(nil)
@@ -0,0 +1,213 @@
+package serviceperson
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	domainperson "teamleader-management/internal/domain/person"
+	"teamleader-management/internal/dto"
+	interfaceperson "teamleader-management/internal/interfaces/person"
+	"teamleader-management/utils"
+
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// AssignSupervisor moves a salesman to the team of a TL from req.EffectiveFrom on, closing their current
+// assignment the day before. An empty supervisor takes the salesman out of any team.
+func (s *ServicePerson) AssignSupervisor(personId string, req dto.SupervisorAssign, actorId string) (domainperson.Person, error) {
+	effectiveFrom, err := assignmentDate(req.EffectiveFrom)
+	if err != nil {
+		return domainperson.Person{}, err
+	}
+	supervisorId := ""
+	if req.SupervisorId != nil {
+		supervisorId = *req.SupervisorId
+	}
+	if supervisorId != "" {
+		if err := s.checkSupervisor(supervisorId); err != nil {
+			return domainperson.Person{}, err
+		}
+	}
+
+	var person domainperson.Person
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		person, err = s.assign(s.Repo.WithTx(tx), s.Supervisors.WithTx(tx), personId, supervisorId, effectiveFrom, req.Reason, actorId)
+		return err
+	})
+	if err != nil {
+		return domainperson.Person{}, err
+	}
+	return person, nil
+}
+
+// TransferTeam moves salesmen of one TL to another on the same day, all of the TL's current team when no
+// person is given. Nobody is moved when any of them cannot be.
+func (s *ServicePerson) TransferTeam(req dto.TeamTransfer, actorId string) (dto.TeamTransferResponse, error) {
+	if req.FromSupervisorId == req.ToSupervisorId {
+		return dto.TeamTransferResponse{}, errors.New("from_supervisor_id and to_supervisor_id must differ")
+	}
+	effectiveFrom, err := assignmentDate(req.EffectiveFrom)
+	if err != nil {
+		return dto.TeamTransferResponse{}, err
+	}
+	if err := s.checkSupervisor(req.ToSupervisorId); err != nil {
+		return dto.TeamTransferResponse{}, err
+	}
+
+	personIds := req.PersonIds
+	if len(personIds) == 0 {
+		personIds, err = s.Supervisors.GetTeamMemberIds(req.FromSupervisorId, today())
+		if err != nil {
+			return dto.TeamTransferResponse{}, err
+		}
+		if len(personIds) == 0 {
+			return dto.TeamTransferResponse{}, errors.New("the team of from_supervisor_id has no member")
+		}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		repo, supervisors := s.Repo.WithTx(tx), s.Supervisors.WithTx(tx)
+		for _, personId := range personIds {
+			current, err := supervisors.GetCurrent(personId)
+			if err != nil || current.SupervisorId != req.FromSupervisorId {
+				return fmt.Errorf("person %s is not in the team of from_supervisor_id", personId)
+			}
+			if _, err := s.assign(repo, supervisors, personId, req.ToSupervisorId, effectiveFrom, req.Reason, actorId); err != nil {
+				return fmt.Errorf("person %s: %w", personId, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.TeamTransferResponse{}, err
+	}
+
+	return dto.TeamTransferResponse{
+		Transferred:   len(personIds),
+		PersonIds:     personIds,
+		EffectiveFrom: effectiveFrom.Format(dateLayout),
+	}, nil
+}
+
+// GetSupervisorHistory returns the team assignments of a person, the latest first.
+func (s *ServicePerson) GetSupervisorHistory(personId string) ([]domainperson.SupervisorAssignment, error) {
+	if _, err := s.Repo.GetByID(personId); err != nil {
+		return nil, err
+	}
+	return s.Supervisors.GetHistory(personId)
+}
+
+// GetTeam returns the persons reporting to a TL on a date.
+func (s *ServicePerson) GetTeam(supervisorId string, date time.Time) ([]domainperson.Person, error) {
+	if _, err := s.Repo.GetByID(supervisorId); err != nil {
+		return nil, err
+	}
+	ids, err := s.Supervisors.GetTeamMemberIds(supervisorId, date)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.GetByIDs(ids)
+}
+
+// assign closes the current assignment of a salesman and opens the new one, keeping persons.supervisor_id
+// on the current supervisor.
+func (s *ServicePerson) assign(repo interfaceperson.RepoPersonInterface, supervisors interfaceperson.RepoSupervisorAssignmentInterface, personId, supervisorId string, effectiveFrom time.Time, reason *string, actorId string) (domainperson.Person, error) {
+	person, err := repo.GetByID(personId)
+	if err != nil {
+		return domainperson.Person{}, err
+	}
+	if person.Role != utils.RoleSM {
+		return domainperson.Person{}, errors.New("only salesmen can be assigned to a team")
+	}
+	if person.Id == supervisorId {
+		return domainperson.Person{}, errors.New("a person cannot supervise themselves")
+	}
+
+	history, err := supervisors.GetHistory(personId)
+	if err != nil {
+		return domainperson.Person{}, err
+	}
+	if len(history) > 0 {
+		latest := history[0]
+		if latest.EffectiveTo == nil {
+			if latest.SupervisorId == supervisorId {
+				return domainperson.Person{}, errors.New("person already reports to this supervisor")
+			}
+			if !effectiveFrom.After(latest.EffectiveFrom) {
+				return domainperson.Person{}, fmt.Errorf("effective_from must be after %s, the start of the current assignment", latest.EffectiveFrom.Format(dateLayout))
+			}
+			if err := supervisors.End(latest.Id, effectiveFrom, actorId); err != nil {
+				return domainperson.Person{}, err
+			}
+		} else if effectiveFrom.Before(*latest.EffectiveTo) {
+			return domainperson.Person{}, fmt.Errorf("effective_from must not be before %s, the end of the last assignment", latest.EffectiveTo.Format(dateLayout))
+		}
+	}
+	if supervisorId == "" && (len(history) == 0 || history[0].EffectiveTo != nil) {
+		return domainperson.Person{}, errors.New("person is not in any team")
+	}
+
+	now := time.Now()
+	if supervisorId != "" {
+		assignment := domainperson.SupervisorAssignment{
+			Id:            utils.CreateUUID(),
+			PersonId:      personId,
+			SupervisorId:  supervisorId,
+			EffectiveFrom: effectiveFrom,
+			Reason:        reason,
+			CreatedAt:     now,
+			CreatedBy:     actorId,
+			UpdatedAt:     now,
+			UpdatedBy:     actorId,
+		}
+		if err := supervisors.Store(assignment); err != nil {
+			return domainperson.Person{}, err
+		}
+		person.SupervisorId = &supervisorId
+	} else {
+		person.SupervisorId = nil
+	}
+
+	person.UpdatedAt = now
+	person.UpdatedBy = actorId
+	if err := repo.Update(person); err != nil {
+		return domainperson.Person{}, err
+	}
+	return person, nil
+}
+
+// checkSupervisor checks that a person can lead a team.
+func (s *ServicePerson) checkSupervisor(supervisorId string) error {
+	supervisor, err := s.Repo.GetByID(supervisorId)
+	if err != nil {
+		return errors.New("supervisor not found")
+	}
+	if supervisor.Role != utils.RoleTL {
+		return errors.New("supervisor must be a team leader")
+	}
+	if !supervisor.Active {
+		return errors.New("supervisor is not active")
+	}
+	return nil
+}
+
+// assignmentDate returns the day an assignment starts: today by default, never in the future.
+func assignmentDate(date *time.Time) (time.Time, error) {
+	if date == nil {
+		return today(), nil
+	}
+	d := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if d.After(today()) {
+		return time.Time{}, errors.New("effective_from cannot be in the future")
+	}
+	return d, nil
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
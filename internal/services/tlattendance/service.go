@@ -7,6 +7,7 @@ import (
 
 	domaintlattendance "teamleader-management/internal/domain/tlattendance"
 	"teamleader-management/internal/dto"
+	interfaceperson "teamleader-management/internal/interfaces/person"
 	interfacetlattendance "teamleader-management/internal/interfaces/tlattendance"
 	"teamleader-management/pkg/filter"
 
@@ -15,13 +16,19 @@ import (
 
 type ServiceTLAttendance struct {
 	Repo interfacetlattendance.RepoTLAttendanceInterface
+	Team interfaceperson.RepoSupervisorAssignmentInterface
 }
 
-func NewTLAttendanceService(repo interfacetlattendance.RepoTLAttendanceInterface) *ServiceTLAttendance {
-	return &ServiceTLAttendance{Repo: repo}
+func NewTLAttendanceService(repo interfacetlattendance.RepoTLAttendanceInterface, team interfaceperson.RepoSupervisorAssignmentInterface) *ServiceTLAttendance {
+	return &ServiceTLAttendance{Repo: repo, Team: team}
 }
 
 func (s *ServiceTLAttendance) Create(personId string, req dto.TLAttendanceCreate, actorId string) ([]domaintlattendance.TLAttendanceRecord, error) {
+	salesmen, err := s.Team.TeamMembers(personId, req.Date, attendanceSalesmanIds(req.Attendance))
+	if err != nil {
+		return nil, err
+	}
+
 	recordUniqueId := uuid.New().String()
 	now := time.Now()
 
@@ -31,7 +38,7 @@ func (s *ServiceTLAttendance) Create(personId string, req dto.TLAttendanceCreate
 			Id:             uuid.New().String(),
 			TlPersonId:     personId,
 			SalesmanId:     att.SalesmanPersonId,
-			SalesmanName:   att.SalesmanName,
+			SalesmanName:   salesmen[att.SalesmanPersonId].Name,
 			Date:           req.Date,
 			Status:         att.Status,
 			RecordUniqueId: recordUniqueId,
@@ -93,10 +100,6 @@ func (s *ServiceTLAttendance) Update(recordUniqueId string, personId string, req
 		return nil, errors.New("unauthorized access to this attendance record")
 	}
 
-	if err := s.Repo.DeleteByRecordUniqueId(recordUniqueId); err != nil {
-		return nil, err
-	}
-
 	dateToUse := existingRecords[0].Date
 	if req.Date != nil {
 		dateToUse = *req.Date
@@ -113,6 +116,15 @@ func (s *ServiceTLAttendance) Update(recordUniqueId string, personId string, req
 		}
 	}
 
+	salesmen, err := s.Team.TeamMembers(personId, dateToUse, attendanceSalesmanIds(attendanceToUse))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.DeleteByRecordUniqueId(recordUniqueId); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	var newRecords []domaintlattendance.TLAttendanceRecord
 	for _, att := range attendanceToUse {
@@ -120,7 +132,7 @@ func (s *ServiceTLAttendance) Update(recordUniqueId string, personId string, req
 			Id:             uuid.New().String(),
 			TlPersonId:     personId,
 			SalesmanId:     att.SalesmanPersonId,
-			SalesmanName:   att.SalesmanName,
+			SalesmanName:   salesmen[att.SalesmanPersonId].Name,
 			Date:           dateToUse,
 			Status:         att.Status,
 			RecordUniqueId: recordUniqueId,
@@ -161,4 +173,12 @@ func (s *ServiceTLAttendance) Delete(recordUniqueId string, personId string) err
 	return s.Repo.DeleteByRecordUniqueId(recordUniqueId)
 }
 
+func attendanceSalesmanIds(attendance []dto.AttendanceRecord) []string {
+	ids := make([]string, 0, len(attendance))
+	for _, att := range attendance {
+		ids = append(ids, att.SalesmanPersonId)
+	}
+	return ids
+}
+
 var _ interfacetlattendance.ServiceTLAttendanceInterface = (*ServiceTLAttendance)(nil)
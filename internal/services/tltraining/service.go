@@ -7,6 +7,7 @@ import (
 
 	domaintltraining "teamleader-management/internal/domain/tltraining"
 	"teamleader-management/internal/dto"
+	interfaceperson "teamleader-management/internal/interfaces/person"
 	interfacetltraining "teamleader-management/internal/interfaces/tltraining"
 	"teamleader-management/pkg/filter"
 
@@ -15,13 +16,23 @@ import (
 
 type ServiceTLTraining struct {
 	Repo interfacetltraining.RepoTLTrainingInterface
+	Team interfaceperson.RepoSupervisorAssignmentInterface
 }
 
-func NewTLTrainingService(repo interfacetltraining.RepoTLTrainingInterface) *ServiceTLTraining {
-	return &ServiceTLTraining{Repo: repo}
+func NewTLTrainingService(repo interfacetltraining.RepoTLTrainingInterface, team interfaceperson.RepoSupervisorAssignmentInterface) *ServiceTLTraining {
+	return &ServiceTLTraining{Repo: repo, Team: team}
 }
 
 func (s *ServiceTLTraining) Create(personId string, req dto.TLTrainingCreate, actorId string) ([]domaintltraining.TLTrainingParticipation, error) {
+	salesmanIds := make([]string, 0, len(req.Participants))
+	for _, participant := range req.Participants {
+		salesmanIds = append(salesmanIds, participant.SalesmanPersonId)
+	}
+	salesmen, err := s.Team.TeamMembers(personId, req.Date, salesmanIds)
+	if err != nil {
+		return nil, err
+	}
+
 	trainingBatch := uuid.New().String()
 	now := time.Now()
 
@@ -33,7 +44,7 @@ func (s *ServiceTLTraining) Create(personId string, req dto.TLTrainingCreate, ac
 			TrainingName:  req.TrainingName,
 			Date:          req.Date,
 			SalesmanId:    participant.SalesmanPersonId,
-			SalesmanName:  participant.SalesmanName,
+			SalesmanName:  salesmen[participant.SalesmanPersonId].Name,
 			Status:        participant.Status,
 			TrainingBatch: trainingBatch,
 			CreatedAt:     now,
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_dashboard_datasets_status;
+
+ALTER TABLE IF EXISTS dashboard_datasets
+    DROP COLUMN IF EXISTS finished_at,
+    DROP COLUMN IF EXISTS started_at,
+    DROP COLUMN IF EXISTS error_message,
+    DROP COLUMN IF EXISTS processed_rows,
+    DROP COLUMN IF EXISTS total_rows;
@@ -0,0 +1,13 @@
+ALTER TABLE IF EXISTS dashboard_datasets
+    ADD COLUMN IF NOT EXISTS total_rows INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS processed_rows INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS error_message TEXT,
+    ADD COLUMN IF NOT EXISTS started_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP;
+
+DO $$
+BEGIN
+  IF to_regclass('dashboard_datasets') IS NOT NULL THEN
+    CREATE INDEX IF NOT EXISTS idx_dashboard_datasets_status ON dashboard_datasets(status);
+  END IF;
+END$$;
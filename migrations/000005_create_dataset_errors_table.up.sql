@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS dashboard_dataset_errors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset_id UUID NOT NULL,
+    row_number INT NOT NULL,
+    column_name VARCHAR(150),
+    value TEXT,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dashboard_dataset_errors_dataset_id ON dashboard_dataset_errors(dataset_id, row_number);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS dataset_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset_type VARCHAR(50) NOT NULL,
+    sheet_name VARCHAR(100) NOT NULL DEFAULT '',
+    header_row INT NOT NULL DEFAULT 1,
+    columns JSONB NOT NULL DEFAULT '{}'::jsonb,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR(100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dataset_templates_type ON dataset_templates(dataset_type) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_dataset_templates_deleted_at ON dataset_templates(deleted_at);
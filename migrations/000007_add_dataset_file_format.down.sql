@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS dashboard_datasets
+    DROP COLUMN IF EXISTS file_format;
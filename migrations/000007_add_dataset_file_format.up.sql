@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS dashboard_datasets
+    ADD COLUMN IF NOT EXISTS file_format VARCHAR(10) NOT NULL DEFAULT 'xlsx';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_dashboard_datasets_period;
+DROP INDEX IF EXISTS idx_dashboard_datasets_content_hash;
+
+ALTER TABLE IF EXISTS dashboard_datasets
+    DROP COLUMN IF EXISTS superseded_by,
+    DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,11 @@
+ALTER TABLE IF EXISTS dashboard_datasets
+    ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS superseded_by UUID;
+
+DO $$
+BEGIN
+  IF to_regclass('dashboard_datasets') IS NOT NULL THEN
+    CREATE INDEX IF NOT EXISTS idx_dashboard_datasets_content_hash ON dashboard_datasets(type, content_hash);
+    CREATE INDEX IF NOT EXISTS idx_dashboard_datasets_period ON dashboard_datasets(type, period_date, period_frequency);
+  END IF;
+END$$;
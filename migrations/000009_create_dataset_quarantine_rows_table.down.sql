@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS dashboard_datasets
+    DROP COLUMN IF EXISTS quarantined_rows;
+
+DROP TABLE IF EXISTS dataset_quarantine_rows;
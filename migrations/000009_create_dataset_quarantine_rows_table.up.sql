@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS dataset_quarantine_rows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset_id UUID NOT NULL,
+    dataset_type VARCHAR(50) NOT NULL,
+    period_date DATE NOT NULL,
+    row_number INT NOT NULL,
+    honda_id VARCHAR(50) NOT NULL,
+    dealer_code VARCHAR(50),
+    raw_payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    field_values JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    resolved_person_id UUID,
+    resolved_at TIMESTAMP,
+    resolved_by VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR(100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_quarantine_rows_dataset_id ON dataset_quarantine_rows(dataset_id, row_number);
+CREATE INDEX IF NOT EXISTS idx_dataset_quarantine_rows_status ON dataset_quarantine_rows(status, honda_id);
+CREATE INDEX IF NOT EXISTS idx_dataset_quarantine_rows_deleted_at ON dataset_quarantine_rows(deleted_at);
+
+ALTER TABLE IF EXISTS dashboard_datasets
+    ADD COLUMN IF NOT EXISTS quarantined_rows INT NOT NULL DEFAULT 0;
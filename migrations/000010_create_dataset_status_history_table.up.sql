@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS dataset_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dataset_id UUID NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    changed_by VARCHAR(100),
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_status_history_dataset_id ON dataset_status_history(dataset_id, changed_at);
+
+-- Existing datasets start their history with their current status.
+DO $$
+BEGIN
+  IF to_regclass('dashboard_datasets') IS NOT NULL THEN
+    INSERT INTO dataset_status_history (dataset_id, from_status, to_status, reason, changed_by, changed_at)
+    SELECT d.id, NULL, d.status, 'recorded before status history was kept', COALESCE(d.updated_by, d.uploaded_by), COALESCE(d.updated_at, d.uploaded_at, CURRENT_TIMESTAMP)
+    FROM dashboard_datasets d
+    WHERE NOT EXISTS (SELECT 1 FROM dataset_status_history h WHERE h.dataset_id = d.id);
+  END IF;
+END$$;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dataset_connector_runs;
+DROP TABLE IF EXISTS dataset_connectors;
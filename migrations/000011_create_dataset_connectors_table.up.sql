@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS dataset_connectors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(150) NOT NULL,
+    url TEXT NOT NULL,
+    auth_header VARCHAR(100),
+    auth_value TEXT,
+    format VARCHAR(10) NOT NULL,
+    dataset_type VARCHAR(50) NOT NULL,
+    period_frequency VARCHAR(20) NOT NULL DEFAULT 'DAILY',
+    period_lag_days INT NOT NULL DEFAULT 0,
+    schedule VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_run_status VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR(100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_connectors_next_run_at ON dataset_connectors(active, next_run_at);
+CREATE INDEX IF NOT EXISTS idx_dataset_connectors_deleted_at ON dataset_connectors(deleted_at);
+
+CREATE TABLE IF NOT EXISTS dataset_connector_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    connector_id UUID NOT NULL,
+    trigger_type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    http_status INT,
+    dataset_id UUID,
+    message TEXT,
+    bytes_read BIGINT NOT NULL DEFAULT 0,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    triggered_by VARCHAR(100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_connector_runs_connector_id ON dataset_connector_runs(connector_id, started_at DESC);
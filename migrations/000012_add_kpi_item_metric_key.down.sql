@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_kpi_items_metric_key;
+
+ALTER TABLE IF EXISTS kpi_items
+    DROP COLUMN IF EXISTS metric_key;
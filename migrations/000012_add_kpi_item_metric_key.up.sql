@@ -0,0 +1,30 @@
+ALTER TABLE IF EXISTS kpi_items
+    ADD COLUMN IF NOT EXISTS metric_key VARCHAR(100);
+
+-- Bind the existing KPI items to their metric by the names the calculator used to map;
+-- items with any other name stay unbound and must be given a metric_key before the next evaluation.
+DO $$
+BEGIN
+  IF to_regclass('kpi_items') IS NOT NULL THEN
+    UPDATE kpi_items k
+    SET metric_key = m.metric_key
+    FROM (VALUES
+        ('Quantity Activity', 'quantity_activity'),
+        ('Sales FLP', 'sales_flp'),
+        ('Disiplin & Kehadiran Tim', 'attendance'),
+        ('Sesi Coaching', 'coaching_sessions'),
+        ('Sesi Briefing', 'briefing_sessions'),
+        ('Jumlah Tim', 'team_size'),
+        ('Kuis', 'quiz_score'),
+        ('Partisipasi Training', 'training_participation'),
+        ('Login Apple', 'apple_logins'),
+        ('Point Apple', 'apple_points'),
+        ('Point my Hero', 'myhero_points'),
+        ('Jumlah Prospek', 'total_prospects'),
+        ('Ratio Prospek', 'prospect_ratio')
+    ) AS m(name, metric_key)
+    WHERE k.name = m.name AND k.metric_key IS NULL;
+
+    CREATE INDEX IF NOT EXISTS idx_kpi_items_metric_key ON kpi_items(metric_key);
+  END IF;
+END$$;
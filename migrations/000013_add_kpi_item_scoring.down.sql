@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS kpi_items
+    DROP COLUMN IF EXISTS scoring_params,
+    DROP COLUMN IF EXISTS scoring_type;
@@ -0,0 +1,4 @@
+-- Existing KPI items keep the linear scoring capped at 100% of the target they were scored with so far.
+ALTER TABLE IF EXISTS kpi_items
+    ADD COLUMN IF NOT EXISTS scoring_type VARCHAR(50) NOT NULL DEFAULT 'LINEAR_CAPPED',
+    ADD COLUMN IF NOT EXISTS scoring_params JSONB NOT NULL DEFAULT '{}'::jsonb;
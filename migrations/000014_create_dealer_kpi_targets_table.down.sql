@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS evaluation_details
+    DROP COLUMN IF EXISTS target_source,
+    DROP COLUMN IF EXISTS target_value;
+
+DROP TABLE IF EXISTS dealer_kpi_targets;
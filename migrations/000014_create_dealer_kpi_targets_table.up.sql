@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS dealer_kpi_targets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dealer_code VARCHAR(50) NOT NULL,
+    kpi_item_id UUID NOT NULL,
+    target_value NUMERIC(15, 2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR(100)
+);
+
+-- One row per dealer and KPI item; deleting soft-deletes it and saving the target again revives it.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_dealer_kpi_targets_dealer_kpi ON dealer_kpi_targets(dealer_code, kpi_item_id);
+CREATE INDEX IF NOT EXISTS idx_dealer_kpi_targets_deleted_at ON dealer_kpi_targets(deleted_at);
+
+-- Evaluation details keep the target they were scored against and where it came from
+-- (PERSON, DEALER or KPI); details scored before have no source and used the KPI target.
+ALTER TABLE IF EXISTS evaluation_details
+    ADD COLUMN IF NOT EXISTS target_value NUMERIC(15, 2),
+    ADD COLUMN IF NOT EXISTS target_source VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS person_supervisor_assignments;
+
+DROP INDEX IF EXISTS idx_persons_supervisor_id;
+
+ALTER TABLE IF EXISTS persons
+    DROP COLUMN IF EXISTS supervisor_id;
@@ -0,0 +1,52 @@
+ALTER TABLE IF EXISTS persons
+    ADD COLUMN IF NOT EXISTS supervisor_id UUID;
+
+CREATE TABLE IF NOT EXISTS person_supervisor_assignments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    person_id UUID NOT NULL,
+    supervisor_id UUID NOT NULL,
+    effective_from DATE NOT NULL,
+    effective_to DATE,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(100),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by VARCHAR(100),
+    CONSTRAINT chk_person_supervisor_assignments_range CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+-- At most one current assignment per person; team lookups go by supervisor and date.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_person_supervisor_assignments_current ON person_supervisor_assignments(person_id) WHERE effective_to IS NULL;
+CREATE INDEX IF NOT EXISTS idx_person_supervisor_assignments_supervisor ON person_supervisor_assignments(supervisor_id, effective_from, effective_to);
+
+DO $$
+BEGIN
+  IF to_regclass('persons') IS NOT NULL THEN
+    CREATE INDEX IF NOT EXISTS idx_persons_supervisor_id ON persons(supervisor_id);
+  END IF;
+
+  -- Until now the only link between a salesman and a TL was the attendance the TL recorded: start the
+  -- history of each active salesman with the TL who recorded their latest attendance, from the first
+  -- attendance that TL recorded for them.
+  IF to_regclass('persons') IS NOT NULL AND to_regclass('tl_attendance_records') IS NOT NULL THEN
+    INSERT INTO person_supervisor_assignments (person_id, supervisor_id, effective_from, reason, created_by, updated_by)
+    SELECT latest.salesman_id, latest.tl_person_id,
+           (SELECT MIN(a.date)::date FROM tl_attendance_records a
+            WHERE a.salesman_id = latest.salesman_id AND a.tl_person_id = latest.tl_person_id AND a.deleted_at IS NULL),
+           'derived from the attendance recorded before team assignments were kept', 'system', 'system'
+    FROM (
+        SELECT DISTINCT ON (r.salesman_id) r.salesman_id::uuid AS salesman_id, r.tl_person_id::uuid AS tl_person_id
+        FROM tl_attendance_records r
+        INNER JOIN persons p ON p.id::text = r.salesman_id::text AND p.role = 'salesman' AND p.active = true AND p.deleted_at IS NULL
+        INNER JOIN persons tl ON tl.id::text = r.tl_person_id::text AND tl.role = 'teamleader' AND tl.deleted_at IS NULL
+        WHERE r.deleted_at IS NULL
+        ORDER BY r.salesman_id, r.date DESC
+    ) latest
+    WHERE NOT EXISTS (SELECT 1 FROM person_supervisor_assignments h WHERE h.person_id = latest.salesman_id);
+
+    UPDATE persons p
+    SET supervisor_id = h.supervisor_id
+    FROM person_supervisor_assignments h
+    WHERE h.person_id = p.id AND h.effective_to IS NULL AND p.supervisor_id IS NULL;
+  END IF;
+END$$;
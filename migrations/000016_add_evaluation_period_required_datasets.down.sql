@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS evaluation_periods
+    DROP COLUMN IF EXISTS updated_by,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS required_datasets;
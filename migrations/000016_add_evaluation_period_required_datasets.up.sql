@@ -0,0 +1,6 @@
+-- Dataset types required before a period is evaluated, overriding EVALUATION_REQUIRED_DATASETS for that
+-- period. NULL keeps the default; an empty array requires nothing.
+ALTER TABLE IF EXISTS evaluation_periods
+    ADD COLUMN IF NOT EXISTS required_datasets TEXT[],
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS updated_by VARCHAR(100);
@@ -0,0 +1,9 @@
+DO $$
+BEGIN
+  IF to_regclass('dashboard_datasets') IS NOT NULL THEN
+    UPDATE dashboard_datasets SET total_rows = 0 WHERE total_rows IS NULL;
+  END IF;
+END$$;
+
+ALTER TABLE IF EXISTS dashboard_datasets
+    ALTER COLUMN total_rows SET DEFAULT 0;
@@ -0,0 +1,10 @@
+-- total_rows is NULL until the whole file was read; imports still queued or running do not know it yet.
+ALTER TABLE IF EXISTS dashboard_datasets
+    ALTER COLUMN total_rows DROP DEFAULT;
+
+DO $$
+BEGIN
+  IF to_regclass('dashboard_datasets') IS NOT NULL THEN
+    UPDATE dashboard_datasets SET total_rows = NULL WHERE status IN ('UPLOADED', 'PROCESSING');
+  END IF;
+END$$;
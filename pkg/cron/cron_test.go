@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"too few fields", "* * * *"},
+		{"too many fields", "* * * * * *"},
+		{"minute out of range", "60 * * * *"},
+		{"day of month zero", "0 0 0 * *"},
+		{"month out of range", "0 0 1 13 *"},
+		{"day of week out of range", "0 0 * * 8"},
+		{"unknown name", "0 0 * foo *"},
+		{"reversed range", "0 0 * * 5-1"},
+		{"zero step", "*/0 * * * *"},
+		{"empty list item", "1,,2 * * * *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.expr); err == nil {
+				t.Errorf("Parse(%q) returned no error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2024-01-10 is a Wednesday.
+	from := time.Date(2024, time.January, 10, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", from, time.Date(2024, 1, 10, 10, 8, 0, 0, time.UTC)},
+		{"strictly after a match", "8 10 * * *", time.Date(2024, 1, 10, 10, 8, 0, 0, time.UTC), time.Date(2024, 1, 11, 10, 8, 0, 0, time.UTC)},
+		{"minute step", "*/15 * * * *", from, time.Date(2024, 1, 10, 10, 15, 0, 0, time.UTC)},
+		{"step over a range", "10-40/10 * * * *", from, time.Date(2024, 1, 10, 10, 10, 0, 0, time.UTC)},
+		{"step from a value", "5/20 * * * *", from, time.Date(2024, 1, 10, 10, 25, 0, 0, time.UTC)},
+		{"hour range", "0 13-15 * * *", from, time.Date(2024, 1, 10, 13, 0, 0, 0, time.UTC)},
+		{"list", "0 6,18 * * *", from, time.Date(2024, 1, 10, 18, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 mar *", from, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"month name range", "0 0 1 JUN-AUG *", from, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"day of week name", "30 6 * * fri", from, time.Date(2024, 1, 12, 6, 30, 0, 0, time.UTC)},
+		{"day of week name range", "0 9 * * mon-tue", from, time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{"day of week 7 is sunday", "0 0 * * 7", from, time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"day of week 0 is sunday", "0 0 * * 0", from, time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		// Both restricted: the 20th or any Monday, whichever comes first.
+		{"day of month or day of week", "0 0 20 * mon", from, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"day of month or day of week, dom first", "0 0 11 * mon", from, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		// Only the day of month restricted: the day of week does not widen it.
+		{"day of month only", "0 0 20 * *", from, time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", from, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"year rollover", "0 0 1 1 *", from, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"macro", "@daily", from, time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{"never matches", "0 0 31 2 *", from, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%q, %s) = %s, want %s", tt.expr, tt.from, got, tt.want)
+			}
+		})
+	}
+}
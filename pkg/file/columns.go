@@ -0,0 +1,68 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column is a column of an import sheet, found by matching its header against Aliases case-insensitively.
+// The first alias is the header written on exports.
+type Column struct {
+	Field    string
+	Aliases  []string
+	Required bool
+}
+
+// LocateColumns maps the field of every column to its position in header; columns that are not present map to -1.
+// It fails when a required column is missing.
+func LocateColumns(header []string, columns []Column) (map[string]int, error) {
+	index := make(map[string]int, len(columns))
+	var missing []string
+	for _, col := range columns {
+		index[col.Field] = -1
+	found:
+		for i, name := range header {
+			name = strings.TrimSpace(name)
+			for _, alias := range col.Aliases {
+				if strings.EqualFold(name, alias) {
+					index[col.Field] = i
+					break found
+				}
+			}
+		}
+		if index[col.Field] < 0 && col.Required {
+			missing = append(missing, col.Aliases[0])
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing column(s): %s", strings.Join(missing, ", "))
+	}
+	return index, nil
+}
+
+// HeaderRow returns the export header of columns.
+func HeaderRow(columns []Column) []string {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Aliases[0]
+	}
+	return header
+}
+
+// Cell returns the trimmed value at idx, or an empty string when the row is shorter or idx is negative.
+func Cell(row []string, idx int) string {
+	if idx < 0 || idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}
+
+// IsBlankRow reports whether every cell of row is empty.
+func IsBlankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
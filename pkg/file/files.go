@@ -15,7 +15,16 @@ func ReadExcelRows(data []byte, sheetIndex, minRow int) ([][]string, error) {
 	}
 	defer f.Close()
 
-	sheetName := f.GetSheetName(sheetIndex)
+	return readSheetRows(f, f.GetSheetName(sheetIndex), minRow)
+}
+
+// ReadExcelSheetRows reads the rows of the named sheet, or of the first sheet when sheetName is empty.
+// Large files should be read with NewRowSource(FormatXLSX, r).Iterate instead.
+func ReadExcelSheetRows(data []byte, sheetName string, minRow int) ([][]string, error) {
+	return xlsxSource{r: bytes.NewReader(data)}.Rows(sheetName, minRow)
+}
+
+func readSheetRows(f *excelize.File, sheetName string, minRow int) ([][]string, error) {
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read rows: %w", err)
@@ -26,3 +35,68 @@ func ReadExcelRows(data []byte, sheetIndex, minRow int) ([][]string, error) {
 
 	return rows, nil
 }
+
+// ExcelDropdown restricts the cells of a column (0-based) between FromRow and ToRow (1-based) to a list of values.
+type ExcelDropdown struct {
+	Column  int
+	Values  []string
+	FromRow int
+	ToRow   int
+}
+
+// WriteExcelRows builds a single-sheet workbook from the given rows.
+func WriteExcelRows(sheetName string, rows [][]string) ([]byte, error) {
+	return WriteExcelRowsWithDropdowns(sheetName, rows, nil)
+}
+
+// WriteExcelRowsWithDropdowns builds a single-sheet workbook and adds a drop-down list validation per dropdown.
+func WriteExcelRowsWithDropdowns(sheetName string, rows [][]string, dropdowns []ExcelDropdown) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	if err := f.SetSheetName(f.GetSheetName(0), sheetName); err != nil {
+		return nil, fmt.Errorf("failed to set sheet name: %w", err)
+	}
+
+	for i, row := range rows {
+		values := make([]interface{}, len(row))
+		for j, v := range row {
+			values[j] = v
+		}
+		cellRef, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.SetSheetRow(sheetName, cellRef, &values); err != nil {
+			return nil, fmt.Errorf("failed to write row %d: %w", i+1, err)
+		}
+	}
+
+	for _, d := range dropdowns {
+		from, err := excelize.CoordinatesToCellName(d.Column+1, d.FromRow)
+		if err != nil {
+			return nil, err
+		}
+		to, err := excelize.CoordinatesToCellName(d.Column+1, d.ToRow)
+		if err != nil {
+			return nil, err
+		}
+		dv := excelize.NewDataValidation(true)
+		dv.SetSqref(from + ":" + to)
+		if err := dv.SetDropList(d.Values); err != nil {
+			return nil, fmt.Errorf("failed to set drop-down list: %w", err)
+		}
+		if err := f.AddDataValidation(sheetName, dv); err != nil {
+			return nil, fmt.Errorf("failed to add data validation: %w", err)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write excel file: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,414 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Supported tabular upload formats.
+const (
+	FormatXLSX = "xlsx"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// RowSource returns the rows of an uploaded file as strings, the first row being the header.
+// sheetName is only meaningful for formats with several sheets.
+type RowSource interface {
+	// Iterate reads the rows one at a time, so memory use does not grow with the number of rows.
+	Iterate(sheetName string) (RowIterator, error)
+	// Rows reads every row at once and fails when there are fewer than minRow rows.
+	Rows(sheetName string, minRow int) ([][]string, error)
+}
+
+// RowIterator walks the rows of a file. Row is only valid until the next call to Next.
+type RowIterator interface {
+	Next() bool
+	Row() []string
+	Err() error
+	Close() error
+}
+
+// RowNumberer is implemented by iterators whose rows are not one per line of the file: the CSV reader
+// skips blank lines and a quoted cell may span several lines. RowNumber is the line the current row starts on.
+type RowNumberer interface {
+	RowNumber() int
+}
+
+var rowSources = map[string]func(r io.Reader) RowSource{
+	FormatXLSX: func(r io.Reader) RowSource { return xlsxSource{r: r} },
+	FormatCSV:  func(r io.Reader) RowSource { return csvSource{r: r} },
+	FormatJSON: func(r io.Reader) RowSource { return jsonSource{r: r} },
+}
+
+// RegisterRowSource adds or replaces the reader used for a format.
+func RegisterRowSource(format string, factory func(r io.Reader) RowSource) {
+	rowSources[strings.ToLower(format)] = factory
+}
+
+// NewRowSource returns the reader registered for the format. A source can only be read once.
+func NewRowSource(format string, r io.Reader) (RowSource, error) {
+	factory, ok := rowSources[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported file format: %s", format)
+	}
+	return factory(r), nil
+}
+
+// DetectFormat picks the format from the file extension, falling back to the content type.
+func DetectFormat(fileName, contentType string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), ".")) {
+	case "xlsx", "xlsm":
+		return FormatXLSX, nil
+	case "csv", "txt":
+		return FormatCSV, nil
+	case "json":
+		return FormatJSON, nil
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch mediaType {
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return FormatXLSX, nil
+	case "text/csv", "application/csv", "text/plain":
+		return FormatCSV, nil
+	case "application/json", "text/json":
+		return FormatJSON, nil
+	}
+
+	return "", errors.New("unsupported file format, expected XLSX, CSV or JSON")
+}
+
+// collectRows drains it into memory.
+func collectRows(it RowIterator, minRow int, kind string) ([][]string, error) {
+	defer it.Close()
+
+	var rows [][]string
+	for it.Next() {
+		row := make([]string, len(it.Row()))
+		copy(row, it.Row())
+		rows = append(rows, row)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) < minRow {
+		return nil, fmt.Errorf("%s has no data rows", kind)
+	}
+	return rows, nil
+}
+
+type xlsxSource struct {
+	r io.Reader
+}
+
+// Iterate opens the named sheet, or the first sheet when sheetName is empty, with excelize's row reader.
+// Large worksheets are unpacked to a temporary file by excelize instead of being held in memory.
+func (s xlsxSource) Iterate(sheetName string) (RowIterator, error) {
+	f, err := excelize.OpenReader(s.r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read excel file: %w", err)
+	}
+
+	if sheetName == "" {
+		sheetName = f.GetSheetName(0)
+	} else if idx, err := f.GetSheetIndex(sheetName); err != nil || idx < 0 {
+		f.Close()
+		return nil, fmt.Errorf("sheet %q not found", sheetName)
+	}
+
+	rows, err := f.Rows(sheetName)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return &xlsxIterator{f: f, rows: rows}, nil
+}
+
+func (s xlsxSource) Rows(sheetName string, minRow int) ([][]string, error) {
+	it, err := s.Iterate(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	return collectRows(it, minRow, "excel file")
+}
+
+// xlsxIterator yields the rows of a sheet; empty rows between data rows are returned as empty slices
+// so that row numbers keep matching the sheet.
+type xlsxIterator struct {
+	f    *excelize.File
+	rows *excelize.Rows
+	row  []string
+	err  error
+}
+
+func (it *xlsxIterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		return false
+	}
+	it.row, it.err = it.rows.Columns()
+	if it.err != nil {
+		it.err = fmt.Errorf("failed to read rows: %w", it.err)
+		return false
+	}
+	return true
+}
+
+func (it *xlsxIterator) Row() []string { return it.row }
+func (it *xlsxIterator) Err() error    { return it.err }
+
+func (it *xlsxIterator) Close() error {
+	rowsErr := it.rows.Close()
+	if err := it.f.Close(); err != nil {
+		return err
+	}
+	return rowsErr
+}
+
+// csvSource reads comma or semicolon separated files; the delimiter is taken from the header line.
+type csvSource struct {
+	r io.Reader
+}
+
+func (s csvSource) Iterate(_ string) (RowIterator, error) {
+	br := bufio.NewReaderSize(s.r, 64*1024)
+	if bom, _ := br.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	// The header line is looked at without consuming it; a header longer than the buffer keeps the comma.
+	firstLine, _ := br.Peek(br.Size())
+	if idx := bytes.IndexByte(firstLine, '\n'); idx >= 0 {
+		firstLine = firstLine[:idx]
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	return &csvIterator{reader: reader}, nil
+}
+
+func (s csvSource) Rows(sheetName string, minRow int) ([][]string, error) {
+	it, err := s.Iterate(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	return collectRows(it, minRow, "csv file")
+}
+
+type csvIterator struct {
+	reader *csv.Reader
+	row    []string
+	line   int
+	err    error
+}
+
+func (it *csvIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	row, err := it.reader.Read()
+	if err != nil {
+		if err != io.EOF {
+			it.err = fmt.Errorf("failed to read csv file: %w", err)
+		}
+		return false
+	}
+	it.row = row
+	it.line, _ = it.reader.FieldPos(0)
+	return true
+}
+
+func (it *csvIterator) Row() []string  { return it.row }
+func (it *csvIterator) RowNumber() int { return it.line }
+func (it *csvIterator) Err() error     { return it.err }
+func (it *csvIterator) Close() error   { return nil }
+
+// jsonSource reads a JSON array of objects or an array of arrays whose first element is the header.
+// For objects, the header is made of the keys of the first object in document order; keys that only
+// appear in later objects are ignored.
+type jsonSource struct {
+	r io.Reader
+}
+
+func (s jsonSource) Iterate(_ string) (RowIterator, error) {
+	br := bufio.NewReader(s.r)
+	if bom, _ := br.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read json payload, expected an array: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("failed to read json payload, expected an array")
+	}
+	return &jsonIterator{dec: dec}, nil
+}
+
+func (s jsonSource) Rows(sheetName string, minRow int) ([][]string, error) {
+	it, err := s.Iterate(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	return collectRows(it, minRow, "json payload")
+}
+
+type jsonIterator struct {
+	dec     *json.Decoder
+	item    int
+	objects bool
+	header  []string
+	columns map[string]int
+	pending []string // first object, returned after the header row
+	row     []string
+	err     error
+}
+
+func (it *jsonIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pending != nil {
+		it.row, it.pending = it.pending, nil
+		return true
+	}
+	if !it.dec.More() {
+		return false
+	}
+
+	var raw json.RawMessage
+	if err := it.dec.Decode(&raw); err != nil {
+		it.err = fmt.Errorf("item %d: %w", it.item+1, err)
+		return false
+	}
+	it.item++
+
+	if it.item == 1 {
+		it.objects = !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("["))
+		if it.objects {
+			keys, err := jsonObjectKeys(raw)
+			if err != nil {
+				it.err = fmt.Errorf("item 1: expected an object: %w", err)
+				return false
+			}
+			it.header = keys
+			it.columns = make(map[string]int, len(keys))
+			for i, k := range keys {
+				it.columns[k] = i
+			}
+			if it.pending, it.err = it.objectRow(raw); it.err != nil {
+				return false
+			}
+			it.row = it.header
+			return true
+		}
+	}
+
+	if it.objects {
+		it.row, it.err = it.objectRow(raw)
+	} else {
+		it.row, it.err = it.arrayRow(raw)
+	}
+	return it.err == nil
+}
+
+func (it *jsonIterator) objectRow(raw json.RawMessage) ([]string, error) {
+	var record map[string]interface{}
+	if err := decodeJSON(raw, &record); err != nil {
+		return nil, fmt.Errorf("item %d: expected an object: %w", it.item, err)
+	}
+	row := make([]string, len(it.header))
+	for k, v := range record {
+		if idx, ok := it.columns[k]; ok {
+			row[idx] = jsonValueString(v)
+		}
+	}
+	return row, nil
+}
+
+func (it *jsonIterator) arrayRow(raw json.RawMessage) ([]string, error) {
+	var values []interface{}
+	if err := decodeJSON(raw, &values); err != nil {
+		return nil, fmt.Errorf("item %d: expected an array: %w", it.item, err)
+	}
+	row := make([]string, len(values))
+	for j, v := range values {
+		row[j] = jsonValueString(v)
+	}
+	return row, nil
+}
+
+func (it *jsonIterator) Row() []string { return it.row }
+func (it *jsonIterator) Err() error    { return it.err }
+func (it *jsonIterator) Close() error  { return nil }
+
+func decodeJSON(raw json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// jsonObjectKeys returns the keys of a JSON object in document order.
+func jsonObjectKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("not an object")
+	}
+
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		keys = append(keys, key)
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func jsonValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		if val {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
@@ -0,0 +1,62 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// TempFile is an upload spooled to local disk. Close removes the file.
+type TempFile struct {
+	*os.File
+	Size int64
+	Hash string // hex encoded sha256 of the content
+}
+
+// SpoolTemp copies r to a temporary file while hashing it, so that large uploads are never held in memory.
+// It fails when r is larger than maxBytes; a maxBytes of zero or less means no limit.
+// The returned file is positioned at its start.
+func SpoolTemp(r io.Reader, maxBytes int64) (*TempFile, error) {
+	f, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp := &TempFile{File: f}
+
+	src := r
+	if maxBytes > 0 {
+		src = io.LimitReader(r, maxBytes+1)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), src)
+	if err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if maxBytes > 0 && size > maxBytes {
+		tmp.Close()
+		return nil, fmt.Errorf("file exceeds the maximum upload size of %d MB", maxBytes>>20)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+
+	tmp.Size = size
+	tmp.Hash = hex.EncodeToString(hash.Sum(nil))
+	return tmp, nil
+}
+
+// Rewind positions the file at its start so that it can be read again.
+func (t *TempFile) Rewind() error {
+	_, err := t.Seek(0, io.SeekStart)
+	return err
+}
+
+func (t *TempFile) Close() error {
+	err := t.File.Close()
+	_ = os.Remove(t.Name())
+	return err
+}
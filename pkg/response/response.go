@@ -46,7 +46,7 @@ func Response(code int, msg string, logId uuid.UUID, data interface{}) *ApiRespo
 	res.Id = logId
 	res.Message = msg
 	res.Data = data
-	res.Status = code == http.StatusOK || code == http.StatusCreated
+	res.Status = code == http.StatusOK || code == http.StatusCreated || code == http.StatusAccepted
 
 	return res
 }
@@ -14,6 +14,9 @@ type StorageProvider interface {
 	// UploadFileFromBytes uploads file from byte array and returns the public URL
 	UploadFileFromBytes(ctx context.Context, data []byte, filename string, folder string, contentType string) (string, error)
 
+	// UploadFileFromReader streams size bytes from reader and returns the public URL; size may be -1 when unknown
+	UploadFileFromReader(ctx context.Context, reader io.Reader, size int64, filename string, folder string, contentType string) (string, error)
+
 	// DeleteFile deletes a file using its URL
 	DeleteFile(ctx context.Context, fileURL string) error
 
@@ -22,6 +25,9 @@ type StorageProvider interface {
 
 	// DownloadFile downloads a file and returns a ReadCloser
 	DownloadFile(ctx context.Context, objectName string) (io.ReadCloser, error)
+
+	// ObjectName returns the object name of a URL returned by an upload
+	ObjectName(fileURL string) string
 }
 
 // Config holds the configuration for storage providers
@@ -118,6 +118,30 @@ func (m *MinIOAdapter) UploadFileFromBytes(ctx context.Context, data []byte, fil
 	return fileURL, nil
 }
 
+func (m *MinIOAdapter) UploadFileFromReader(ctx context.Context, reader io.Reader, size int64, filename string, folder string, contentType string) (string, error) {
+	ext := filepath.Ext(filename)
+	uniqueFilename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), uuid.New().String()[:8], ext)
+
+	objectName := uniqueFilename
+	if folder != "" {
+		objectName = fmt.Sprintf("%s/%s", strings.Trim(folder, "/"), uniqueFilename)
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	_, err := m.client.PutObject(ctx, m.bucketName, objectName, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	fileURL := fmt.Sprintf("%s/%s/%s", m.baseURL, m.bucketName, objectName)
+	return fileURL, nil
+}
+
 func (m *MinIOAdapter) DeleteFile(ctx context.Context, fileURL string) error {
 	objectName := m.extractObjectName(fileURL)
 	if objectName == "" {
@@ -144,6 +168,10 @@ func (m *MinIOAdapter) DownloadFile(ctx context.Context, objectName string) (io.
 	return object, nil
 }
 
+func (m *MinIOAdapter) ObjectName(fileURL string) string {
+	return m.extractObjectName(fileURL)
+}
+
 func (m *MinIOAdapter) extractObjectName(fileURL string) string {
 	parts := strings.Split(fileURL, "/")
 	if len(parts) < 2 {
@@ -106,6 +106,30 @@ func (r *R2Adapter) UploadFileFromBytes(ctx context.Context, data []byte, filena
 	return fileURL, nil
 }
 
+func (r *R2Adapter) UploadFileFromReader(ctx context.Context, reader io.Reader, size int64, filename string, folder string, contentType string) (string, error) {
+	ext := filepath.Ext(filename)
+	uniqueFilename := fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), uuid.New().String()[:8], ext)
+
+	objectName := uniqueFilename
+	if folder != "" {
+		objectName = fmt.Sprintf("%s/%s", strings.Trim(folder, "/"), uniqueFilename)
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	_, err := r.client.PutObject(ctx, r.bucketName, objectName, reader, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file to R2: %w", err)
+	}
+
+	fileURL := fmt.Sprintf("%s/%s", strings.TrimRight(r.baseURL, "/"), objectName)
+	return fileURL, nil
+}
+
 func (r *R2Adapter) DeleteFile(ctx context.Context, fileURL string) error {
 	objectName := r.extractObjectName(fileURL)
 	if objectName == "" {
@@ -132,6 +156,10 @@ func (r *R2Adapter) DownloadFile(ctx context.Context, objectName string) (io.Rea
 	return object, nil
 }
 
+func (r *R2Adapter) ObjectName(fileURL string) string {
+	return r.extractObjectName(fileURL)
+}
+
 func (r *R2Adapter) extractObjectName(fileURL string) string {
 	objectName := strings.TrimPrefix(fileURL, r.baseURL)
 	objectName = strings.TrimPrefix(objectName, "/")
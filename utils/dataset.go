@@ -1,5 +1,7 @@
 package utils
 
+import "sort"
+
 var AllowedDatasetTypes = map[string]bool{
 	DatasetQuiz:          true,
 	DatasetLoginApple:    true,
@@ -17,9 +19,109 @@ var AllowedPeriodFrequencies = map[string]bool{
 	PeriodYearly:    true,
 }
 
+// DatasetMetricTables maps each dataset type to the table its rows are imported into.
+var DatasetMetricTables = map[string]string{
+	DatasetQuiz:          "quiz_results",
+	DatasetLoginApple:    "apple_logins",
+	DatasetSalesFLP:      "sales_flp",
+	DatasetPointApple:    "apple_points",
+	DatasetPointMyHero:   "myhero_points",
+	DatasetTotalProspect: "prospects",
+}
+
+// DatasetMetricTableNames returns every table holding rows imported from a dataset, sorted.
+func DatasetMetricTableNames() []string {
+	tables := make([]string, 0, len(DatasetMetricTables))
+	for _, table := range DatasetMetricTables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// DatasetDailyOnlyTypes are the dataset types whose rows describe a single day, so they can only be uploaded
+// DAILY: an Apple login row is the login of the day of its period date.
+var DatasetDailyOnlyTypes = map[string]bool{
+	DatasetLoginApple: true,
+}
+
+// DatasetSystemActor is recorded as the uploader of datasets imported without a user, e.g. by the directory watcher.
+const DatasetSystemActor = "system"
+
 const (
 	DatasetStatusUploaded   = "UPLOADED"
 	DatasetStatusProcessing = "PROCESSING"
 	DatasetStatusDone       = "DONE"
 	DatasetStatusFailed     = "FAILED"
+	DatasetStatusSuperseded = "SUPERSEDED"
+	DatasetStatusArchived   = "ARCHIVED"
+)
+
+// DatasetStatusDeleted is recorded in the status history when a dataset is deleted; no dataset keeps it.
+const DatasetStatusDeleted = "DELETED"
+
+// DatasetStatusTransitions lists the statuses a dataset may move to from each status.
+// ARCHIVED is final; DONE datasets leave the active set by being superseded or deleted. A SUPERSEDED
+// dataset goes back to UPLOADED when the dataset that superseded it is deleted.
+var DatasetStatusTransitions = map[string][]string{
+	DatasetStatusUploaded:   {DatasetStatusProcessing, DatasetStatusFailed, DatasetStatusArchived},
+	DatasetStatusProcessing: {DatasetStatusDone, DatasetStatusFailed},
+	DatasetStatusDone:       {DatasetStatusSuperseded, DatasetStatusUploaded},
+	DatasetStatusFailed:     {DatasetStatusProcessing, DatasetStatusUploaded, DatasetStatusArchived},
+	DatasetStatusSuperseded: {DatasetStatusUploaded, DatasetStatusArchived},
+	DatasetStatusArchived:   {},
+}
+
+// DatasetManualStatuses are the statuses an admin may set directly; the others are set by the import flow.
+var DatasetManualStatuses = map[string]bool{
+	DatasetStatusFailed:   true,
+	DatasetStatusArchived: true,
+}
+
+// CanTransitionDatasetStatus reports whether a dataset may move from one status to another.
+func CanTransitionDatasetStatus(from, to string) bool {
+	for _, next := range DatasetStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Behaviour of the evaluation when datasets required for a period are missing
+const (
+	EvaluationCoverageStrict = "strict" // refuse to calculate unless forced
+	EvaluationCoverageWarn   = "warn"   // calculate and report the missing datasets
+)
+
+// Where the dataset types required for a period come from
+const (
+	RequiredDatasetsPeriod  = "PERIOD"  // required_datasets of the evaluation period
+	RequiredDatasetsDefault = "DEFAULT" // EVALUATION_REQUIRED_DATASETS
+)
+
+// Outcome of a dataset connector run
+const (
+	ConnectorRunRunning = "RUNNING"
+	ConnectorRunSuccess = "SUCCESS"
+	ConnectorRunFailed  = "FAILED"
+	ConnectorRunSkipped = "SKIPPED" // the endpoint returned a file that was already imported
+)
+
+// What started a dataset connector run
+const (
+	ConnectorTriggerSchedule = "SCHEDULE"
+	ConnectorTriggerManual   = "MANUAL"
+)
+
+const (
+	QuarantineStatusPending   = "PENDING"
+	QuarantineStatusResolved  = "RESOLVED"
+	QuarantineStatusDiscarded = "DISCARDED"
+)
+
+const (
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	ContentTypeCSV  = "text/csv"
+	ContentTypeJSON = "application/json"
 )
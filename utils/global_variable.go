@@ -37,6 +37,22 @@ const (
 	PeriodYearly    = "YEARLY"
 )
 
+// KPI scoring strategies, see the scoring registry of the evaluation
+const (
+	ScoringLinearCapped = "LINEAR_CAPPED" // achievement ratio times the weight, capped at 100%
+	ScoringLinearBonus  = "LINEAR_BONUS"  // like LINEAR_CAPPED with a higher cap rewarding over-achievement
+	ScoringStepped      = "STEPPED"       // share of the weight of the highest achievement tier reached
+	ScoringPassFail     = "PASS_FAIL"     // full weight from a threshold, nothing below
+	ScoringInverse      = "INVERSE"       // lower is better, full weight up to the target
+)
+
+// Sources of the target a KPI is scored against, from the most to the least specific
+const (
+	TargetSourcePerson = "PERSON" // person_kpi_targets of the period
+	TargetSourceDealer = "DEALER" // dealer_kpi_targets of the dealer of the person
+	TargetSourceKPI    = "KPI"    // target_value of the KPI item
+)
+
 var AllowedRoles = map[string]bool{
 	RoleSuperAdmin: true,
 	RoleAdmin:      true,
@@ -49,6 +65,7 @@ const (
 	EntityTLActivity = "tl_activity"
 	EntityTLCoaching = "tl_coaching"
 	EntityTLBriefing = "tl_briefing"
+	EntityDataset    = "dataset"
 )
 
 const (